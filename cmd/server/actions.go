@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"dice-service/internal/monsters"
+)
+
+type actionRollRequest struct {
+	Advantage string `json:"advantage"` // normal, advantage, disadvantage
+	TargetAC  int    `json:"targetAC"`
+}
+
+// rollMonsterAction бросает атаку и урон действия монстра из библиотеки.
+// Карточка не изменяется: перезарядка и число использований за день
+// хранятся в экземплярах монстра.
+func (s *server) rollMonsterAction(w http.ResponseWriter, r *http.Request, id, name string) {
+	// Тело запроса необязательно
+	var payload actionRollRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	monster, err := s.monsterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result, _, err := monsters.RollAction(monster, name, monsters.ActionRollOptions{
+		Mode:     payload.Advantage,
+		TargetAC: payload.TargetAC,
+	})
+	if err != nil {
+		writeActionRollError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeActionRollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, monsters.ErrActionNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, monsters.ErrActionUnavailable):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,867 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/combat"
+	"dice-service/internal/company"
+	"dice-service/internal/conditions"
+	"dice-service/internal/dice"
+	"dice-service/internal/encounters"
+	"dice-service/internal/events"
+	"dice-service/internal/monsters"
+)
+
+type createCombatRequest struct {
+	Name            string             `json:"name"`
+	EncounterID     string             `json:"encounterId,omitempty"` // монстры из сохранённого столкновения
+	Characters      []string           `json:"characters,omitempty"`  // пусто - все персонажи компании
+	Monsters        []encounters.Group `json:"monsters,omitempty"`
+	HPMode          string             `json:"hpMode,omitempty"`          // хиты новых экземпляров: fixed, average, roll, max
+	Instances       []string           `json:"instances,omitempty"`       // экземпляры монстров из компании
+	GroupInitiative bool               `json:"groupInitiative,omitempty"` // одинаковые монстры бросают инициативу вместе
+}
+
+type participantStateRequest struct {
+	Amount     int    `json:"amount,omitempty"`     // damage, heal
+	Type       string `json:"type,omitempty"`       // damage: тип урона для сопротивлений и иммунитетов
+	Cost       int    `json:"cost,omitempty"`       // legendary: стоимость действия, 0 - одно
+	Initiative *int   `json:"initiative,omitempty"` // initiative: новое значение
+}
+
+// combatView бой в ответах API: журнал со снимками состояния доступен
+// только через /combats/{id}/log и в ответ не включается.
+type combatView struct {
+	combat.Combat
+	Log []combat.LogEntry `json:"log,omitempty"`
+}
+
+func viewCombat(c combat.Combat) combatView {
+	return combatView{Combat: c}
+}
+
+// combatLogResponse журнал боя: записи без снимков состояния и рассказ.
+type combatLogResponse struct {
+	CombatID  string            `json:"combatId"`
+	Entries   []combat.LogEntry `json:"entries"`
+	Narrative []string          `json:"narrative"`
+}
+
+type combatActionResponse struct {
+	Combat combatView                `json:"combat"`
+	Result monsters.ActionRollResult `json:"result"`
+}
+
+type combatCommandRequest struct {
+	ParticipantID string `json:"participantId,omitempty"`
+	Resume        bool   `json:"resume,omitempty"`  // delay: участник возвращается в бой
+	Action        string `json:"action,omitempty"`  // ready: подготовленное действие
+	Trigger       string `json:"trigger,omitempty"` // ready: его триггер
+	Use           bool   `json:"use,omitempty"`     // ready: участник выполняет подготовленное действие
+}
+
+// handleCompanyCombats обрабатывает бои компании:
+//
+//	GET, POST  .../combats
+func (s *server) handleCompanyCombats(w http.ResponseWriter, r *http.Request, companyID string, rest []string) {
+	if len(rest) != 0 {
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+	comp, err := s.companyStore.Get(companyID)
+	if err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list := s.combatStore.List(companyID)
+		views := make([]combatView, 0, len(list))
+		for _, c := range list {
+			views = append(views, viewCombat(c))
+		}
+		writeJSON(w, http.StatusOK, views)
+	case http.MethodPost:
+		s.createCombat(w, r, comp)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// createCombat начинает бой: бросает инициативу персонажам компании
+// и монстрам и упорядочивает участников.
+func (s *server) createCombat(w http.ResponseWriter, r *http.Request, comp company.Company) {
+	var payload createCombatRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	groups := payload.Monsters
+	if payload.EncounterID != "" {
+		e, err := s.encounterStore.Get(payload.EncounterID)
+		if err != nil || e.CompanyID != comp.ID {
+			writeError(w, http.StatusNotFound, "encounter not found")
+			return
+		}
+		groups = append(groups, e.Monsters...)
+		if payload.Name == "" {
+			payload.Name = e.Name
+		}
+	}
+
+	// Бонус инициативы и Ловкость берутся из текущего листа персонажа,
+	// копия в компании может устареть
+	var participants []combat.Participant
+	for _, sheet := range comp.Characters {
+		if len(payload.Characters) == 0 || slices.Contains(payload.Characters, sheet.ID) {
+			current, err := s.characterStore.Get(sheet.ID)
+			if err != nil && !errors.Is(err, characters.ErrNotFound) {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if err == nil {
+				sheet = current
+			}
+			participants = append(participants, combat.CharacterParticipant(sheet))
+		}
+	}
+	for _, id := range payload.Characters {
+		if !slices.ContainsFunc(comp.Characters, func(c characters.CharacterSheet) bool { return c.ID == id }) {
+			writeError(w, http.StatusBadRequest, "character "+id+" is not in this company")
+			return
+		}
+	}
+	if len(groups) > 0 {
+		if err := encounters.ValidateGroups(groups); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Экземпляры из компании копируются в бой, новые создаются по группам
+	lookup := s.encounterMonsterLookup(comp)
+	var instances []monsters.Instance
+	byMonster := make(map[string][]monsters.Instance)
+	var order []string
+	for _, id := range payload.Instances {
+		index := slices.IndexFunc(comp.Instances, func(inst monsters.Instance) bool { return inst.ID == id })
+		if index < 0 {
+			writeError(w, http.StatusBadRequest, "monster instance "+id+" is not in this company")
+			return
+		}
+		inst := comp.Instances[index]
+		if _, seen := byMonster[inst.MonsterID]; !seen {
+			order = append(order, inst.MonsterID)
+		}
+		byMonster[inst.MonsterID] = append(byMonster[inst.MonsterID], inst)
+		instances = append(instances, inst)
+	}
+	for _, g := range groups {
+		m, err := lookup(g.MonsterID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "monster "+g.MonsterID+": "+err.Error())
+			return
+		}
+		created, err := monsters.NewInstances(m, g.Count, payload.HPMode, instances)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "monster "+g.MonsterID+": "+err.Error())
+			return
+		}
+		if _, seen := byMonster[m.ID]; !seen {
+			order = append(order, m.ID)
+		}
+		byMonster[m.ID] = append(byMonster[m.ID], created...)
+		instances = append(instances, created...)
+	}
+	for _, monsterID := range order {
+		m, err := lookup(monsterID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "monster "+monsterID+": "+err.Error())
+			return
+		}
+		participants = append(participants, combat.MonsterParticipants(m, byMonster[monsterID], payload.GroupInitiative)...)
+	}
+
+	c, err := combat.New(comp.ID, strings.TrimSpace(payload.Name), participants, rollInitiativeD20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	created, err := s.combatStore.Create(c)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.publish(comp.ID, events.TypeEntityAdded, entityEvent{Kind: "combat", ID: created.ID, Name: created.Name})
+	setETag(w, created.Version)
+	writeJSON(w, http.StatusCreated, viewCombat(created))
+}
+
+// handleCombatByID обрабатывает бой:
+//
+//	GET, DELETE  /combats/{id}
+//	GET          /combats/{id}/log
+//	POST         /combats/{id}/(next|previous|delay|ready|undo|redo)
+//	POST         /combats/{id}/participants/{participantId}/(damage|heal|reaction|legendary|initiative)
+//	POST         /combats/{id}/participants/{participantId}/actions/{name}/roll
+//	...          /combats/{id}/participants/{participantId}/conditions/...
+func (s *server) handleCombatByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/combats/"), "/")
+	id := parts[0]
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing combat id")
+		return
+	}
+
+	c, err := s.combatStore.Get(id)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "log" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		entries := make([]combat.LogEntry, 0, len(c.Log))
+		for _, e := range c.Log {
+			e.Before, e.After = nil, nil
+			entries = append(entries, e)
+		}
+		writeJSON(w, http.StatusOK, combatLogResponse{CombatID: c.ID, Entries: entries, Narrative: c.Narrative()})
+		return
+	}
+	if len(parts) >= 4 && parts[1] == "participants" && parts[3] == "conditions" {
+		s.updateCombatConditions(w, r, c, parts[2], parts[4:])
+		return
+	}
+	if len(parts) == 6 && parts[1] == "participants" && parts[3] == "actions" && parts[5] == "roll" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.rollCombatAction(w, r, c, parts[2], parts[4])
+		return
+	}
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.runCombatCommand(w, r, c, parts[1])
+		return
+	}
+	if len(parts) == 4 && parts[1] == "participants" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.updateCombatParticipant(w, r, c, parts[2], parts[3])
+		return
+	}
+	if len(parts) > 2 {
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		setETag(w, c.Version)
+		writeJSON(w, http.StatusOK, viewCombat(c))
+	case http.MethodDelete:
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.combatStore.Delete(id, version); err != nil {
+			writeCombatError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"message": "combat deleted"})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// runCombatCommand меняет очерёдность ходов и сохраняет бой.
+// Тело запроса необязательно для next и previous.
+func (s *server) runCombatCommand(w http.ResponseWriter, r *http.Request, c combat.Combat, command string) {
+	var payload combatCommandRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		c.Version = version
+	}
+
+	before := c.Snapshot()
+	actor := c.Current().Name
+	var summary string
+	var readied *combat.ReadiedAction
+	switch command {
+	case "next":
+		err = c.Next()
+		summary = fmt.Sprintf("%s ends the turn, %s acts", actor, c.Current().Name)
+	case "previous":
+		err = c.Previous()
+		summary = fmt.Sprintf("turn goes back from %s to %s", actor, c.Current().Name)
+	case "undo":
+		_, err = c.Undo()
+	case "redo":
+		_, err = c.Redo()
+	case "delay":
+		if payload.Resume {
+			err = c.Resume(payload.ParticipantID)
+			summary = fmt.Sprintf("%s stops delaying and acts", c.Current().Name)
+			break
+		}
+		if payload.ParticipantID != "" && payload.ParticipantID != c.Current().ID {
+			writeError(w, http.StatusConflict, "only the current participant can delay")
+			return
+		}
+		err = c.Delay()
+		summary = fmt.Sprintf("%s delays, %s acts", actor, c.Current().Name)
+	case "ready":
+		if payload.Use {
+			var action combat.ReadiedAction
+			action, err = c.UseReady(payload.ParticipantID)
+			readied = &action
+			if p, ok := participantByID(before.Participants, payload.ParticipantID); ok {
+				summary = fmt.Sprintf("%s uses the readied action: %s", p.Name, action.Action)
+			}
+			break
+		}
+		if payload.ParticipantID != "" && payload.ParticipantID != c.Current().ID {
+			writeError(w, http.StatusConflict, "only the current participant can ready an action")
+			return
+		}
+		if strings.TrimSpace(payload.Action) == "" || strings.TrimSpace(payload.Trigger) == "" {
+			writeError(w, http.StatusBadRequest, "action and trigger are required")
+			return
+		}
+		err = c.Ready(payload.Action, payload.Trigger)
+		summary = fmt.Sprintf("%s readies %s (trigger: %s), %s acts", actor, payload.Action, payload.Trigger, c.Current().Name)
+	default:
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+	if err != nil {
+		if errors.Is(err, combat.ErrParticipantNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if command != "undo" && command != "redo" {
+		c.Record(command, summary, before)
+	}
+
+	saved, err := s.combatStore.Update(c)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+	s.syncRosterInstances(saved)
+	if command == "undo" || command == "redo" {
+		s.syncCombatCharacters(saved)
+		s.publishRestored(saved, before)
+	} else if readied == nil {
+		current := saved.Current()
+		s.publish(saved.CompanyID, events.TypeTurnAdvanced, turnEvent{
+			CombatID:      saved.ID,
+			Command:       command,
+			Round:         saved.Round,
+			ParticipantID: current.ID,
+			Name:          current.Name,
+		})
+	}
+	setETag(w, saved.Version)
+	if readied != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"combat": viewCombat(saved), "readied": readied})
+		return
+	}
+	writeJSON(w, http.StatusOK, viewCombat(saved))
+}
+
+// updateCombatParticipant меняет состояние экземпляра монстра в бою:
+// хиты, реакцию и легендарные действия. У персонажа меняются только
+// хиты, они переносятся в его лист.
+func (s *server) updateCombatParticipant(w http.ResponseWriter, r *http.Request, c combat.Combat, participantID, command string) {
+	var payload participantStateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		c.Version = version
+	}
+
+	if payload.Amount < 0 || payload.Cost < 0 {
+		writeError(w, http.StatusBadRequest, "amount and cost must not be negative")
+		return
+	}
+
+	snapshot := c.Snapshot()
+	if command == "initiative" {
+		s.changeInitiative(w, c, snapshot, participantID, payload.Initiative)
+		return
+	}
+	if p, ok := participantByID(c.Participants, participantID); ok && p.Kind == combat.KindCharacter && (command == "damage" || command == "heal") {
+		s.changeCharacterHitPoints(w, c, p, command, payload.Amount)
+		return
+	}
+
+	inst, err := c.Instance(participantID)
+	if err != nil {
+		if errors.Is(err, combat.ErrParticipantNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before := inst.CurrentHitPoints + inst.TemporaryHitPoints
+	var summary string
+	switch command {
+	case "damage":
+		amount, adjustment := payload.Amount, ""
+		if payload.Type != "" {
+			mon, err := s.combatMonster(c.CompanyID, inst.MonsterID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			amount, adjustment = monsters.AdjustDamage(mon, amount, payload.Type)
+		}
+		err = inst.Damage(amount)
+		summary = fmt.Sprintf("%s takes %d damage (%d → %d HP)", inst.Name, amount, before, inst.CurrentHitPoints+inst.TemporaryHitPoints)
+		if adjustment != "" {
+			summary += fmt.Sprintf(", %s to %s", adjustment, payload.Type)
+		}
+	case "heal":
+		err = inst.Heal(payload.Amount)
+		summary = fmt.Sprintf("%s heals %d (%d → %d HP)", inst.Name, payload.Amount, before, inst.CurrentHitPoints+inst.TemporaryHitPoints)
+	case "reaction":
+		err = inst.UseReaction()
+		summary = fmt.Sprintf("%s uses a reaction", inst.Name)
+	case "legendary":
+		err = inst.UseLegendaryAction(max(1, payload.Cost))
+		summary = fmt.Sprintf("%s uses a legendary action (cost %d)", inst.Name, max(1, payload.Cost))
+	default:
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	c.Record(command, summary, snapshot)
+
+	saved, err := s.combatStore.Update(c)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+	s.syncRosterInstances(saved)
+	if command == "damage" || command == "heal" {
+		s.publish(saved.CompanyID, events.TypeHPChanged, hpEvent{
+			CombatID:           saved.ID,
+			ParticipantID:      participantID,
+			InstanceID:         inst.ID,
+			Name:               inst.Name,
+			Change:             inst.CurrentHitPoints + inst.TemporaryHitPoints - before,
+			CurrentHitPoints:   inst.CurrentHitPoints,
+			MaxHitPoints:       inst.MaxHitPoints,
+			TemporaryHitPoints: inst.TemporaryHitPoints,
+		})
+	}
+	setETag(w, saved.Version)
+	writeJSON(w, http.StatusOK, viewCombat(saved))
+}
+
+// rollCombatAction бросает действие экземпляра монстра в бою. Перезарядка
+// и число использований хранятся в экземпляре участника, поэтому
+// экземпляры, созданные только для боя, тоже их учитывают. Бросок
+// записывается в журнал, и отмена возвращает действию перезарядку.
+func (s *server) rollCombatAction(w http.ResponseWriter, r *http.Request, c combat.Combat, participantID, name string) {
+	var payload actionRollRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		c.Version = version
+	}
+
+	snapshot := c.Snapshot()
+	inst, err := c.Instance(participantID)
+	if err != nil {
+		if errors.Is(err, combat.ErrParticipantNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if inst.Defeated() {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s: %s is defeated", monsters.ErrActionUnavailable, inst.Name))
+		return
+	}
+	mon, err := s.combatMonster(c.CompanyID, inst.MonsterID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, updated, err := monsters.RollAction(inst.Apply(mon), name, monsters.ActionRollOptions{
+		Mode:     payload.Advantage,
+		TargetAC: payload.TargetAC,
+	})
+	if err != nil {
+		writeActionRollError(w, err)
+		return
+	}
+	if updated != nil {
+		inst.Capture(*updated)
+	}
+	c.Record("action", fmt.Sprintf("%s uses %s", inst.Name, name), snapshot)
+
+	saved, err := s.combatStore.Update(c)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+	s.syncRosterInstances(saved)
+	s.publish(saved.CompanyID, events.TypeRoll, rollEvent{
+		CombatID:      saved.ID,
+		ParticipantID: participantID,
+		InstanceID:    inst.ID,
+		Name:          inst.Name,
+		Action:        name,
+		Result:        &result,
+	})
+	setETag(w, saved.Version)
+	writeJSON(w, http.StatusOK, combatActionResponse{Combat: viewCombat(saved), Result: result})
+}
+
+// changeCharacterHitPoints наносит урон или лечит участника-персонажа.
+// Хиты из листа сначала записываются в участника, чтобы снимок журнала
+// до команды их содержал и отмена вернула лист к ним.
+func (s *server) changeCharacterHitPoints(w http.ResponseWriter, c combat.Combat, p combat.Participant, command string, amount int) {
+	sheet, err := s.characterStore.Get(p.RefID)
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	i := slices.IndexFunc(c.Participants, func(q combat.Participant) bool { return q.ID == p.ID })
+	vitals := combat.VitalsOf(sheet)
+	c.Participants[i].Vitals = &vitals
+	snapshot := c.Snapshot()
+
+	var changed characters.CharacterSheet
+	var summary string
+	before := sheet.CurrentHitPoints + sheet.TemporaryHitPoints
+	if command == "damage" {
+		changed, _, err = characters.ApplyDamage(sheet, amount, false)
+		summary = fmt.Sprintf("%s takes %d damage (%d → %d HP)", p.Name, amount, before, changed.CurrentHitPoints+changed.TemporaryHitPoints)
+	} else {
+		changed, err = characters.ApplyHealing(sheet, amount)
+		summary = fmt.Sprintf("%s heals %d (%d → %d HP)", p.Name, amount, before, changed.CurrentHitPoints+changed.TemporaryHitPoints)
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	vitals = combat.VitalsOf(changed)
+	c.Participants[i].Vitals = &vitals
+	c.Record(command, summary, snapshot)
+
+	saved, err := s.combatStore.Update(c)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+	s.syncCombatCharacters(saved)
+	s.publish(saved.CompanyID, events.TypeHPChanged, hpEvent{
+		CombatID:           saved.ID,
+		ParticipantID:      p.ID,
+		CharacterID:        p.RefID,
+		Name:               p.Name,
+		Change:             vitals.CurrentHitPoints + vitals.TemporaryHitPoints - before,
+		CurrentHitPoints:   vitals.CurrentHitPoints,
+		MaxHitPoints:       vitals.MaxHitPoints,
+		TemporaryHitPoints: vitals.TemporaryHitPoints,
+	})
+	setETag(w, saved.Version)
+	writeJSON(w, http.StatusOK, viewCombat(saved))
+}
+
+// syncCombatCharacters переносит хиты участников-персонажей в их листы,
+// например после отмены урона. Ошибка записи не отменяет изменение боя.
+func (s *server) syncCombatCharacters(c combat.Combat) {
+	for _, p := range c.Participants {
+		if p.Kind != combat.KindCharacter || p.Vitals == nil {
+			continue
+		}
+		sheet, err := s.characterStore.Get(p.RefID)
+		if err != nil {
+			if !errors.Is(err, characters.ErrNotFound) {
+				log.Printf("failed to sync character %s from combat %s: %v", p.RefID, c.ID, err)
+			}
+			continue
+		}
+		// Максимум хитов лист хранит свой
+		current := combat.VitalsOf(sheet)
+		current.MaxHitPoints = p.Vitals.MaxHitPoints
+		if current == *p.Vitals {
+			continue
+		}
+		if _, err := s.characterStore.UpdateLabeled(p.RefID, p.Vitals.Apply(sheet), "combat "+c.Name); err != nil {
+			log.Printf("failed to sync character %s from combat %s: %v", p.RefID, c.ID, err)
+		}
+	}
+}
+
+// changeInitiative меняет инициативу участника; порядок ходов
+// пересчитывается, ход остаётся за текущим участником.
+func (s *server) changeInitiative(w http.ResponseWriter, c combat.Combat, before combat.State, participantID string, initiative *int) {
+	if initiative == nil {
+		writeError(w, http.StatusBadRequest, "initiative is required")
+		return
+	}
+	p, ok := participantByID(c.Participants, participantID)
+	if !ok {
+		writeError(w, http.StatusNotFound, combat.ErrParticipantNotFound.Error()+": "+participantID)
+		return
+	}
+	if err := c.SetInitiative(participantID, *initiative); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	c.Record("initiative", fmt.Sprintf("%s's initiative changes from %d to %d", p.Name, p.Initiative, *initiative), before)
+
+	saved, err := s.combatStore.Update(c)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+	setETag(w, saved.Version)
+	writeJSON(w, http.StatusOK, viewCombat(saved))
+}
+
+// updateCombatConditions накладывает, снимает и отсчитывает состояния
+// участника боя так же, как для экземпляров монстров компании.
+func (s *server) updateCombatConditions(w http.ResponseWriter, r *http.Request, c combat.Combat, participantID string, rest []string) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		c.Version = version
+	}
+
+	before := c.Snapshot()
+	list, err := c.Conditions(participantID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	outcome, code, err := applyConditionRequest(r, *list, rest)
+	if err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	*list = outcome.list
+
+	p, _ := participantByID(c.Participants, participantID)
+	var summary string
+	switch {
+	case outcome.applied != nil:
+		summary = fmt.Sprintf("%s is %s", p.Name, outcome.applied.Name)
+	case rest[0] == "tick":
+		summary = fmt.Sprintf("%s's conditions tick down", p.Name)
+		for _, expired := range outcome.expired {
+			summary += fmt.Sprintf(", %s ends", expired.Name)
+		}
+	case len(rest) == 1:
+		summary = fmt.Sprintf("%s is no longer %s", p.Name, rest[0])
+	case outcome.ended:
+		summary = fmt.Sprintf("%s saves and is no longer %s", p.Name, rest[0])
+	default:
+		summary = fmt.Sprintf("%s fails a save against %s", p.Name, rest[0])
+	}
+	c.Record("condition", summary, before)
+
+	saved, err := s.combatStore.Update(c)
+	if err != nil {
+		writeCombatError(w, err)
+		return
+	}
+	s.syncRosterInstances(saved)
+	if outcome.applied != nil {
+		s.publish(saved.CompanyID, events.TypeCondition, conditionEvent{
+			Kind:       "participant",
+			ID:         p.ID,
+			Name:       p.Name,
+			Condition:  *outcome.applied,
+			Conditions: outcome.list,
+		})
+	}
+	setETag(w, saved.Version)
+	writeJSON(w, http.StatusOK, viewCombat(saved))
+}
+
+// publishRestored после отмены или повтора рассылает события, по которым
+// клиенты приводят своё состояние к восстановленному: изменения хитов,
+// новые состояния и смену хода.
+func (s *server) publishRestored(c combat.Combat, before combat.State) {
+	for _, p := range c.Participants {
+		old, ok := participantByID(before.Participants, p.ID)
+		if !ok {
+			continue
+		}
+		if p.Instance != nil && old.Instance != nil {
+			hp, oldHP := p.Instance.CurrentHitPoints+p.Instance.TemporaryHitPoints, old.Instance.CurrentHitPoints+old.Instance.TemporaryHitPoints
+			if hp != oldHP || p.Instance.CurrentHitPoints != old.Instance.CurrentHitPoints {
+				s.publish(c.CompanyID, events.TypeHPChanged, hpEvent{
+					CombatID:           c.ID,
+					ParticipantID:      p.ID,
+					InstanceID:         p.Instance.ID,
+					Name:               p.Name,
+					Change:             hp - oldHP,
+					CurrentHitPoints:   p.Instance.CurrentHitPoints,
+					MaxHitPoints:       p.Instance.MaxHitPoints,
+					TemporaryHitPoints: p.Instance.TemporaryHitPoints,
+				})
+			}
+		}
+		if p.Vitals != nil && old.Vitals != nil && *p.Vitals != *old.Vitals {
+			hp, oldHP := p.Vitals.CurrentHitPoints+p.Vitals.TemporaryHitPoints, old.Vitals.CurrentHitPoints+old.Vitals.TemporaryHitPoints
+			s.publish(c.CompanyID, events.TypeHPChanged, hpEvent{
+				CombatID:           c.ID,
+				ParticipantID:      p.ID,
+				CharacterID:        p.RefID,
+				Name:               p.Name,
+				Change:             hp - oldHP,
+				CurrentHitPoints:   p.Vitals.CurrentHitPoints,
+				MaxHitPoints:       p.Vitals.MaxHitPoints,
+				TemporaryHitPoints: p.Vitals.TemporaryHitPoints,
+			})
+		}
+		current, previous := participantConditions(p), participantConditions(old)
+		for _, cond := range current {
+			if !slices.ContainsFunc(previous, func(o conditions.Condition) bool { return o.Name == cond.Name }) {
+				s.publish(c.CompanyID, events.TypeCondition, conditionEvent{
+					Kind:       "participant",
+					ID:         p.ID,
+					Name:       p.Name,
+					Condition:  cond,
+					Conditions: current,
+				})
+			}
+		}
+	}
+	if c.Round != before.Round || c.Turn != before.Turn || c.Current().ID != before.Participants[before.Turn].ID {
+		current := c.Current()
+		s.publish(c.CompanyID, events.TypeTurnAdvanced, turnEvent{
+			CombatID:      c.ID,
+			Command:       "restore",
+			Round:         c.Round,
+			ParticipantID: current.ID,
+			Name:          current.Name,
+		})
+	}
+}
+
+// participantConditions состояния участника: экземпляра монстра или
+// самого участника-персонажа.
+func participantConditions(p combat.Participant) []conditions.Condition {
+	if p.Instance != nil {
+		return p.Instance.Conditions
+	}
+	return p.Conditions
+}
+
+func participantByID(participants []combat.Participant, id string) (combat.Participant, bool) {
+	for _, p := range participants {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return combat.Participant{}, false
+}
+
+// combatMonster карточка монстра участника боя: копия из компании
+// или монстр из общего хранилища.
+func (s *server) combatMonster(companyID, monsterID string) (monsters.Monster, error) {
+	comp, err := s.companyStore.Get(companyID)
+	if err != nil {
+		return monsters.Monster{}, err
+	}
+	return s.encounterMonsterLookup(comp)(monsterID)
+}
+
+// rollInitiativeD20 бросок d20 для инициативы.
+func rollInitiativeD20() (int, error) {
+	result, err := dice.RollD20(dice.ModeNormal)
+	if err != nil {
+		return 0, err
+	}
+	return result.Natural, nil
+}
+
+func writeCombatError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, combat.ErrNotFound):
+		writeError(w, http.StatusNotFound, "combat not found")
+	case errors.Is(err, combat.ErrVersionConflict):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
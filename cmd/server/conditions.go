@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/company"
+	"dice-service/internal/conditions"
+	"dice-service/internal/events"
+	"dice-service/internal/monsters"
+)
+
+type tickConditionsRequest struct {
+	Rounds int `json:"rounds"`
+}
+
+type conditionSaveRequest struct {
+	Total int `json:"total"`
+}
+
+type characterConditionsResponse struct {
+	Character characters.CharacterSheet `json:"character"`
+	Derived   characters.DerivedStats   `json:"derived"`
+	Expired   []conditions.Condition    `json:"expired,omitempty"`
+	Ended     bool                      `json:"ended,omitempty"`
+}
+
+type monsterConditionsResponse struct {
+	Monster monsters.Monster       `json:"monster"`
+	Speed   string                 `json:"speed"`
+	Effects conditions.Effects     `json:"effects"`
+	Expired []conditions.Condition `json:"expired,omitempty"`
+	Ended   bool                   `json:"ended,omitempty"`
+}
+
+// conditionOutcome результат операции над списком состояний.
+type conditionOutcome struct {
+	list    []conditions.Condition
+	applied *conditions.Condition // наложенное состояние
+	expired []conditions.Condition
+	ended   bool
+}
+
+// applyConditionRequest выполняет операцию над списком состояний по остатку пути:
+//
+//	POST   .../conditions              - наложить состояние
+//	POST   .../conditions/tick         - отсчитать раунды
+//	DELETE .../conditions/{name}       - снять состояние
+//	POST   .../conditions/{name}/save  - результат спасброска против состояния
+//
+// При ошибке возвращает HTTP-код ответа.
+func applyConditionRequest(r *http.Request, list []conditions.Condition, rest []string) (conditionOutcome, int, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	switch {
+	case len(rest) == 0:
+		if r.Method != http.MethodPost {
+			return conditionOutcome{}, http.StatusMethodNotAllowed, errors.New("method not allowed")
+		}
+		var c conditions.Condition
+		if err := decoder.Decode(&c); err != nil {
+			return conditionOutcome{}, http.StatusBadRequest, errors.New("invalid JSON payload")
+		}
+		updated, err := conditions.Apply(list, c)
+		if err != nil {
+			return conditionOutcome{}, http.StatusBadRequest, err
+		}
+		return conditionOutcome{list: updated, applied: &c}, http.StatusOK, nil
+
+	case len(rest) == 1 && rest[0] == "tick":
+		if r.Method != http.MethodPost {
+			return conditionOutcome{}, http.StatusMethodNotAllowed, errors.New("method not allowed")
+		}
+		var payload tickConditionsRequest
+		if err := decoder.Decode(&payload); err != nil {
+			return conditionOutcome{}, http.StatusBadRequest, errors.New("invalid JSON payload")
+		}
+		if payload.Rounds < 1 {
+			return conditionOutcome{}, http.StatusBadRequest, errors.New("rounds must be at least 1")
+		}
+		remaining, expired := conditions.Tick(list, payload.Rounds)
+		return conditionOutcome{list: remaining, expired: expired}, http.StatusOK, nil
+
+	case len(rest) == 1:
+		if r.Method != http.MethodDelete {
+			return conditionOutcome{}, http.StatusMethodNotAllowed, errors.New("method not allowed")
+		}
+		updated, found := conditions.Remove(list, rest[0])
+		if !found {
+			return conditionOutcome{}, http.StatusNotFound, errors.New("condition not found")
+		}
+		return conditionOutcome{list: updated}, http.StatusOK, nil
+
+	case len(rest) == 2 && rest[1] == "save":
+		if r.Method != http.MethodPost {
+			return conditionOutcome{}, http.StatusMethodNotAllowed, errors.New("method not allowed")
+		}
+		var payload conditionSaveRequest
+		if err := decoder.Decode(&payload); err != nil {
+			return conditionOutcome{}, http.StatusBadRequest, errors.New("invalid JSON payload")
+		}
+		updated, ended, err := conditions.ResolveSave(list, rest[0], payload.Total)
+		if err != nil {
+			return conditionOutcome{}, http.StatusBadRequest, err
+		}
+		return conditionOutcome{list: updated, ended: ended}, http.StatusOK, nil
+	}
+
+	return conditionOutcome{}, http.StatusNotFound, errors.New("404 page not found")
+}
+
+func (s *server) handleCharacterConditions(w http.ResponseWriter, r *http.Request, id string, rest []string) {
+	sheet, ok := s.characterForAction(w, r, id)
+	if !ok {
+		return
+	}
+
+	outcome, code, err := applyConditionRequest(r, sheet.Conditions, rest)
+	if err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	sheet.Conditions = outcome.list
+
+	updated, err := s.characterStore.UpdateLabeled(id, sheet, "conditions")
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, characterConditionsResponse{
+		Character: updated,
+		Derived:   characters.Derive(updated),
+		Expired:   outcome.expired,
+		Ended:     outcome.ended,
+	})
+}
+
+func (s *server) getCharacterDerived(w http.ResponseWriter, r *http.Request, id string) {
+	sheet, err := s.characterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, characters.Derive(sheet))
+}
+
+func (s *server) handleCompanyMonsterConditions(w http.ResponseWriter, r *http.Request, companyID, monsterID string, rest []string) {
+	comp, err := s.companyStore.Get(companyID)
+	if err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var mon monsters.Monster
+	found := false
+	for _, m := range comp.Monsters {
+		if m.ID == monsterID {
+			mon = m
+			found = true
+			break
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "monster not found")
+		return
+	}
+
+	outcome, code, err := applyConditionRequest(r, mon.Conditions, rest)
+	if err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	mon.Conditions = outcome.list
+
+	if err := s.companyStore.UpdateMonster(companyID, mon); err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		if errors.Is(err, company.ErrMonsterNotInCompany) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if outcome.applied != nil {
+		s.publish(companyID, events.TypeCondition, conditionEvent{
+			Kind:       "monster",
+			ID:         mon.ID,
+			Name:       mon.Name,
+			Condition:  *outcome.applied,
+			Conditions: mon.Conditions,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, monsterConditionsResponse{
+		Monster: mon,
+		Speed:   monsters.EffectiveSpeed(mon),
+		Effects: conditions.Summarize(mon.Conditions),
+		Expired: outcome.expired,
+		Ended:   outcome.ended,
+	})
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"dice-service/internal/company"
+	"dice-service/internal/encounters"
+	"dice-service/internal/events"
+	"dice-service/internal/monsters"
+)
+
+type evaluateEncounterRequest struct {
+	EncounterID string             `json:"encounterId,omitempty"`
+	Monsters    []encounters.Group `json:"monsters,omitempty"`
+}
+
+type generateEncountersRequest struct {
+	Difficulty  string `json:"difficulty"`
+	Type        string `json:"type,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	MaxMonsters int    `json:"maxMonsters,omitempty"`
+	Count       int    `json:"count,omitempty"` // число вариантов
+	Seed        int64  `json:"seed,omitempty"`  // 0 - случайное зерно
+}
+
+// encounterView столкновение вместе с оценкой сложности для текущего отряда.
+type encounterView struct {
+	encounters.Encounter
+	Evaluation *encounters.Evaluation `json:"evaluation,omitempty"`
+}
+
+// handleCompanyEncounters обрабатывает столкновения компании:
+//
+//	GET, POST         .../encounters
+//	POST              .../encounters/evaluate
+//	POST              .../encounters/generate
+//	GET, PUT, DELETE  .../encounters/{encounterId}
+func (s *server) handleCompanyEncounters(w http.ResponseWriter, r *http.Request, companyID string, rest []string) {
+	comp, err := s.companyStore.Get(companyID)
+	if err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch {
+	case len(rest) == 0:
+		switch r.Method {
+		case http.MethodGet:
+			list := s.encounterStore.List(companyID)
+			views := make([]encounterView, 0, len(list))
+			for _, e := range list {
+				views = append(views, s.viewEncounter(comp, e))
+			}
+			writeJSON(w, http.StatusOK, views)
+		case http.MethodPost:
+			s.createEncounter(w, r, comp)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case len(rest) == 1 && rest[0] == "evaluate":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.evaluateEncounter(w, r, comp)
+	case len(rest) == 1 && rest[0] == "generate":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.generateEncounters(w, r, comp)
+	case len(rest) == 1:
+		s.handleEncounterByID(w, r, comp, rest[0])
+	default:
+		writeError(w, http.StatusNotFound, "404 page not found")
+	}
+}
+
+// evaluateEncounter оценивает сохранённое столкновение или переданный
+// список монстров против персонажей компании.
+func (s *server) evaluateEncounter(w http.ResponseWriter, r *http.Request, comp company.Company) {
+	var payload evaluateEncounterRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	groups := payload.Monsters
+	if payload.EncounterID != "" {
+		e, err := s.encounterStore.Get(payload.EncounterID)
+		if err != nil || e.CompanyID != comp.ID {
+			writeError(w, http.StatusNotFound, "encounter not found")
+			return
+		}
+		groups = e.Monsters
+	}
+
+	eval, err := s.evaluate(comp, groups)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, eval)
+}
+
+// generateEncounters подбирает случайные столкновения из каталога монстров
+// под отряд компании. Зерно возвращается в ответе, чтобы варианты можно
+// было получить повторно.
+func (s *server) generateEncounters(w http.ResponseWriter, r *http.Request, comp company.Company) {
+	var payload generateEncountersRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if payload.Seed == 0 {
+		// Зерно должно точно представляться числом в JSON
+		payload.Seed = time.Now().UnixNano() & (1<<53 - 1)
+	}
+
+	page, err := s.monsterStore.Find(monsters.Query{Type: payload.Type, Environment: payload.Environment})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	levels := make([]int, 0, len(comp.Characters))
+	for _, c := range comp.Characters {
+		levels = append(levels, c.Level)
+	}
+
+	candidates, err := encounters.Generate(levels, page.Items, encounters.GenerateOptions{
+		Difficulty:  payload.Difficulty,
+		MaxMonsters: payload.MaxMonsters,
+		Candidates:  payload.Count,
+		Seed:        payload.Seed,
+	})
+	if err != nil {
+		if errors.Is(err, encounters.ErrNoCandidates) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"seed":       payload.Seed,
+		"candidates": candidates,
+	})
+}
+
+func (s *server) createEncounter(w http.ResponseWriter, r *http.Request, comp company.Company) {
+	var e encounters.Encounter
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&e); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	e.ID = ""
+	e.CompanyID = comp.ID
+	if _, err := encounters.Resolve(e.Monsters, s.encounterMonsterLookup(comp)); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := s.encounterStore.Create(e)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.publish(comp.ID, events.TypeEntityAdded, entityEvent{Kind: "encounter", ID: created.ID, Name: created.Name})
+	setETag(w, created.Version)
+	writeJSON(w, http.StatusCreated, s.viewEncounter(comp, created))
+}
+
+func (s *server) handleEncounterByID(w http.ResponseWriter, r *http.Request, comp company.Company, id string) {
+	existing, err := s.encounterStore.Get(id)
+	if err != nil || existing.CompanyID != comp.ID {
+		if err != nil && !errors.Is(err, encounters.ErrNotFound) {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeError(w, http.StatusNotFound, "encounter not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		setETag(w, existing.Version)
+		writeJSON(w, http.StatusOK, s.viewEncounter(comp, existing))
+	case http.MethodPut:
+		var e encounters.Encounter
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&e); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON payload")
+			return
+		}
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if version != 0 {
+			e.Version = version
+		}
+		e.ID = id
+		e.CompanyID = comp.ID
+		if _, err := encounters.Resolve(e.Monsters, s.encounterMonsterLookup(comp)); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		updated, err := s.encounterStore.Update(e)
+		if err != nil {
+			writeEncounterError(w, err)
+			return
+		}
+		setETag(w, updated.Version)
+		writeJSON(w, http.StatusOK, s.viewEncounter(comp, updated))
+	case http.MethodDelete:
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.encounterStore.Delete(id, version); err != nil {
+			writeEncounterError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"message": "encounter deleted"})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// evaluate оценивает группы монстров против персонажей компании.
+func (s *server) evaluate(comp company.Company, groups []encounters.Group) (encounters.Evaluation, error) {
+	combatants, err := encounters.Resolve(groups, s.encounterMonsterLookup(comp))
+	if err != nil {
+		return encounters.Evaluation{}, err
+	}
+	levels := make([]int, 0, len(comp.Characters))
+	for _, c := range comp.Characters {
+		levels = append(levels, c.Level)
+	}
+	return encounters.Evaluate(levels, combatants)
+}
+
+// viewEncounter добавляет к столкновению оценку; если оценить нельзя
+// (в компании нет персонажей), оценка опускается.
+func (s *server) viewEncounter(comp company.Company, e encounters.Encounter) encounterView {
+	view := encounterView{Encounter: e}
+	if eval, err := s.evaluate(comp, e.Monsters); err == nil {
+		view.Evaluation = &eval
+	}
+	return view
+}
+
+// encounterMonsterLookup ищет монстра в каталоге, затем среди монстров компании.
+func (s *server) encounterMonsterLookup(comp company.Company) func(string) (monsters.Monster, error) {
+	return func(id string) (monsters.Monster, error) {
+		m, err := s.monsterStore.Get(id)
+		if err == nil || !errors.Is(err, monsters.ErrNotFound) {
+			return m, err
+		}
+		for _, m := range comp.Monsters {
+			if m.ID == id {
+				return m, nil
+			}
+		}
+		return monsters.Monster{}, monsters.ErrNotFound
+	}
+}
+
+func writeEncounterError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, encounters.ErrNotFound):
+		writeError(w, http.StatusNotFound, "encounter not found")
+	case errors.Is(err, encounters.ErrVersionConflict):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must contain a single entity tag")
+
+// setETag отдаёт версию ресурса в заголовке ETag.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// noMatchVersion не совпадает ни с одной сохранённой версией: хранилища
+// отвечают на неё ErrVersionConflict.
+const noMatchVersion = -1
+
+// ifMatchVersion разбирает заголовок If-Match. Отсутствующий заголовок или "*"
+// дают версию 0 - сохранение без проверки версии. If-Match сравнивает теги
+// строго (RFC 9110, 13.1.1), поэтому слабый тег не совпадает ни с чем.
+func ifMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(value, "W/") {
+		return noMatchVersion, nil
+	}
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/company"
+	"dice-service/internal/conditions"
+	"dice-service/internal/events"
+	"dice-service/internal/monsters"
+)
+
+// heartbeatInterval период комментариев SSE, не дающих прокси закрыть
+// простаивающее соединение.
+const heartbeatInterval = 15 * time.Second
+
+// entityEvent данные события entity.added.
+type entityEvent struct {
+	Kind string `json:"kind"` // character, monster, instance, encounter, combat
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// hpEvent данные события hp.changed.
+type hpEvent struct {
+	CombatID           string `json:"combatId,omitempty"`
+	ParticipantID      string `json:"participantId,omitempty"`
+	InstanceID         string `json:"instanceId,omitempty"`
+	CharacterID        string `json:"characterId,omitempty"`
+	Name               string `json:"name"`
+	Change             int    `json:"change"` // урон отрицательный, лечение положительное
+	CurrentHitPoints   int    `json:"currentHitPoints"`
+	MaxHitPoints       int    `json:"maxHitPoints"`
+	TemporaryHitPoints int    `json:"temporaryHitPoints,omitempty"`
+}
+
+// turnEvent данные события turn.advanced.
+type turnEvent struct {
+	CombatID      string `json:"combatId"`
+	Command       string `json:"command"`
+	Round         int    `json:"round"`
+	ParticipantID string `json:"participantId"`
+	Name          string `json:"name"`
+}
+
+// conditionEvent данные события condition.applied.
+type conditionEvent struct {
+	Kind       string                 `json:"kind"` // monster, instance, participant
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Condition  conditions.Condition   `json:"condition"`
+	Conditions []conditions.Condition `json:"conditions"`
+}
+
+// rollEvent данные события roll.made. Бросок действия монстра
+// передаётся в Result, атака персонажа - в Attack.
+type rollEvent struct {
+	CombatID      string                     `json:"combatId,omitempty"`
+	ParticipantID string                     `json:"participantId,omitempty"`
+	InstanceID    string                     `json:"instanceId,omitempty"`
+	CharacterID   string                     `json:"characterId,omitempty"`
+	Name          string                     `json:"name"`
+	Action        string                     `json:"action"`
+	Result        *monsters.ActionRollResult `json:"result,omitempty"`
+	Attack        *characters.AttackResult   `json:"attack,omitempty"`
+}
+
+// handleCompanyEvents отдаёт поток событий компании:
+//
+//	GET  .../events  (SSE, либо WebSocket при Upgrade: websocket)
+//
+// Переподключившийся клиент передаёт последний полученный ID в
+// заголовке Last-Event-ID или параметре lastEventId и получает
+// пропущенные события.
+func (s *server) handleCompanyEvents(w http.ResponseWriter, r *http.Request, companyID string, rest []string) {
+	if len(rest) != 0 {
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, err := s.companyStore.Get(companyID); err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	var lastEventID uint64
+	if lastID != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	if events.IsWebSocket(r) {
+		s.streamWebSocket(w, r, companyID, lastEventID)
+		return
+	}
+	s.streamSSE(w, r, companyID, lastEventID)
+}
+
+// streamSSE пишет события в формате text/event-stream, пока клиент
+// не отключится.
+func (s *server) streamSSE(w http.ResponseWriter, r *http.Request, companyID string, lastEventID uint64) {
+	rc := http.NewResponseController(w)
+	// Поток живёт дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sub, replay := s.events.Subscribe(companyID, lastEventID)
+	defer s.events.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range replay {
+		if err := writeSSEEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := writeSSEEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// streamWebSocket отправляет каждое событие отдельным текстовым
+// сообщением с JSON события.
+func (s *server) streamWebSocket(w http.ResponseWriter, r *http.Request, companyID string, lastEventID uint64) {
+	conn, err := events.Upgrade(w, r, s.allowedOrigins)
+	if err != nil {
+		if errors.Is(err, events.ErrOriginNotAllowed) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer conn.Close()
+
+	sub, replay := s.events.Subscribe(companyID, lastEventID)
+	defer s.events.Unsubscribe(sub)
+
+	send := func(e events.Event) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return conn.WriteText(data)
+	}
+	for _, e := range replay {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(events.PingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-conn.Closed():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := send(e); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// publish рассылает событие компании. Ошибка не мешает ответу на
+// запрос, изменение уже сохранено.
+func (s *server) publish(companyID, eventType string, data any) {
+	if _, err := s.events.Publish(companyID, eventType, data); err != nil {
+		log.Printf("publish %s event for company %s: %v", eventType, companyID, err)
+	}
+}
+
+// publishCharacter рассылает событие персонажа всем компаниям, в
+// которых он состоит.
+func (s *server) publishCharacter(characterID, eventType string, data any) {
+	page, err := s.companyStore.Find(company.Query{CharacterID: characterID})
+	if err != nil {
+		log.Printf("find companies of character %s for %s event: %v", characterID, eventType, err)
+		return
+	}
+	for _, comp := range page.Items {
+		s.publish(comp.ID, eventType, data)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dice-service/internal/characters"
+)
+
+// handleCharacterHistory обрабатывает историю изменений персонажа:
+//
+//	GET .../history        - список ревизий
+//	GET .../history/{rev}  - ревизия с полным листом
+func (s *server) handleCharacterHistory(w http.ResponseWriter, r *http.Request, id string, rest []string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch len(rest) {
+	case 0:
+		history, err := s.characterStore.History(id)
+		if err != nil {
+			writeCharacterHistoryError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, history)
+	case 1:
+		rev, err := strconv.Atoi(rest[0])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "revision must be a number")
+			return
+		}
+		revision, err := s.characterStore.Revision(id, rev)
+		if err != nil {
+			writeCharacterHistoryError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, revision)
+	default:
+		writeError(w, http.StatusNotFound, "404 page not found")
+	}
+}
+
+// revertCharacter восстанавливает лист персонажа из ревизии. Откат сам
+// записывается новой ревизией, поэтому его тоже можно отменить.
+func (s *server) revertCharacter(w http.ResponseWriter, r *http.Request, id, revParam string) {
+	rev, err := strconv.Atoi(revParam)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "revision must be a number")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	revision, err := s.characterStore.Revision(id, rev)
+	if err != nil {
+		writeCharacterHistoryError(w, err)
+		return
+	}
+	// Версия берётся из If-Match, а не из сохранённой ревизии
+	revision.Sheet.Version = version
+
+	updated, err := s.characterStore.UpdateLabeled(id, revision.Sheet, characters.RevertLabel(rev))
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func writeCharacterHistoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, characters.ErrNotFound):
+		writeError(w, http.StatusNotFound, "character not found")
+	case errors.Is(err, characters.ErrRevisionNotFound):
+		writeError(w, http.StatusNotFound, "revision not found")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"dice-service/internal/combat"
+	"dice-service/internal/company"
+	"dice-service/internal/conditions"
+	"dice-service/internal/events"
+	"dice-service/internal/monsters"
+)
+
+type hitPointsRequest struct {
+	Amount int    `json:"amount"`
+	Type   string `json:"type,omitempty"` // damage: тип урона для сопротивлений и иммунитетов
+}
+
+type instanceConditionsResponse struct {
+	Instance monsters.Instance      `json:"instance"`
+	Effects  conditions.Effects     `json:"effects"`
+	Expired  []conditions.Condition `json:"expired,omitempty"`
+	Ended    bool                   `json:"ended,omitempty"`
+}
+
+type instanceActionResponse struct {
+	Instance monsters.Instance         `json:"instance"`
+	Result   monsters.ActionRollResult `json:"result"`
+}
+
+// handleCompanyInstances обрабатывает экземпляры монстров компании:
+//
+//	GET          .../instances
+//	GET, DELETE  .../instances/{instanceId}
+//	POST         .../instances/{instanceId}/(damage|heal|reaction)
+//	POST         .../instances/{instanceId}/actions/{name}/roll
+//	...          .../instances/{instanceId}/conditions/...
+func (s *server) handleCompanyInstances(w http.ResponseWriter, r *http.Request, companyID string, rest []string) {
+	comp, err := s.companyStore.Get(companyID)
+	if err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if len(rest) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		instances := comp.Instances
+		if instances == nil {
+			instances = []monsters.Instance{}
+		}
+		writeJSON(w, http.StatusOK, instances)
+		return
+	}
+
+	index := slices.IndexFunc(comp.Instances, func(inst monsters.Instance) bool { return inst.ID == rest[0] })
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "monster instance not found")
+		return
+	}
+	inst := comp.Instances[index]
+
+	switch {
+	case len(rest) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, inst)
+		case http.MethodDelete:
+			if err := s.companyStore.RemoveMonsterInstance(companyID, inst.ID); err != nil {
+				writeInstanceError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"message": "monster instance removed from company"})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case rest[1] == "conditions":
+		var outcome conditionOutcome
+		code := http.StatusOK
+		inst, err := s.companyStore.UpdateMonsterInstance(companyID, inst.ID, func(i *monsters.Instance) error {
+			var err error
+			outcome, code, err = applyConditionRequest(r, i.Conditions, rest[2:])
+			if err != nil {
+				return err
+			}
+			i.Conditions = outcome.list
+			return nil
+		})
+		if err != nil {
+			if code != http.StatusOK {
+				writeError(w, code, err.Error())
+				return
+			}
+			writeInstanceError(w, err)
+			return
+		}
+		s.syncCombatInstance(companyID, inst, fmt.Sprintf("%s's conditions change in the company roster", inst.Name))
+		if outcome.applied != nil {
+			s.publish(companyID, events.TypeCondition, conditionEvent{
+				Kind:       "instance",
+				ID:         inst.ID,
+				Name:       inst.Name,
+				Condition:  *outcome.applied,
+				Conditions: inst.Conditions,
+			})
+		}
+		writeJSON(w, http.StatusOK, instanceConditionsResponse{
+			Instance: inst,
+			Effects:  conditions.Summarize(inst.Conditions),
+			Expired:  outcome.expired,
+			Ended:    outcome.ended,
+		})
+	case len(rest) == 4 && rest[1] == "actions" && rest[3] == "roll":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.rollInstanceAction(w, r, comp, inst, rest[2])
+	case len(rest) == 2:
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.updateInstanceState(w, r, comp, inst, rest[1])
+	default:
+		writeError(w, http.StatusNotFound, "404 page not found")
+	}
+}
+
+// updateInstanceState наносит урон, лечит или тратит реакцию экземпляра.
+// Урон с типом учитывает сопротивления и иммунитеты монстра.
+func (s *server) updateInstanceState(w http.ResponseWriter, r *http.Request, comp company.Company, inst monsters.Instance, command string) {
+	var payload hitPointsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	if payload.Amount < 0 {
+		writeError(w, http.StatusBadRequest, "amount must not be negative")
+		return
+	}
+	if command != "damage" && command != "heal" && command != "reaction" {
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+
+	amount := payload.Amount
+	if command == "damage" && payload.Type != "" {
+		mon, err := s.encounterMonsterLookup(comp)(inst.MonsterID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		amount, _ = monsters.AdjustDamage(mon, amount, payload.Type)
+	}
+
+	var before int
+	var stateErr error
+	inst, err := s.companyStore.UpdateMonsterInstance(comp.ID, inst.ID, func(i *monsters.Instance) error {
+		before = i.CurrentHitPoints + i.TemporaryHitPoints
+		switch command {
+		case "damage":
+			stateErr = i.Damage(amount)
+		case "heal":
+			stateErr = i.Heal(amount)
+		case "reaction":
+			stateErr = i.UseReaction()
+		}
+		return stateErr
+	})
+	if stateErr != nil {
+		writeError(w, http.StatusConflict, stateErr.Error())
+		return
+	}
+	if err != nil {
+		writeInstanceError(w, err)
+		return
+	}
+	after := inst.CurrentHitPoints + inst.TemporaryHitPoints
+	switch command {
+	case "damage":
+		s.syncCombatInstance(comp.ID, inst, fmt.Sprintf("%s takes %d damage in the company roster (%d → %d HP)", inst.Name, amount, before, after))
+	case "heal":
+		s.syncCombatInstance(comp.ID, inst, fmt.Sprintf("%s heals %d in the company roster (%d → %d HP)", inst.Name, amount, before, after))
+	case "reaction":
+		s.syncCombatInstance(comp.ID, inst, fmt.Sprintf("%s uses a reaction in the company roster", inst.Name))
+	}
+	if command == "damage" || command == "heal" {
+		s.publish(comp.ID, events.TypeHPChanged, hpEvent{
+			InstanceID:         inst.ID,
+			Name:               inst.Name,
+			Change:             after - before,
+			CurrentHitPoints:   inst.CurrentHitPoints,
+			MaxHitPoints:       inst.MaxHitPoints,
+			TemporaryHitPoints: inst.TemporaryHitPoints,
+		})
+	}
+	writeJSON(w, http.StatusOK, inst)
+}
+
+// rollInstanceAction бросает действие экземпляра: перезарядка и число
+// использований хранятся в экземпляре, а не в карточке монстра.
+// Побеждённый экземпляр (0 хитов) действовать не может.
+func (s *server) rollInstanceAction(w http.ResponseWriter, r *http.Request, comp company.Company, inst monsters.Instance, name string) {
+	var payload actionRollRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	mon, err := s.encounterMonsterLookup(comp)(inst.MonsterID)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var result monsters.ActionRollResult
+	var rollErr error
+	inst, err = s.companyStore.UpdateMonsterInstance(comp.ID, inst.ID, func(i *monsters.Instance) error {
+		if i.Defeated() {
+			rollErr = fmt.Errorf("%w: %s is defeated", monsters.ErrActionUnavailable, i.Name)
+			return rollErr
+		}
+		var updated *monsters.Monster
+		result, updated, rollErr = monsters.RollAction(i.Apply(mon), name, monsters.ActionRollOptions{
+			Mode:     payload.Advantage,
+			TargetAC: payload.TargetAC,
+		})
+		if rollErr != nil {
+			return rollErr
+		}
+		if updated != nil {
+			i.Capture(*updated)
+		}
+		return nil
+	})
+	if rollErr != nil {
+		writeActionRollError(w, rollErr)
+		return
+	}
+	if err != nil {
+		writeInstanceError(w, err)
+		return
+	}
+	s.syncCombatInstance(comp.ID, inst, fmt.Sprintf("%s uses %s in the company roster", inst.Name, name))
+	s.publish(comp.ID, events.TypeRoll, rollEvent{InstanceID: inst.ID, Name: inst.Name, Action: name, Result: &result})
+	writeJSON(w, http.StatusOK, instanceActionResponse{Instance: inst, Result: result})
+}
+
+// syncAttempts сколько раз синхронизация перечитывает бой, изменённый
+// параллельным запросом.
+const syncAttempts = 3
+
+// syncCombatInstance переносит состояние экземпляра из состава компании
+// в бои, где он участвует. Перенос записывается в журнал боя командой
+// roster, поэтому его можно отменить, как любую другую команду; бой,
+// изменённый параллельно, перечитывается, а не перезаписывается.
+func (s *server) syncCombatInstance(companyID string, inst monsters.Instance, summary string) {
+	for _, c := range s.combatStore.List(companyID) {
+		for attempt := 1; ; attempt++ {
+			before := c.Snapshot()
+			changed := false
+			for i, p := range c.Participants {
+				if p.Instance != nil && p.Instance.ID == inst.ID && !reflect.DeepEqual(*p.Instance, inst) {
+					synced := inst.Clone()
+					c.Participants[i].Instance = &synced
+					changed = true
+				}
+			}
+			if !changed {
+				break
+			}
+			c.Record("roster", summary, before)
+			_, err := s.combatStore.Update(c)
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, combat.ErrVersionConflict) || attempt == syncAttempts {
+				log.Printf("failed to sync monster instance %s into combat %s: %v", inst.ID, c.ID, err)
+				break
+			}
+			id := c.ID
+			if c, err = s.combatStore.Get(id); err != nil {
+				log.Printf("failed to sync monster instance %s into combat %s: %v", inst.ID, id, err)
+				break
+			}
+		}
+	}
+}
+
+// syncRosterInstances переносит состояние экземпляров из боя в состав
+// компании; экземпляры, созданные только для боя, пропускаются.
+func (s *server) syncRosterInstances(c combat.Combat) {
+	for _, p := range c.Participants {
+		if p.Instance == nil {
+			continue
+		}
+		inst := *p.Instance
+		_, err := s.companyStore.UpdateMonsterInstance(c.CompanyID, inst.ID, func(i *monsters.Instance) error {
+			if reflect.DeepEqual(*i, inst) {
+				return errInstanceUnchanged
+			}
+			*i = inst.Clone()
+			return nil
+		})
+		if err != nil && !errors.Is(err, errInstanceUnchanged) && !errors.Is(err, company.ErrInstanceNotFound) {
+			log.Printf("failed to sync monster instance %s from combat %s: %v", inst.ID, c.ID, err)
+		}
+	}
+}
+
+// errInstanceUnchanged отменяет запись экземпляра, совпадающего с составом.
+var errInstanceUnchanged = errors.New("monster instance unchanged")
+
+func writeInstanceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, company.ErrNotFound):
+		writeError(w, http.StatusNotFound, "company not found")
+	case errors.Is(err, company.ErrInstanceNotFound):
+		writeError(w, http.StatusNotFound, "monster instance not found")
+	case errors.Is(err, company.ErrVersionConflict):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
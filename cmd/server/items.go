@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"dice-service/internal/items"
+)
+
+func (s *server) handleItemsCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, items.List(r.URL.Query().Get("category")))
+}
+
+func (s *server) handleItemByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/items/")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing item id")
+		return
+	}
+
+	item, err := items.Find(id)
+	if err != nil {
+		if errors.Is(err, items.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
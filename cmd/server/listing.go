@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/company"
+	"dice-service/internal/monsters"
+)
+
+// maxListLimit наибольший размер страницы списка.
+const maxListLimit = 500
+
+// listParams общие параметры постраничных списков:
+// sort (с префиксом "-" для обратного порядка), limit, offset или cursor.
+// Без limit возвращаются все записи, как раньше.
+type listParams struct {
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+}
+
+func parseListParams(values url.Values) (listParams, error) {
+	var params listParams
+
+	params.Sort = values.Get("sort")
+	if strings.HasPrefix(params.Sort, "-") {
+		params.Sort = strings.TrimPrefix(params.Sort, "-")
+		params.Desc = true
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return listParams{}, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		params.Limit = limit
+	}
+
+	if raw := values.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return listParams{}, errors.New("offset must be a non-negative integer")
+		}
+		params.Offset = offset
+	}
+	if raw := values.Get("cursor"); raw != "" {
+		offset, err := decodeCursor(raw)
+		if err != nil {
+			return listParams{}, err
+		}
+		params.Offset = offset
+	}
+	return params, nil
+}
+
+// encodeCursor и decodeCursor скрывают смещение следующей страницы
+// в непрозрачной строке.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return offset, nil
+}
+
+// writePageHeaders выставляет X-Total-Count и ссылку на следующую страницу
+// в заголовке Link, если она есть.
+func writePageHeaders(w http.ResponseWriter, r *http.Request, params listParams, returned, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if params.Limit == 0 || params.Offset+returned >= total {
+		return
+	}
+
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", encodeCursor(params.Offset+returned))
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+}
+
+// parseLevel разбирает необязательный числовой параметр уровня.
+func parseLevel(values url.Values, name string) (int, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	level, err := strconv.Atoi(raw)
+	if err != nil || level < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return level, nil
+}
+
+// parseCR разбирает необязательный показатель опасности ("5", "1/4", "0.5").
+func parseCR(values url.Values, name string) (*monsters.ChallengeRating, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	cr, err := monsters.ParseChallengeRating(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, raw)
+	}
+	return &cr, nil
+}
+
+func (s *server) listCharacters(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	params, err := parseListParams(values)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := characters.Query{
+		Name:   values.Get("name"),
+		Class:  values.Get("class"),
+		Race:   values.Get("race"),
+		Sort:   params.Sort,
+		Desc:   params.Desc,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+	if q.LevelMin, err = parseLevel(values, "level_min"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.LevelMax, err = parseLevel(values, "level_max"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := q.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.characterStore.Find(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writePageHeaders(w, r, params, len(page.Items), page.Total)
+	writeJSON(w, http.StatusOK, page.Items)
+}
+
+func (s *server) listMonsters(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	params, err := parseListParams(values)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := monsters.Query{
+		Name:        values.Get("name"),
+		Type:        values.Get("type"),
+		Size:        values.Get("size"),
+		Environment: values.Get("environment"),
+		Sort:        params.Sort,
+		Desc:        params.Desc,
+		Limit:       params.Limit,
+		Offset:      params.Offset,
+	}
+	if q.CRMin, err = parseCR(values, "cr_min"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.CRMax, err = parseCR(values, "cr_max"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := q.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.monsterStore.Find(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writePageHeaders(w, r, params, len(page.Items), page.Total)
+	writeJSON(w, http.StatusOK, page.Items)
+}
+
+func (s *server) listCompanies(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	params, err := parseListParams(values)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := company.Query{
+		Name:   values.Get("name"),
+		Sort:   params.Sort,
+		Desc:   params.Desc,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+	if err := q.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.companyStore.Find(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writePageHeaders(w, r, params, len(page.Items), page.Total)
+	writeJSON(w, http.StatusOK, page.Items)
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -13,9 +15,14 @@ import (
 	_ "github.com/lib/pq"
 
 	"dice-service/internal/characters"
+	"dice-service/internal/combat"
 	"dice-service/internal/company"
 	"dice-service/internal/dice"
+	"dice-service/internal/encounters"
+	"dice-service/internal/events"
 	"dice-service/internal/monsters"
+	"dice-service/internal/render"
+	"dice-service/internal/search"
 )
 
 func main() {
@@ -25,9 +32,13 @@ func main() {
 	}
 
 	var (
-		charStore    characters.Store
-		monsterStore monsters.Store
-		companyStore company.Store
+		charStore      characters.Store
+		monsterStore   monsters.Store
+		companyStore   company.Store
+		searcher       search.Searcher
+		templates      monsters.TemplateStore
+		encounterStore encounters.Store
+		combatStore    combat.Store
 	)
 
 	// Проверяем наличие DATABASE_URL
@@ -56,9 +67,31 @@ func main() {
 		charStore = characters.NewPostgresStore(db)
 		monsterStore = monsters.NewPostgresStore(db)
 		companyStore = company.NewPostgresStore(db)
+		searcher = search.NewPostgresSearcher(db)
+		templates = monsters.NewPostgresTemplateStore(db)
+		encounterStore = encounters.NewPostgresStore(db)
+		combatStore = combat.NewPostgresStore(db)
 	}
 
 	api := newServer(charStore, monsterStore, companyStore)
+	if searcher != nil {
+		api.searcher = searcher
+	}
+	if templates != nil {
+		api.templateStore = templates
+	}
+	if encounterStore != nil {
+		api.encounterStore = encounterStore
+	}
+	if combatStore != nil {
+		api.combatStore = combatStore
+	}
+	// ALLOWED_ORIGINS: через запятую, например https://dm.example.com
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			api.allowedOrigins = append(api.allowedOrigins, origin)
+		}
+	}
 
 	server := &http.Server{
 		Addr:              ":" + port,
@@ -94,6 +127,12 @@ type server struct {
 	characterStore characters.Store
 	monsterStore   monsters.Store
 	companyStore   company.Store
+	templateStore  monsters.TemplateStore
+	encounterStore encounters.Store
+	combatStore    combat.Store
+	searcher       search.Searcher
+	events         *events.Hub
+	allowedOrigins []string // сайты, которым доступен WebSocket-поток событий, кроме своего
 }
 
 func newServer(charStore characters.Store, monStore monsters.Store, compStore company.Store) *server {
@@ -101,6 +140,16 @@ func newServer(charStore characters.Store, monStore monsters.Store, compStore co
 		characterStore: charStore,
 		monsterStore:   monStore,
 		companyStore:   compStore,
+		templateStore:  monsters.NewMemoryTemplateStore(),
+		encounterStore: encounters.NewMemoryStore(),
+		combatStore:    combat.NewMemoryStore(),
+		events:         events.NewHub(events.DefaultReplaySize),
+		// Без базы данных ищем по инвертированному индексу в памяти
+		searcher: search.NewMemoryIndex(
+			search.CharacterSource(charStore),
+			search.MonsterSource(monStore),
+			search.CompanySource(compStore),
+		),
 	}
 }
 
@@ -116,6 +165,13 @@ func (s *server) routes() *http.ServeMux {
 	mux.Handle("/monsters", http.HandlerFunc(s.handleMonstersCollection))
 	mux.Handle("/monsters/", http.HandlerFunc(s.handleMonsterByID))
 	mux.Handle("/monsters/load-samples", http.HandlerFunc(s.handleLoadSampleMonsters))
+	mux.Handle("/monsters/import/statblock", http.HandlerFunc(s.handleImportStatBlock))
+	mux.Handle("/monsters/templates", http.HandlerFunc(s.handleMonsterTemplates))
+	mux.Handle("/monsters/templates/", http.HandlerFunc(s.handleMonsterTemplateByID))
+	mux.Handle("/items", http.HandlerFunc(s.handleItemsCollection))
+	mux.Handle("/items/", http.HandlerFunc(s.handleItemByID))
+	mux.Handle("/search", http.HandlerFunc(s.handleSearch))
+	mux.Handle("/combats/", http.HandlerFunc(s.handleCombatByID))
 	
 	// Company endpoints
 	// Используем точное совпадение для /companies
@@ -183,33 +239,68 @@ func (s *server) handleCharactersCollection(w http.ResponseWriter, r *http.Reque
 
 func (s *server) handleCharacterByID(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/characters/")
-	if path == "" {
+	parts := strings.Split(path, "/")
+	id := parts[0]
+	if id == "" {
 		writeError(w, http.StatusBadRequest, "missing character id")
 		return
 	}
 
-	// Проверяем специальный путь для повышения уровня
-	if strings.HasSuffix(path, "/levelup") {
-		if r.Method == http.MethodPost {
-			id := strings.TrimSuffix(path, "/levelup")
-			if id == "" {
-				writeError(w, http.StatusBadRequest, "missing character id")
+	// Вложенные ресурсы и действия над персонажем: /characters/{id}/{action}
+	if len(parts) >= 2 {
+		switch parts[1] {
+		case "conditions":
+			s.handleCharacterConditions(w, r, id, parts[2:])
+			return
+		case "history":
+			s.handleCharacterHistory(w, r, id, parts[2:])
+			return
+		case "revert":
+			if len(parts) != 3 {
+				writeError(w, http.StatusNotFound, "404 page not found")
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
-			s.levelUpCharacter(w, r, id)
+			s.revertCharacter(w, r, id, parts[2])
+			return
+		case "purse":
+			if len(parts) != 3 || parts[2] != "convert" {
+				writeError(w, http.StatusNotFound, "404 page not found")
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			s.convertCharacterCoins(w, r, id)
 			return
 		}
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+
+		methods, ok := s.characterActions()[parts[1]]
+		if !ok || len(parts) != 2 {
+			writeError(w, http.StatusNotFound, "404 page not found")
+			return
+		}
+		handler, ok := methods[r.Method]
+		if !ok {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		handler(w, r, id)
 		return
 	}
 
 	// Обычный путь с ID
-	id := path
 	switch r.Method {
 	case http.MethodGet:
 		s.getCharacter(w, r, id)
 	case http.MethodPut:
 		s.updateCharacter(w, r, id)
+	case http.MethodPatch:
+		s.patchCharacter(w, r, id)
 	case http.MethodDelete:
 		s.deleteCharacter(w, r, id)
 	default:
@@ -217,6 +308,20 @@ func (s *server) handleCharacterByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// characterActions обработчики /characters/{id}/{action} по методам
+// запроса: у каждого действия свой набор допустимых методов.
+func (s *server) characterActions() map[string]map[string]func(http.ResponseWriter, *http.Request, string) {
+	return map[string]map[string]func(http.ResponseWriter, *http.Request, string){
+		"derived":   {http.MethodGet: s.getCharacterDerived},
+		"levelup":   {http.MethodPost: s.levelUpCharacter},
+		"deathsave": {http.MethodPost: s.deathSaveCharacter},
+		"damage":    {http.MethodPost: s.damageCharacter},
+		"heal":      {http.MethodPost: s.healCharacter},
+		"attack":    {http.MethodPost: s.attackWithCharacter},
+		"xp":        {http.MethodPost: s.awardCharacterXP},
+	}
+}
+
 func (s *server) createCharacter(w http.ResponseWriter, r *http.Request) {
 	var sheet characters.CharacterSheet
 	decoder := json.NewDecoder(r.Body)
@@ -226,6 +331,9 @@ func (s *server) createCharacter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sheet.ID = ""
+	if len(sheet.Items) > 0 {
+		sheet.ArmorClass = characters.ComputeArmorClass(sheet)
+	}
 
 	created, err := s.characterStore.Create(sheet)
 	if err != nil {
@@ -233,14 +341,10 @@ func (s *server) createCharacter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, created.Version)
 	writeJSON(w, http.StatusCreated, created)
 }
 
-func (s *server) listCharacters(w http.ResponseWriter, r *http.Request) {
-	items := s.characterStore.List()
-	writeJSON(w, http.StatusOK, items)
-}
-
 func (s *server) getCharacter(w http.ResponseWriter, r *http.Request, id string) {
 	sheet, err := s.characterStore.Get(id)
 	if err != nil {
@@ -251,6 +355,7 @@ func (s *server) getCharacter(w http.ResponseWriter, r *http.Request, id string)
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	setETag(w, sheet.Version)
 	writeJSON(w, http.StatusOK, sheet)
 }
 
@@ -266,27 +371,66 @@ func (s *server) updateCharacter(w http.ResponseWriter, r *http.Request, id stri
 	// путь определяет идентификатор
 	sheet.ID = id
 
+	// If-Match задаёт ожидаемую версию вместо поля version в теле
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		sheet.Version = version
+	}
+
+	// При изменении снаряжения, характеристик или классов КД пересчитывается
+	existing, err := s.characterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if characters.ArmorClassInputsChanged(existing, sheet) {
+		sheet.ArmorClass = characters.ComputeArmorClass(sheet)
+	}
+
 	updated, err := s.characterStore.Update(id, sheet)
 	if err != nil {
 		if errors.Is(err, characters.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "character not found")
 			return
 		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		// ошибки валидации и т.п. считаем ошибкой запроса
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	setETag(w, updated.Version)
 	writeJSON(w, http.StatusOK, updated)
 }
 
 func (s *server) deleteCharacter(w http.ResponseWriter, r *http.Request, id string) {
-	err := s.characterStore.Delete(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.characterStore.Delete(id, version)
 	if err != nil {
 		if errors.Is(err, characters.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "character not found")
 			return
 		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -294,32 +438,392 @@ func (s *server) deleteCharacter(w http.ResponseWriter, r *http.Request, id stri
 	writeJSON(w, http.StatusOK, map[string]string{"message": "character deleted"})
 }
 
-func (s *server) levelUpCharacter(w http.ResponseWriter, r *http.Request, id string) {
-	// Получаем текущего персонажа
+// characterForAction читает персонажа для действия над ним (урон, лечение,
+// опыт и т.п.). Версия из If-Match должна совпадать с текущей; она же
+// проверяется при сохранении результата.
+func (s *server) characterForAction(w http.ResponseWriter, r *http.Request, id string) (characters.CharacterSheet, bool) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return characters.CharacterSheet{}, false
+	}
 	sheet, err := s.characterStore.Get(id)
 	if err != nil {
 		if errors.Is(err, characters.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "character not found")
-			return
+			return characters.CharacterSheet{}, false
 		}
 		writeError(w, http.StatusInternalServerError, err.Error())
+		return characters.CharacterSheet{}, false
+	}
+	if version != 0 && version != sheet.Version {
+		writeError(w, http.StatusPreconditionFailed, characters.ErrVersionConflict.Error())
+		return characters.CharacterSheet{}, false
+	}
+	return sheet, true
+}
+
+type levelUpRequest struct {
+	Class string `json:"class"` // класс, в котором повышается уровень; пусто - основной класс
+}
+
+func (s *server) levelUpCharacter(w http.ResponseWriter, r *http.Request, id string) {
+	// Тело запроса необязательно: без него уровень повышается в основном классе
+	var payload levelUpRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	sheet, ok := s.characterForAction(w, r, id)
+	if !ok {
 		return
 	}
 
 	// Повышаем уровень
-	leveledUp, err := characters.LevelUp(sheet)
+	leveledUp, err := characters.LevelUp(sheet, payload.Class)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if characters.ArmorClassInputsChanged(sheet, leveledUp) {
+		leveledUp.ArmorClass = characters.ComputeArmorClass(leveledUp)
+	}
 
 	// Сохраняем обновлённого персонажа
-	updated, err := s.characterStore.Update(id, leveledUp)
+	updated, err := s.characterStore.UpdateLabeled(id, leveledUp, fmt.Sprintf("level up to %d", leveledUp.Level))
 	if err != nil {
 		if errors.Is(err, characters.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "character not found")
 			return
 		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+type xpRequest struct {
+	Amount    int    `json:"amount"`
+	AutoLevel bool   `json:"autoLevel"` // сразу повысить уровень, если опыта достаточно
+	Class     string `json:"class"`     // класс для автоматического повышения; пусто - основной
+}
+
+type xpResponse struct {
+	Character    characters.CharacterSheet `json:"character"`
+	LevelsGained int                       `json:"levelsGained"`
+	NextLevelXP  int                       `json:"nextLevelXP"`
+	LevelUpError string                    `json:"levelUpError,omitempty"` // автоматическое повышение не удалось, опыт начислен
+}
+
+func (s *server) awardCharacterXP(w http.ResponseWriter, r *http.Request, id string) {
+	var payload xpRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	sheet, ok := s.characterForAction(w, r, id)
+	if !ok {
+		return
+	}
+
+	// В компаниях с развитием по вехам опыт не начисляется
+	if comp, ok := s.milestoneCompanyOf(id); ok {
+		writeError(w, http.StatusConflict, fmt.Sprintf("company %q uses milestone advancement", comp.Name))
+		return
+	}
+
+	awarded, gained, err := characters.AwardXP(sheet, payload.Amount, payload.AutoLevel, payload.Class)
+	var levelUpErr error
+	if errors.Is(err, characters.ErrAutoLevel) {
+		levelUpErr, err = err, nil
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.characterStore.UpdateLabeled(id, awarded, fmt.Sprintf("xp +%d", payload.Amount))
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response := xpResponse{
+		Character:    updated,
+		LevelsGained: gained,
+		NextLevelXP:  characters.XPForLevel(updated.Level + 1),
+	}
+	if levelUpErr != nil {
+		response.LevelUpError = levelUpErr.Error()
+	}
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// milestoneCompanyOf ищет компанию с развитием по вехам, в которой состоит персонаж.
+func (s *server) milestoneCompanyOf(characterID string) (company.CompanySummary, bool) {
+	page, err := s.companyStore.Find(company.Query{
+		CharacterID: characterID,
+		Advancement: company.AdvancementMilestone,
+		Limit:       1,
+	})
+	if err != nil || len(page.Items) == 0 {
+		return company.CompanySummary{}, false
+	}
+	return page.Items[0], true
+}
+
+type deathSaveResponse struct {
+	Character characters.CharacterSheet  `json:"character"`
+	DeathSave characters.DeathSaveResult `json:"deathSave"`
+}
+
+func (s *server) deathSaveCharacter(w http.ResponseWriter, r *http.Request, id string) {
+	sheet, ok := s.characterForAction(w, r, id)
+	if !ok {
+		return
+	}
+
+	rolled, result, err := characters.RollDeathSave(sheet)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.characterStore.UpdateLabeled(id, rolled, "death save")
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.publishCharacterHP(sheet, updated)
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, deathSaveResponse{Character: updated, DeathSave: result})
+}
+
+type damageRequest struct {
+	Amount   int  `json:"amount"`
+	Critical bool `json:"critical"`
+}
+
+type damageResponse struct {
+	Character characters.CharacterSheet `json:"character"`
+	Damage    characters.DamageResult   `json:"damage"`
+}
+
+func (s *server) damageCharacter(w http.ResponseWriter, r *http.Request, id string) {
+	var payload damageRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	sheet, ok := s.characterForAction(w, r, id)
+	if !ok {
+		return
+	}
+
+	damaged, result, err := characters.ApplyDamage(sheet, payload.Amount, payload.Critical)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.characterStore.UpdateLabeled(id, damaged, fmt.Sprintf("damage %d", payload.Amount))
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.publishCharacterHP(sheet, updated)
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, damageResponse{Character: updated, Damage: result})
+}
+
+type healRequest struct {
+	Amount int `json:"amount"`
+}
+
+func (s *server) healCharacter(w http.ResponseWriter, r *http.Request, id string) {
+	var payload healRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	sheet, ok := s.characterForAction(w, r, id)
+	if !ok {
+		return
+	}
+
+	healed, err := characters.ApplyHealing(sheet, payload.Amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.characterStore.UpdateLabeled(id, healed, fmt.Sprintf("heal %d", payload.Amount))
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.publishCharacterHP(sheet, updated)
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+type attackRequest struct {
+	Weapon    string `json:"weapon"`
+	TargetAC  int    `json:"targetAC"`
+	Advantage string `json:"advantage"` // normal, advantage, disadvantage
+	TwoHanded bool   `json:"twoHanded"`
+}
+
+func (s *server) attackWithCharacter(w http.ResponseWriter, r *http.Request, id string) {
+	var payload attackRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if strings.TrimSpace(payload.Weapon) == "" {
+		writeError(w, http.StatusBadRequest, "weapon must not be empty")
+		return
+	}
+
+	sheet, err := s.characterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result, err := characters.Attack(sheet, characters.AttackOptions{
+		Weapon:    payload.Weapon,
+		TargetAC:  payload.TargetAC,
+		Mode:      payload.Advantage,
+		TwoHanded: payload.TwoHanded,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.publishCharacter(sheet.ID, events.TypeRoll, rollEvent{CharacterID: sheet.ID, Name: sheet.Name, Action: result.Weapon, Attack: &result})
+	writeJSON(w, http.StatusOK, result)
+}
+
+// publishCharacterHP сообщает компаниям персонажа об изменении хитов.
+func (s *server) publishCharacterHP(before, after characters.CharacterSheet) {
+	change := after.CurrentHitPoints + after.TemporaryHitPoints - before.CurrentHitPoints - before.TemporaryHitPoints
+	if change == 0 {
+		return
+	}
+	s.publishCharacter(after.ID, events.TypeHPChanged, hpEvent{
+		CharacterID:        after.ID,
+		Name:               after.Name,
+		Change:             change,
+		CurrentHitPoints:   after.CurrentHitPoints,
+		MaxHitPoints:       after.MaxHitPoints,
+		TemporaryHitPoints: after.TemporaryHitPoints,
+	})
+}
+
+type convertCoinsRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+func (s *server) convertCharacterCoins(w http.ResponseWriter, r *http.Request, id string) {
+	var payload convertCoinsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	sheet, err := s.characterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	purse, err := sheet.Purse.Convert(payload.From, payload.To, payload.Amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sheet.Purse = purse
+
+	updated, err := s.characterStore.UpdateLabeled(id, sheet, "convert coins")
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -371,6 +875,7 @@ func (s *server) handleGenerateCharacter(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setETag(w, created.Version)
 	writeJSON(w, http.StatusCreated, created)
 }
 
@@ -386,17 +891,64 @@ func (s *server) handleMonstersCollection(w http.ResponseWriter, r *http.Request
 }
 
 func (s *server) handleMonsterByID(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/monsters/")
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/monsters/"), "/")
+	id := parts[0]
 	if id == "" {
 		writeError(w, http.StatusBadRequest, "missing monster id")
 		return
 	}
 
+	// Блок характеристик: /monsters/{id}/statblock
+	if len(parts) == 2 && parts[1] == "statblock" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.getMonsterStatBlock(w, r, id)
+		return
+	}
+
+	// Масштабирование: /monsters/{id}/scale
+	if len(parts) == 2 && parts[1] == "scale" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.scaleMonster(w, r, id)
+		return
+	}
+
+	// Применение шаблона: /monsters/{id}/apply-template
+	if len(parts) == 2 && parts[1] == "apply-template" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.applyMonsterTemplate(w, r, id)
+		return
+	}
+
+	// Бросок действия: /monsters/{id}/actions/{name}/roll
+	if len(parts) >= 2 {
+		if len(parts) != 4 || parts[1] != "actions" || parts[3] != "roll" {
+			writeError(w, http.StatusNotFound, "404 page not found")
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.rollMonsterAction(w, r, id, parts[2])
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.getMonster(w, r, id)
 	case http.MethodPut:
 		s.updateMonster(w, r, id)
+	case http.MethodPatch:
+		s.patchMonster(w, r, id)
 	case http.MethodDelete:
 		s.deleteMonster(w, r, id)
 	default:
@@ -413,6 +965,10 @@ func (s *server) createMonster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	monster.ID = ""
+	if err := monsters.ValidateHitDice(monster); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	created, err := s.monsterStore.Create(monster)
 	if err != nil {
@@ -420,14 +976,10 @@ func (s *server) createMonster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, created.Version)
 	writeJSON(w, http.StatusCreated, created)
 }
 
-func (s *server) listMonsters(w http.ResponseWriter, r *http.Request) {
-	items := s.monsterStore.List()
-	writeJSON(w, http.StatusOK, items)
-}
-
 func (s *server) getMonster(w http.ResponseWriter, r *http.Request, id string) {
 	monster, err := s.monsterStore.Get(id)
 	if err != nil {
@@ -438,6 +990,13 @@ func (s *server) getMonster(w http.ResponseWriter, r *http.Request, id string) {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// Клиент, предпочитающий Markdown, HTML или текст, получает блок характеристик
+	if format := render.Negotiate(r.Header.Get("Accept")); format != "" {
+		s.writeStatBlock(w, monster, format)
+		return
+	}
+	setETag(w, monster.Version)
+	w.Header().Set("Vary", "Accept")
 	writeJSON(w, http.StatusOK, monster)
 }
 
@@ -452,26 +1011,52 @@ func (s *server) updateMonster(w http.ResponseWriter, r *http.Request, id string
 
 	monster.ID = id
 
+	// If-Match задаёт ожидаемую версию вместо поля version в теле
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		monster.Version = version
+	}
+
 	updated, err := s.monsterStore.Update(id, monster)
 	if err != nil {
 		if errors.Is(err, monsters.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "monster not found")
 			return
 		}
+		if errors.Is(err, monsters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	s.regenerateDerived(w, updated)
+	setETag(w, updated.Version)
 	writeJSON(w, http.StatusOK, updated)
 }
 
 func (s *server) deleteMonster(w http.ResponseWriter, r *http.Request, id string) {
-	err := s.monsterStore.Delete(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.monsterStore.Delete(id, version)
 	if err != nil {
 		if errors.Is(err, monsters.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "monster not found")
 			return
 		}
+		if errors.Is(err, monsters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -539,10 +1124,24 @@ func (s *server) handleCompanyByID(w http.ResponseWriter, r *http.Request) {
 		case "monsters":
 			if len(parts) == 2 {
 				s.handleCompanyMonsters(w, r, id)
+			} else if len(parts) >= 4 && parts[3] == "conditions" {
+				s.handleCompanyMonsterConditions(w, r, id, parts[2], parts[4:])
 			} else {
 				s.handleCompanyMonsterByID(w, r, id, parts[2])
 			}
 			return
+		case "instances":
+			s.handleCompanyInstances(w, r, id, parts[2:])
+			return
+		case "encounters":
+			s.handleCompanyEncounters(w, r, id, parts[2:])
+			return
+		case "combats":
+			s.handleCompanyCombats(w, r, id, parts[2:])
+			return
+		case "events":
+			s.handleCompanyEvents(w, r, id, parts[2:])
+			return
 		}
 	}
 
@@ -551,6 +1150,8 @@ func (s *server) handleCompanyByID(w http.ResponseWriter, r *http.Request) {
 		s.getCompany(w, r, id)
 	case http.MethodPut:
 		s.updateCompany(w, r, id)
+	case http.MethodPatch:
+		s.patchCompany(w, r, id)
 	case http.MethodDelete:
 		s.deleteCompany(w, r, id)
 	default:
@@ -573,6 +1174,9 @@ func (s *server) createCompany(w http.ResponseWriter, r *http.Request) {
 	if comp.Monsters == nil {
 		comp.Monsters = []monsters.Monster{}
 	}
+	if comp.Instances == nil {
+		comp.Instances = []monsters.Instance{}
+	}
 
 	created, err := s.companyStore.Create(comp)
 	if err != nil {
@@ -584,15 +1188,10 @@ func (s *server) createCompany(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, created.Version)
 	writeJSON(w, http.StatusCreated, created)
 }
 
-func (s *server) listCompanies(w http.ResponseWriter, r *http.Request) {
-	items := s.companyStore.List()
-	log.Printf("listCompanies: возвращено %d компаний", len(items))
-	writeJSON(w, http.StatusOK, items)
-}
-
 func (s *server) getCompany(w http.ResponseWriter, r *http.Request, id string) {
 	comp, err := s.companyStore.Get(id)
 	if err != nil {
@@ -603,6 +1202,7 @@ func (s *server) getCompany(w http.ResponseWriter, r *http.Request, id string) {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	setETag(w, comp.Version)
 	writeJSON(w, http.StatusOK, comp)
 }
 
@@ -616,12 +1216,26 @@ func (s *server) updateCompany(w http.ResponseWriter, r *http.Request, id string
 
 	comp.ID = id
 
-	err := s.companyStore.Update(comp)
+	// If-Match задаёт ожидаемую версию вместо поля version в теле
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if version != 0 {
+		comp.Version = version
+	}
+
+	err = s.companyStore.Update(comp)
 	if err != nil {
 		if errors.Is(err, company.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "company not found")
 			return
 		}
+		if errors.Is(err, company.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		if errors.Is(err, company.ErrDuplicateName) {
 			writeError(w, http.StatusConflict, err.Error())
 			return
@@ -631,16 +1245,27 @@ func (s *server) updateCompany(w http.ResponseWriter, r *http.Request, id string
 	}
 
 	updated, _ := s.companyStore.Get(id)
+	setETag(w, updated.Version)
 	writeJSON(w, http.StatusOK, updated)
 }
 
 func (s *server) deleteCompany(w http.ResponseWriter, r *http.Request, id string) {
-	err := s.companyStore.Delete(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.companyStore.Delete(id, version)
 	if err != nil {
 		if errors.Is(err, company.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "company not found")
 			return
 		}
+		if errors.Is(err, company.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -683,6 +1308,7 @@ func (s *server) handleCompanyCharacters(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	s.publish(companyID, events.TypeEntityAdded, entityEvent{Kind: "character", ID: char.ID, Name: char.Name})
 
 	writeJSON(w, http.StatusOK, map[string]string{"message": "character added to company"})
 }
@@ -732,8 +1358,28 @@ func (s *server) handleCompanyMonsters(w http.ResponseWriter, r *http.Request, c
 		return
 	}
 
-	// Добавляем в компанию
-	err = s.companyStore.AddMonster(companyID, mon)
+	comp, err := s.companyStore.Get(companyID)
+	if err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Каждый экземпляр получает свои хиты: из карточки, среднее, бросок или максимум
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	instances, err := monsters.NewInstances(mon, req.Count, req.HPMode, comp.Instances)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Добавляем в компанию копию монстра и его экземпляры
+	err = s.companyStore.AddMonsterInstances(companyID, mon, instances)
 	if err != nil {
 		if errors.Is(err, company.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "company not found")
@@ -742,8 +1388,16 @@ func (s *server) handleCompanyMonsters(w http.ResponseWriter, r *http.Request, c
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	s.publish(companyID, events.TypeEntityAdded, entityEvent{Kind: "monster", ID: mon.ID, Name: mon.Name})
+	for _, inst := range instances {
+		s.publish(companyID, events.TypeEntityAdded, entityEvent{Kind: "instance", ID: inst.ID, Name: inst.Name})
+	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"message": "monster added to company"})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message":   "monster added to company",
+		"hitPoints": instances[0].MaxHitPoints,
+		"instances": instances,
+	})
 }
 
 // Удаление монстра из компании
@@ -1,15 +1,27 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"dice-service/internal/characters"
+	"dice-service/internal/combat"
+	"dice-service/internal/company"
+	"dice-service/internal/encounters"
+	"dice-service/internal/events"
+	"dice-service/internal/monsters"
+	"dice-service/internal/search"
 )
 
 func TestHandleRollSuccess(t *testing.T) {
@@ -188,8 +200,499 @@ func TestGenerateCharacter(t *testing.T) {
 	}
 }
 
+func TestDamageAtZeroHitPointsCountsDeathSaveFailure(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Борин",
+		Class:            "Fighter",
+		Level:            1,
+		MaxHitPoints:     12,
+		CurrentHitPoints: 0,
+		State:            characters.StateUnconscious,
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/damage", strings.NewReader(`{"amount":3,"critical":true}`))
+	rec := httptest.NewRecorder()
+
+	srv.handleCharacterByID(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var payload damageResponse
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if payload.Character.DeathSaves.Failures != 2 {
+		t.Fatalf("expected 2 death save failures, got %d", payload.Character.DeathSaves.Failures)
+	}
+	if payload.Character.State != characters.StateUnconscious {
+		t.Fatalf("unexpected state %q", payload.Character.State)
+	}
+}
+
+func TestCharacterActionsHonourIfMatch(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name: "Борин", Class: "Fighter", Level: 1, MaxHitPoints: 12, CurrentHitPoints: 12,
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		action, body string
+	}{
+		{"damage", `{"amount":3}`},
+		{"heal", `{"amount":3}`},
+		{"xp", `{"amount":100}`},
+		{"levelup", ``},
+		{"conditions", `{"name":"poisoned"}`},
+		{"deathsave", ``},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/"+tt.action, strings.NewReader(tt.body))
+		req.Header.Set("If-Match", `"99"`)
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Fatalf("%s with a stale If-Match: expected 412, got %d: %s", tt.action, rec.Code, rec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/damage", strings.NewReader(`{"amount":3}`))
+	req.Header.Set("If-Match", fmt.Sprintf(`"%d"`, created.Version))
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != fmt.Sprintf(`"%d"`, created.Version+1) {
+		t.Fatalf("expected 200 with the next ETag, got %d %q: %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+}
+
+func TestDeathSaveRequiresDyingCharacter(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Борин",
+		Class:            "Fighter",
+		Level:            1,
+		MaxHitPoints:     12,
+		CurrentHitPoints: 12,
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/deathsave", nil)
+	rec := httptest.NewRecorder()
+
+	srv.handleCharacterByID(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	assertErrorBody(t, rec.Body, characters.ErrNotDying.Error())
+}
+
+func TestCharacterSubresourceMethods(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	sheet, err := srv.characterStore.Create(characters.CharacterSheet{Name: "Арн", Class: "Fighter", Level: 1, MaxHitPoints: 10, CurrentHitPoints: 10})
+	if err != nil {
+		t.Fatalf("create character: %v", err)
+	}
+
+	for _, tt := range []struct {
+		method, action string
+		want           int
+	}{
+		{http.MethodGet, "derived", http.StatusOK},
+		{http.MethodPost, "derived", http.StatusMethodNotAllowed},
+		{http.MethodGet, "damage", http.StatusMethodNotAllowed},
+		{http.MethodGet, "history", http.StatusOK},
+		{http.MethodPost, "unknown", http.StatusNotFound},
+		{http.MethodPost, "damage/extra", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(tt.method, "/characters/"+sheet.ID+"/"+tt.action, nil)
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Fatalf("%s %s: expected %d, got %d: %s", tt.method, tt.action, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestUpdateCharacterMigratesLegacyItems(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{Name: "Aria", Class: "Rogue", Level: 1})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	body := `{"name":"Aria","class":"Rogue","level":1,"items":["Верёвка",{"name":"Факел","quantity":5,"weight":1,"equipped":false,"attuned":false}]}`
+	req := httptest.NewRequest(http.MethodPut, "/characters/"+created.ID, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.handleCharacterByID(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var sheet characters.CharacterSheet
+	if err := json.NewDecoder(rec.Body).Decode(&sheet); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(sheet.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(sheet.Items))
+	}
+	if sheet.Items[0].Name != "Верёвка" || sheet.Items[0].Quantity != 1 || sheet.Items[0].ID == "" {
+		t.Fatalf("legacy item not migrated: %+v", sheet.Items[0])
+	}
+	if sheet.Items[1].Quantity != 5 {
+		t.Fatalf("unexpected quantity: %d", sheet.Items[1].Quantity)
+	}
+}
+
+func TestAttackWithWeapon(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Aria",
+		Class:            "Rogue",
+		Level:            1,
+		AbilityScores:    characters.AbilityScores{Strength: 8, Dexterity: 16},
+		ProficiencyBonus: 2,
+		MaxHitPoints:     9,
+		CurrentHitPoints: 9,
+		Items:            characters.Inventory{{ItemID: "rapier", Quantity: 1, Equipped: true, MagicBonus: 1}},
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/attack", strings.NewReader(`{"weapon":"rapier","targetAC":0,"advantage":"advantage"}`))
+	rec := httptest.NewRecorder()
+
+	srv.handleCharacterByID(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result characters.AttackResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.Ability != "dexterity" {
+		t.Fatalf("finesse weapon should use dexterity, got %q", result.Ability)
+	}
+	if result.AttackBonus != 6 {
+		t.Fatalf("attack bonus = %d, want 6 (DEX 3 + prof 2 + magic 1)", result.AttackBonus)
+	}
+	if len(result.D20.Rolls) != 2 {
+		t.Fatalf("expected two d20 rolls with advantage, got %v", result.D20.Rolls)
+	}
+	if result.D20.Natural == 1 {
+		if result.Hit {
+			t.Fatalf("natural 1 must miss")
+		}
+		return
+	}
+	if !result.Hit || result.Damage == nil {
+		t.Fatalf("expected a hit against AC 0")
+	}
+	if result.Damage.Type != "piercing" {
+		t.Fatalf("unexpected damage type %q", result.Damage.Type)
+	}
+	minDamage, maxDamage := 5, 12
+	if result.Critical {
+		minDamage, maxDamage = 6, 20
+	}
+	if result.Damage.Total < minDamage || result.Damage.Total > maxDamage {
+		t.Fatalf("damage out of range: %d", result.Damage.Total)
+	}
+}
+
+func TestAwardXPAutoLevel(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Борин",
+		Class:            "Fighter",
+		Level:            1,
+		Experience:       250,
+		MaxHitPoints:     12,
+		CurrentHitPoints: 12,
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/xp", strings.NewReader(`{"amount":700,"autoLevel":true}`))
+	rec := httptest.NewRecorder()
+
+	srv.handleCharacterByID(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload xpResponse
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if payload.LevelsGained != 2 || payload.Character.Level != 3 {
+		t.Fatalf("expected two levels gained up to level 3, got %d (level %d)", payload.LevelsGained, payload.Character.Level)
+	}
+	if payload.Character.Experience != 950 || payload.Character.ReadyToLevel {
+		t.Fatalf("unexpected experience state: %d, ready %v", payload.Character.Experience, payload.Character.ReadyToLevel)
+	}
+	if payload.NextLevelXP != 2700 {
+		t.Fatalf("expected next level at 2700 XP, got %d", payload.NextLevelXP)
+	}
+
+	// Неудачное повышение не отменяет начисленный опыт
+	req = httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/xp", strings.NewReader(`{"amount":2000,"autoLevel":true,"class":"Wizard"}`))
+	rec = httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	payload = xpResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if payload.LevelUpError == "" || payload.LevelsGained != 0 || payload.Character.Level != 3 ||
+		payload.Character.Experience != 2950 || !payload.Character.ReadyToLevel {
+		t.Fatalf("expected XP kept with a level up error, got %+v", payload)
+	}
+
+	comp, err := srv.companyStore.Create(company.Company{Name: "Вехи", Advancement: company.AdvancementMilestone})
+	if err != nil {
+		t.Fatalf("create company error: %v", err)
+	}
+	if err := srv.companyStore.AddCharacter(comp.ID, payload.Character); err != nil {
+		t.Fatalf("add character error: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/xp", strings.NewReader(`{"amount":100}`))
+	rec = httptest.NewRecorder()
+
+	srv.handleCharacterByID(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for milestone company, got %d", rec.Code)
+	}
+}
+
+func TestCharacterHistoryAndRevert(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Борин",
+		Class:            "Fighter",
+		Level:            1,
+		MaxHitPoints:     12,
+		CurrentHitPoints: 12,
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/damage", strings.NewReader(`{"amount":5}`))
+	rec := httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("damage: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/characters/"+created.ID+"/history", nil)
+	rec = httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("history: expected 200, got %d", rec.Code)
+	}
+	var history []characters.RevisionSummary
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(history) != 2 || history[0].Label != characters.LabelCreated || history[1].Label != "damage 5" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	changedHP := false
+	for _, change := range history[1].Diff {
+		if change.Path == "currentHitPoints" && string(change.Old) == "12" && string(change.New) == "7" {
+			changedHP = true
+		}
+	}
+	if !changedHP {
+		t.Fatalf("diff does not record hit point change: %+v", history[1].Diff)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/characters/"+created.ID+"/revert/1", nil)
+	rec = httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revert: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var reverted characters.CharacterSheet
+	if err := json.NewDecoder(rec.Body).Decode(&reverted); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if reverted.CurrentHitPoints != 12 {
+		t.Fatalf("expected hit points restored to 12, got %d", reverted.CurrentHitPoints)
+	}
+	if etag := rec.Header().Get("ETag"); etag != fmt.Sprintf(`"%d"`, reverted.Version) {
+		t.Fatalf("revert: unexpected ETag %q for version %d", etag, reverted.Version)
+	}
+
+	revision, err := srv.characterStore.Revision(created.ID, 3)
+	if err != nil {
+		t.Fatalf("revision error: %v", err)
+	}
+	if revision.Label != characters.RevertLabel(1) {
+		t.Fatalf("unexpected revert label %q", revision.Label)
+	}
+}
+
+func TestUpdateMonsterIfMatch(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.monsterStore.Create(monsters.Monster{Name: "Goblin", Type: "Humanoid", ArmorClass: 15, HitPoints: 7})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monsters/"+created.ID, nil)
+	rec := httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	etag := rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("expected ETag \"1\", got %q", etag)
+	}
+
+	body := `{"name":"Goblin Boss","type":"Humanoid","armorClass":17,"hitPoints":21}`
+	req = httptest.NewRequest(http.MethodPut, "/monsters/"+created.ID, strings.NewReader(body))
+	req.Header.Set("If-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Fatalf("expected ETag \"2\" after update, got %q", got)
+	}
+
+	// Второй клиент с устаревшей версией получает 412
+	req = httptest.NewRequest(http.MethodPut, "/monsters/"+created.ID, strings.NewReader(body))
+	req.Header.Set("If-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d", rec.Code)
+	}
+
+	// If-Match сравнивает строго: слабый тег текущей версии не подходит
+	req = httptest.NewRequest(http.MethodPut, "/monsters/"+created.ID, strings.NewReader(body))
+	req.Header.Set("If-Match", `W/"2"`)
+	rec = httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a weak entity tag, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/monsters/"+created.ID, nil)
+	req.Header.Set("If-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 on stale delete, got %d", rec.Code)
+	}
+}
+
+func TestPatchCharacter(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Борин",
+		Class:            "Fighter",
+		Level:            1,
+		MaxHitPoints:     12,
+		CurrentHitPoints: 12,
+		Skills:           []string{"Атлетика"},
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/characters/"+created.ID, strings.NewReader(`{"currentHitPoints":5}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec := httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("merge patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var sheet characters.CharacterSheet
+	if err := json.NewDecoder(rec.Body).Decode(&sheet); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if sheet.CurrentHitPoints != 5 || sheet.Name != "Борин" || len(sheet.Skills) != 1 {
+		t.Fatalf("merge patch changed unexpected fields: %+v", sheet)
+	}
+
+	body := `[{"op":"test","path":"/currentHitPoints","value":5},{"op":"add","path":"/skills/-","value":"Выживание"}]`
+	req = httptest.NewRequest(http.MethodPatch, "/characters/"+created.ID, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	rec = httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("json patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&sheet); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(sheet.Skills) != 2 || sheet.Skills[1] != "Выживание" {
+		t.Fatalf("unexpected skills: %v", sheet.Skills)
+	}
+
+	// Результат патча проходит обычную валидацию
+	req = httptest.NewRequest(http.MethodPatch, "/characters/"+created.ID, strings.NewReader(`{"level":0}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec = httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid result, got %d", rec.Code)
+	}
+
+	// Слишком большое тело не обрезается молча, а отклоняется
+	oversized := `{"notes":"` + strings.Repeat("a", maxPatchSize) + `"}`
+	req = httptest.NewRequest(http.MethodPatch, "/characters/"+created.ID, strings.NewReader(oversized))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec = httptest.NewRecorder()
+	srv.handleCharacterByID(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized patch, got %d", rec.Code)
+	}
+}
+
 func newTestServer() *server {
-	return newServer(characters.NewMemoryStore())
+	return newServer(characters.NewMemoryStore(), monsters.NewMemoryStore(), company.NewMemoryStore())
 }
 
 func assertErrorBody(t *testing.T, r io.Reader, want string) {
@@ -202,3 +705,1399 @@ func assertErrorBody(t *testing.T, r io.Reader, want string) {
 		t.Fatalf("unexpected error: %q, want %q", payload["error"], want)
 	}
 }
+
+func TestListMonstersFilterAndPaging(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	for _, m := range []monsters.Monster{
+		{Name: "Zombie", Type: "Undead", ChallengeRating: 0.25, ArmorClass: 10, HitPoints: 10},
+		{Name: "Wight", Type: "Undead", ChallengeRating: 3, ArmorClass: 10, HitPoints: 10},
+		{Name: "Ghost", Type: "Undead", ChallengeRating: 4, ArmorClass: 10, HitPoints: 10},
+		{Name: "Goblin", Type: "Humanoid", ChallengeRating: 0.25, ArmorClass: 10, HitPoints: 10},
+	} {
+		if _, err := srv.monsterStore.Create(m); err != nil {
+			t.Fatalf("create error: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monsters?type=undead&cr_min=1/2&sort=name&limit=1", nil)
+	rec := httptest.NewRecorder()
+	srv.handleMonstersCollection(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if total := rec.Header().Get("X-Total-Count"); total != "2" {
+		t.Fatalf("expected X-Total-Count 2, got %q", total)
+	}
+	var page []monsters.Monster
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(page) != 1 || page[0].Name != "Ghost" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+
+	link := rec.Header().Get("Link")
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start || !strings.HasSuffix(link, `rel="next"`) {
+		t.Fatalf("unexpected Link header %q", link)
+	}
+	req = httptest.NewRequest(http.MethodGet, link[start+1:end], nil)
+	rec = httptest.NewRecorder()
+	srv.handleMonstersCollection(rec, req)
+	page = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(page) != 1 || page[0].Name != "Wight" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+	if rec.Header().Get("Link") != "" {
+		t.Fatalf("last page must not link further, got %q", rec.Header().Get("Link"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/monsters?sort=speed", nil)
+	rec = httptest.NewRecorder()
+	srv.handleMonstersCollection(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown sort field, got %d", rec.Code)
+	}
+}
+
+func TestListMonstersOrderMatchesPostgres(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	var ids []string
+	for _, m := range []monsters.Monster{
+		{Name: "Zombie", Type: "Undead", ChallengeRating: 0.25, ArmorClass: 10, HitPoints: 10},
+		{Name: "Ghost", Type: "Undead", ChallengeRating: 4, ArmorClass: 10, HitPoints: 10},
+		{Name: "Goblin", Type: "Humanoid", ChallengeRating: 0.25, ArmorClass: 10, HitPoints: 10},
+		{Name: "100%_Rat", Type: "Beast", ChallengeRating: 0, ArmorClass: 10, HitPoints: 1},
+	} {
+		created, err := srv.monsterStore.Create(m)
+		if err != nil {
+			t.Fatalf("create error: %v", err)
+		}
+		ids = append(ids, created.ID)
+	}
+
+	list := func(target string) []string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		srv.handleMonstersCollection(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var page []monsters.Monster
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		var got []string
+		for _, m := range page {
+			got = append(got, m.ID)
+		}
+		return got
+	}
+
+	// Без сортировки и при равных ключах, как и в PostgresStore, решает id
+	byID := append([]string(nil), ids...)
+	sort.Strings(byID)
+	if got := list("/monsters"); !slices.Equal(got, byID) {
+		t.Fatalf("default order %v, want %v", got, byID)
+	}
+	tied := []string{ids[0], ids[2]}
+	sort.Strings(tied)
+	want := append([]string{ids[1]}, tied...)
+	want = append(want, ids[3])
+	if got := list("/monsters?sort=-cr"); !slices.Equal(got, want) {
+		t.Fatalf("descending order %v, want %v", got, want)
+	}
+
+	if got := list("/monsters?name=100%25_"); !slices.Equal(got, []string{ids[3]}) {
+		t.Fatalf("name filter with wildcards: %v", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	dragon, err := srv.monsterStore.Create(monsters.Monster{
+		Name: "Young Red Dragon", Type: "Dragon", ArmorClass: 18, HitPoints: 178,
+		Actions: []monsters.Action{{
+			Name: "Fire Breath", Type: monsters.ActionSave, SaveDC: 17, SaveAbility: "DEX", Recharge: 5,
+			Description: "The dragon exhales fire in a 30-foot cone.",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	if _, err := srv.monsterStore.Create(monsters.Monster{Name: "Goblin", Type: "Humanoid", ArmorClass: 15, HitPoints: 7}); err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=fire+breath", nil)
+	rec := httptest.NewRecorder()
+	srv.handleSearch(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var results []search.Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != dragon.ID || results[0].Type != search.TypeMonster {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if len(results[0].Highlights) == 0 || !strings.Contains(results[0].Highlights[0], "<mark>Fire</mark>") {
+		t.Fatalf("expected highlighted fragment, got %v", results[0].Highlights)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/search?q=", nil)
+	rec = httptest.NewRecorder()
+	srv.handleSearch(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty query, got %d", rec.Code)
+	}
+}
+
+func TestRollMonsterAction(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	created, err := srv.monsterStore.Create(monsters.Monster{
+		Name: "Goblin", Type: "Humanoid", ArmorClass: 15, HitPoints: 7,
+		Actions: []monsters.Action{{Name: "Scimitar", Type: monsters.ActionMelee, AttackBonus: 4, Reach: 5,
+			Damage: []monsters.Damage{{Dice: "1d6+2", Type: "slashing"}}}},
+	})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/monsters/"+created.ID+"/actions/Scimitar/roll", strings.NewReader(`{"targetAC":0}`))
+	rec := httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result monsters.ActionRollResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if result.D20 == nil || result.AttackTotal != result.D20.Natural+4 {
+		t.Fatalf("unexpected attack roll: %+v", result)
+	}
+	if result.Hit && (result.TotalDamage < 3 || result.TotalDamage > 16) {
+		t.Fatalf("damage out of range: %d", result.TotalDamage)
+	}
+
+	// Бросок не меняет карточку в библиотеке
+	if stored, err := srv.monsterStore.Get(created.ID); err != nil || stored.Version != created.Version {
+		t.Fatalf("library monster must not be saved by a roll: %+v, %v", stored, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/monsters/"+created.ID+"/actions/Bow/roll", nil)
+	rec = httptest.NewRecorder()
+	srv.handleMonsterByID(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown action, got %d", rec.Code)
+	}
+}
+
+func TestImportStatBlock(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	text := "Goblin\nSmall humanoid (goblinoid), neutral evil\nArmor Class 15 (leather armor, shield)\nHit Points 7 (2d6)\n" +
+		"Speed 30 ft.\nSTR DEX CON INT WIS CHA\n8 (-1) 14 (+2) 10 (+0) 10 (+0) 8 (-1) 8 (-1)\nChallenge 1/4 (50 XP)\n" +
+		"Actions\nScimitar. Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage.\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/monsters/import/statblock?save=true", strings.NewReader(text))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Monster  monsters.Monster `json:"monster"`
+		Warnings []string         `json:"warnings"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.Monster.ID == "" || resp.Monster.ChallengeRating != 0.25 || resp.Monster.XP != 50 {
+		t.Fatalf("unexpected monster: %+v", resp.Monster)
+	}
+	if len(resp.Monster.Actions) != 1 || resp.Monster.Actions[0].AttackBonus != 4 {
+		t.Fatalf("unexpected actions: %+v", resp.Monster.Actions)
+	}
+	if len(resp.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", resp.Warnings)
+	}
+	if _, err := srv.monsterStore.Get(resp.Monster.ID); err != nil {
+		t.Fatalf("imported monster not saved: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/monsters/import/statblock", strings.NewReader(strings.Repeat(text, maxStatBlockSize/len(text)+1)))
+	req.Header.Set("Content-Type", "text/plain")
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized stat block, got %d", rec.Code)
+	}
+}
+
+func TestMonsterStatBlock(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	monster, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monsters/"+monster.ID+"/statblock?format=text", nil)
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "Хиты 84 (8d10+40)") {
+		t.Fatalf("unexpected stat block:\n%s", rec.Body.String())
+	}
+
+	// Согласование по Accept на самом ресурсе монстра
+	req = httptest.NewRequest(http.MethodGet, "/monsters/"+monster.ID, nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected HTML stat block, got %d %q", rec.Code, ct)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/monsters/"+monster.ID+"/statblock?format=pdf", nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown format, got %d", rec.Code)
+	}
+}
+
+func TestScaleMonster(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/monsters/"+troll.ID+"/scale", strings.NewReader(`{"challengeRating": "1/2", "save": true}`))
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var scaled monsters.Monster
+	if err := json.Unmarshal(rec.Body.Bytes(), &scaled); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if scaled.ID == "" || scaled.ID == troll.ID || scaled.ScaledFrom != troll.ID || scaled.ChallengeRating != 0.5 {
+		t.Fatalf("unexpected scaled monster: %+v", scaled)
+	}
+	if scaled.HitPoints >= troll.HitPoints || scaled.Actions[0].AttackBonus >= troll.Actions[0].AttackBonus {
+		t.Fatalf("monster was not weakened: hp=%d attack=%d", scaled.HitPoints, scaled.Actions[0].AttackBonus)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/monsters/"+troll.ID+"/scale", strings.NewReader(`{"challengeRating": "31"}`))
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid CR, got %d", rec.Code)
+	}
+}
+
+func TestMonsterTemplates(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/monsters/"+troll.ID+"/apply-template", strings.NewReader(`{"templateId": "skeleton", "save": true}`))
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var derived monsters.Monster
+	if err := json.Unmarshal(rec.Body.Bytes(), &derived); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if derived.Type != "Undead" || derived.BaseID != troll.ID || derived.TemplateID != "skeleton" {
+		t.Fatalf("unexpected derived monster: %+v", derived)
+	}
+
+	// Изменение базы пересоздаёт производного монстра
+	troll.ArmorClass = 17
+	body, _ := json.Marshal(troll)
+	req = httptest.NewRequest(http.MethodPut, "/monsters/"+troll.ID, bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	fresh, err := srv.monsterStore.Get(derived.ID)
+	if err != nil || fresh.ArmorClass != 17 {
+		t.Fatalf("derived monster was not regenerated: %+v, %v", fresh, err)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/monsters/templates/zombie", nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for built-in template, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/monsters/templates", strings.NewReader(`{"name": "Ледяной", "damageImmunities": ["cold"], "challengeSteps": 1}`))
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var icy monsters.Template
+	if err := json.Unmarshal(rec.Body.Bytes(), &icy); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+
+	// Шаблон с производными монстрами не удаляется
+	req = httptest.NewRequest(http.MethodPost, "/monsters/"+troll.ID+"/apply-template", strings.NewReader(`{"templateId": "`+icy.ID+`", "save": true}`))
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var icyTroll monsters.Monster
+	if err := json.Unmarshal(rec.Body.Bytes(), &icyTroll); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/monsters/templates/"+icy.ID, nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a template in use, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := srv.monsterStore.Delete(icyTroll.ID, 0); err != nil {
+		t.Fatalf("delete derived monster: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/monsters/templates/"+icy.ID, nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 once no monster uses the template, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/monsters/templates", nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	var list []monsters.Template
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != len(monsters.BuiltinTemplates()) {
+		t.Fatalf("unexpected template list: %s", rec.Body.String())
+	}
+}
+
+func TestCompanyEncounters(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	for i, name := range []string{"Арн", "Бея", "Вил", "Гром"} {
+		sheet := characters.CharacterSheet{ID: fmt.Sprintf("pc%d", i), Name: name, Level: 3}
+		if err := srv.companyStore.AddCharacter(comp.ID, sheet); err != nil {
+			t.Fatalf("add character: %v", err)
+		}
+	}
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	body := fmt.Sprintf(`{"monsters": [{"monsterId": %q, "count": 1}]}`, troll.ID)
+	req := httptest.NewRequest(http.MethodPost, "/companies/"+comp.ID+"/encounters/evaluate", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var eval encounters.Evaluation
+	if err := json.Unmarshal(rec.Body.Bytes(), &eval); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	// Тролль (CR 5, 1800 XP) против четырёх персонажей 3-го уровня
+	if eval.TotalXP != 1800 || eval.AdjustedXP != 1800 || eval.Difficulty != encounters.DifficultyDeadly {
+		t.Fatalf("unexpected evaluation: %+v", eval)
+	}
+
+	body = fmt.Sprintf(`{"name": "Мост тролля", "monsters": [{"monsterId": %q, "count": 1}]}`, troll.ID)
+	req = httptest.NewRequest(http.MethodPost, "/companies/"+comp.ID+"/encounters", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var saved encounterView
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if saved.ID == "" || saved.CompanyID != comp.ID || saved.Evaluation == nil || saved.Evaluation.Difficulty != encounters.DifficultyDeadly {
+		t.Fatalf("unexpected encounter: %+v", saved)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/companies/"+comp.ID+"/encounters", nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	var list []encounterView
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Fatalf("unexpected list: %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/companies/"+comp.ID+"/encounters", strings.NewReader(`{"name": "Пусто", "monsters": [{"monsterId": "missing", "count": 1}]}`))
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown monster, got %d", rec.Code)
+	}
+}
+
+func TestGenerateEncounters(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		sheet := characters.CharacterSheet{ID: fmt.Sprintf("pc%d", i), Name: fmt.Sprintf("Герой %d", i), Level: 12}
+		if err := srv.companyStore.AddCharacter(comp.ID, sheet); err != nil {
+			t.Fatalf("add character: %v", err)
+		}
+	}
+	for _, m := range monsters.GetSampleMonsters() {
+		if _, err := srv.monsterStore.Create(m); err != nil {
+			t.Fatalf("create monster: %v", err)
+		}
+	}
+
+	generate := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/companies/"+comp.ID+"/encounters/generate", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"difficulty": "hard", "environment": "forest", "maxMonsters": 4, "count": 2, "seed": 7}`
+	rec := generate(body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Seed       int64                   `json:"seed"`
+		Candidates []encounters.Evaluation `json:"candidates"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.Seed != 7 || len(resp.Candidates) == 0 {
+		t.Fatalf("unexpected response: %s", rec.Body.String())
+	}
+	for _, c := range resp.Candidates {
+		// В лесу из образцов водятся только тролли
+		if c.Difficulty != encounters.DifficultyHard || c.MonsterCount > 4 || len(c.Monsters) != 1 || c.Monsters[0].ChallengeRating != 5 {
+			t.Fatalf("unexpected candidate: %+v", c)
+		}
+	}
+	if again := generate(body); again.Body.String() != rec.Body.String() {
+		t.Fatalf("same seed gave different candidates: %s", again.Body.String())
+	}
+
+	if rec := generate(`{"difficulty": "easy", "type": "Dragon"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 when nothing fits, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := generate(`{"difficulty": "epic"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown difficulty, got %d", rec.Code)
+	}
+}
+
+func TestCombatUsesCurrentCharacterSheet(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	sheet, err := srv.characterStore.Create(characters.CharacterSheet{Name: "Вир", Class: "Rogue", Level: 3, Initiative: 7, MaxHitPoints: 10, CurrentHitPoints: 10})
+	if err != nil {
+		t.Fatalf("create character: %v", err)
+	}
+	// В компании лежит устаревшая копия листа
+	stale := sheet
+	stale.Initiative = 0
+	if err := srv.companyStore.AddCharacter(comp.ID, stale); err != nil {
+		t.Fatalf("add character: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/companies/"+comp.ID+"/combats", strings.NewReader(`{"name": "Засада"}`))
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var c combat.Combat
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(c.Participants) != 1 || c.Participants[0].InitiativeBonus != 7 {
+		t.Fatalf("expected the initiative bonus from the character store: %+v", c.Participants)
+	}
+}
+
+func TestCombatTracker(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	for i, name := range []string{"Арн", "Бея"} {
+		sheet := characters.CharacterSheet{ID: fmt.Sprintf("pc%d", i), Name: name, Level: 3, Initiative: 2}
+		if err := srv.companyStore.AddCharacter(comp.ID, sheet); err != nil {
+			t.Fatalf("add character: %v", err)
+		}
+	}
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	do := func(method, path, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := fmt.Sprintf(`{"name": "Мост", "monsters": [{"monsterId": %q, "count": 2}], "groupInitiative": true}`, troll.ID)
+	rec := do(http.MethodPost, "/companies/"+comp.ID+"/combats", body, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var c combat.Combat
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(c.Participants) != 4 || c.Round != 1 || c.Turn != 0 {
+		t.Fatalf("unexpected combat: %+v", c)
+	}
+	var trolls []combat.Participant
+	for i, p := range c.Participants {
+		if i > 0 && p.Initiative > c.Participants[i-1].Initiative {
+			t.Fatalf("participants are not ordered by initiative: %+v", c.Participants)
+		}
+		if p.Kind == combat.KindMonster {
+			trolls = append(trolls, p)
+		}
+	}
+	if len(trolls) != 2 || trolls[0].Roll != trolls[1].Roll || trolls[0].Name != "Тролль 1" {
+		t.Fatalf("unexpected trolls: %+v", trolls)
+	}
+
+	first := c.Current().ID
+	rec = do(http.MethodPost, "/combats/"+c.ID+"/delay", "", rec.Header().Get("ETag"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	etag := rec.Header().Get("ETag")
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || c.Turn != 1 {
+		t.Fatalf("unexpected combat after delay: %s", rec.Body.String())
+	}
+
+	body = fmt.Sprintf(`{"participantId": %q, "resume": true}`, first)
+	rec = do(http.MethodPost, "/combats/"+c.ID+"/delay", body, etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || c.Current().ID != first {
+		t.Fatalf("resumed participant should act now: %s", rec.Body.String())
+	}
+
+	rec = do(http.MethodPost, "/combats/"+c.ID+"/next", "", etag)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for stale version, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/combats/"+c.ID+"/ready", `{"action": "Атака", "trigger": "тролль подходит"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body = fmt.Sprintf(`{"participantId": %q, "use": true}`, first)
+	rec = do(http.MethodPost, "/combats/"+c.ID+"/ready", body, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "тролль подходит") {
+		t.Fatalf("expected readied action, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, command := range []string{"next", "next", "next", "previous"} {
+		if rec = do(http.MethodPost, "/combats/"+c.ID+"/"+command, "", ""); rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", command, rec.Code, rec.Body.String())
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || c.Round != 1 || c.Turn != 3 {
+		t.Fatalf("unexpected turn: %s", rec.Body.String())
+	}
+
+	if rec = do(http.MethodPost, "/combats/"+c.ID+"/surrender", "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown command, got %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/companies/"+comp.ID+"/combats", "", "")
+	var list []combat.Combat
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Fatalf("unexpected list: %s", rec.Body.String())
+	}
+	if rec = do(http.MethodDelete, "/combats/"+c.ID, "", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec = do(http.MethodGet, "/combats/"+c.ID, "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestCompanyMonsterInstances(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := fmt.Sprintf(`{"monsterId": %q, "count": 3, "hpMode": "max"}`, troll.ID)
+	rec := do(http.MethodPost, "/companies/"+comp.ID+"/monsters", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var added struct {
+		HitPoints int                 `json:"hitPoints"`
+		Instances []monsters.Instance `json:"instances"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	// Тролль 8d10+40: максимум 120 хитов
+	if len(added.Instances) != 3 || added.HitPoints != 120 || added.Instances[2].Name != "Тролль 3" {
+		t.Fatalf("unexpected instances: %s", rec.Body.String())
+	}
+	second := added.Instances[1]
+
+	rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/damage", `{"amount": 50}`)
+	var inst monsters.Instance
+	if err := json.Unmarshal(rec.Body.Bytes(), &inst); err != nil || inst.CurrentHitPoints != 70 {
+		t.Fatalf("unexpected damage result %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/conditions", `{"name": "poisoned"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for condition, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/reaction", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for reaction, got %d", rec.Code)
+	}
+	if rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/reaction", ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a second reaction, got %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/actions/Коготь/roll", `{"targetAC": 10}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for action roll, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	got, err := srv.companyStore.Get(comp.ID)
+	if err != nil {
+		t.Fatalf("get company: %v", err)
+	}
+	if len(got.Monsters) != 1 || len(got.Instances) != 3 {
+		t.Fatalf("expected one monster card and three instances, got %d and %d", len(got.Monsters), len(got.Instances))
+	}
+	for _, inst := range got.Instances {
+		damaged := inst.ID == second.ID
+		if damaged != (inst.CurrentHitPoints == 70) || damaged != (len(inst.Conditions) == 1) || damaged != inst.ReactionUsed {
+			t.Fatalf("state leaked between instances: %+v", got.Instances)
+		}
+	}
+
+	// Экземпляры из компании участвуют в бою со своими хитами
+	body = fmt.Sprintf(`{"name": "Логово", "instances": [%q], "monsters": [{"monsterId": %q, "count": 1}]}`, second.ID, troll.ID)
+	rec = do(http.MethodPost, "/companies/"+comp.ID+"/combats", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var c combat.Combat
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || len(c.Participants) != 2 {
+		t.Fatalf("unexpected combat: %s", rec.Body.String())
+	}
+	var fromRoster, fresh combat.Participant
+	for _, p := range c.Participants {
+		if p.Instance.ID == second.ID {
+			fromRoster = p
+		} else {
+			fresh = p
+		}
+	}
+	if fromRoster.Instance == nil || fromRoster.Instance.CurrentHitPoints != 70 || fresh.Name != "Тролль 3" {
+		t.Fatalf("unexpected participants: %+v", c.Participants)
+	}
+	freshHP := fresh.Instance.CurrentHitPoints
+	rec = do(http.MethodPost, "/combats/"+c.ID+"/participants/"+fresh.ID+"/damage", `{"amount": 10}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected damage result %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, p := range c.Participants {
+		if p.ID == fresh.ID && p.Instance.CurrentHitPoints != freshHP-10 {
+			t.Fatalf("damage was not applied: %+v", p.Instance)
+		}
+	}
+	if rec = do(http.MethodPost, "/combats/"+c.ID+"/participants/"+fresh.ID+"/legendary", ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a monster without legendary actions, got %d", rec.Code)
+	}
+
+	// Состояние экземпляра из компании синхронизируется между боем и составом
+	if rec = do(http.MethodPost, "/combats/"+c.ID+"/participants/"+fromRoster.ID+"/damage", `{"amount": 5}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodGet, "/companies/"+comp.ID+"/instances/"+second.ID, "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &inst); err != nil || inst.CurrentHitPoints != 65 {
+		t.Fatalf("combat damage should reach the roster: %s", rec.Body.String())
+	}
+	if rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/damage", `{"amount": 100}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if synced, err := srv.combatStore.Get(c.ID); err != nil {
+		t.Fatalf("get combat: %v", err)
+	} else if hp, _ := synced.Instance(fromRoster.ID); hp == nil || hp.CurrentHitPoints != 0 {
+		t.Fatalf("roster damage should reach the combat: %+v", hp)
+	}
+	// Перенос из состава записан в журнал боя: отмена возвращает хиты и там, и в составе
+	if synced, _ := srv.combatStore.Get(c.ID); synced.Log[len(synced.Log)-1].Command != "roster" {
+		t.Fatalf("roster damage should be logged in the combat: %+v", synced.Log[len(synced.Log)-1])
+	}
+	if rec = do(http.MethodPost, "/combats/"+c.ID+"/undo", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for undo, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodGet, "/companies/"+comp.ID+"/instances/"+second.ID, "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &inst); err != nil || inst.CurrentHitPoints != 65 {
+		t.Fatalf("undo should restore the roster hit points: %s", rec.Body.String())
+	}
+	if rec = do(http.MethodPost, "/combats/"+c.ID+"/redo", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for redo, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPost, "/companies/"+comp.ID+"/instances/"+second.ID+"/actions/Коготь/roll", "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a defeated instance, got %d", rec.Code)
+	}
+
+	if rec = do(http.MethodDelete, "/companies/"+comp.ID+"/monsters/"+troll.ID, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if got, _ := srv.companyStore.Get(comp.ID); len(got.Instances) != 0 {
+		t.Fatalf("instances should be removed with their monster: %+v", got.Instances)
+	}
+}
+
+func TestCombatInstanceActionRoll(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	dragon, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[0])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+	sub, _ := srv.events.Subscribe(comp.ID, 0)
+	defer srv.events.Unsubscribe(sub)
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Экземпляр создаётся только для боя, в составе компании его нет
+	rec := do("/companies/"+comp.ID+"/combats", fmt.Sprintf(`{"name": "Логово", "monsters": [{"monsterId": %q, "count": 1}]}`, dragon.ID))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var c combat.Combat
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || len(c.Participants) != 1 {
+		t.Fatalf("unexpected combat: %s", rec.Body.String())
+	}
+	participant := c.Participants[0]
+	breath := "/combats/" + c.ID + "/participants/" + participant.ID + "/actions/Огненное%20дыхание/roll"
+
+	rec = do(breath, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Combat combat.Combat             `json:"combat"`
+		Result monsters.ActionRollResult `json:"result"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Result.Available || resp.Result.SaveDC != 21 {
+		t.Fatalf("unexpected roll: %s", rec.Body.String())
+	}
+	if inst := resp.Combat.Participants[0].Instance; !slices.Equal(inst.Spent, []string{"Огненное дыхание"}) {
+		t.Fatalf("breath should wait for recharge: %+v", inst)
+	}
+
+	// Повторное использование бросает d6 на перезарядку
+	rec = do(breath, "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected second roll %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Result.RechargeRoll < 1 || resp.Result.RechargeRoll > 6 {
+		t.Fatalf("expected a recharge roll: %+v", resp.Result)
+	}
+
+	if rec = do("/combats/"+c.ID+"/participants/"+participant.ID+"/actions/Хвост/roll", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown action, got %d", rec.Code)
+	}
+
+	var rolls int
+	for _, e := range drainEvents(sub) {
+		if e.Type != events.TypeRoll {
+			continue
+		}
+		var roll rollEvent
+		if err := json.Unmarshal(e.Data, &roll); err != nil || roll.CombatID != c.ID || roll.ParticipantID != participant.ID || roll.Result == nil {
+			t.Fatalf("unexpected roll event: %s", e.Data)
+		}
+		rolls++
+	}
+	if rolls != 2 {
+		t.Fatalf("expected 2 roll events, got %d", rolls)
+	}
+
+	// Броски записаны в журнал, отмена первого возвращает перезарядку
+	rec = do("/combats/"+c.ID+"/undo", "")
+	rec = do("/combats/"+c.ID+"/undo", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected undo %d: %s", rec.Code, rec.Body.String())
+	}
+	if inst := c.Participants[0].Instance; len(inst.Spent) != 0 {
+		t.Fatalf("undo should recharge the breath: %+v", inst)
+	}
+}
+
+func TestCompanyEventStream(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	goblin, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[0])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/companies/"+comp.ID+"/monsters", "application/json",
+		strings.NewReader(fmt.Sprintf(`{"monsterId": %q, "hpMode": "max"}`, goblin.ID)))
+	if err != nil {
+		t.Fatalf("add monster: %v", err)
+	}
+	var added struct {
+		Instances []monsters.Instance `json:"instances"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil || len(added.Instances) != 1 {
+		t.Fatalf("unexpected add response: %v", err)
+	}
+	resp.Body.Close()
+	inst := added.Instances[0]
+
+	// Клиент получил первое событие (монстр добавлен) и переподключается
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/companies/"+comp.ID+"/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer stream.Body.Close()
+	if ct := stream.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	reader := bufio.NewReader(stream.Body)
+	next := func() (id, typ string, data events.Event) {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read stream: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				typ = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data); err != nil {
+					t.Fatalf("decode event: %v", err)
+				}
+			case line == "" && id != "":
+				return id, typ, data
+			}
+		}
+	}
+
+	if id, typ, _ := next(); id != "2" || typ != events.TypeEntityAdded {
+		t.Fatalf("expected replayed instance event 2, got %s %s", id, typ)
+	}
+
+	resp, err = http.Post(ts.URL+"/companies/"+comp.ID+"/instances/"+inst.ID+"/damage", "application/json",
+		strings.NewReader(`{"amount": 3}`))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("damage: %v", err)
+	}
+	resp.Body.Close()
+	id, typ, e := next()
+	if id != "3" || typ != events.TypeHPChanged {
+		t.Fatalf("expected live hp event 3, got %s %s", id, typ)
+	}
+	var hp hpEvent
+	if err := json.Unmarshal(e.Data, &hp); err != nil || hp.InstanceID != inst.ID || hp.Change != -3 || hp.CurrentHitPoints != inst.MaxHitPoints-3 {
+		t.Fatalf("unexpected hp event: %s", e.Data)
+	}
+
+	// Рукопожатие со страницы чужого сайта отклоняется
+	foreign := httptest.NewRequest(http.MethodGet, "/companies/"+comp.ID+"/events", nil)
+	foreign.Header.Set("Connection", "Upgrade")
+	foreign.Header.Set("Upgrade", "websocket")
+	foreign.Header.Set("Sec-WebSocket-Version", "13")
+	foreign.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	foreign.Header.Set("Origin", "http://evil.example")
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, foreign)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a foreign origin, got %d", rec.Code)
+	}
+
+	// WebSocket получает те же события
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /companies/%s/events?lastEventId=2 HTTP/1.1\r\nHost: test\r\n"+
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n", comp.ID)
+	wsReader := bufio.NewReader(conn)
+	status, _ := wsReader.ReadString('\n')
+	if !strings.Contains(status, "101") {
+		t.Fatalf("expected 101, got %q", status)
+	}
+	accepted := false
+	for {
+		line, err := wsReader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read handshake: %v", err)
+		}
+		if line == "\r\n" {
+			break
+		}
+		accepted = accepted || line == "Sec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=\r\n"
+	}
+	if !accepted {
+		t.Fatal("missing or wrong Sec-WebSocket-Accept")
+	}
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(wsReader, head); err != nil || head[0] != 0x81 {
+		t.Fatalf("expected a text frame, got %v: %v", head, err)
+	}
+	payload := make([]byte, head[1]&0x7F)
+	if head[1]&0x7F == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(wsReader, ext)
+		payload = make([]byte, int(ext[0])<<8|int(ext[1]))
+	}
+	if _, err := io.ReadFull(wsReader, payload); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	var wsEvent events.Event
+	if err := json.Unmarshal(payload, &wsEvent); err != nil || wsEvent.ID != 3 || wsEvent.Type != events.TypeHPChanged {
+		t.Fatalf("unexpected websocket event %s: %v", payload, err)
+	}
+}
+
+// drainEvents забирает уже полученные подпиской события.
+func drainEvents(sub *events.Subscription) []events.Event {
+	var received []events.Event
+	for {
+		select {
+		case e := <-sub.C:
+			received = append(received, e)
+		default:
+			return received
+		}
+	}
+}
+
+func TestCharacterEvents(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	char, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name:             "Aria",
+		Class:            "Rogue",
+		Level:            1,
+		AbilityScores:    characters.AbilityScores{Strength: 8, Dexterity: 16},
+		ProficiencyBonus: 2,
+		MaxHitPoints:     9,
+		CurrentHitPoints: 9,
+		Items:            characters.Inventory{{ItemID: "rapier", Quantity: 1, Equipped: true}},
+	})
+	if err != nil {
+		t.Fatalf("create character: %v", err)
+	}
+	var companyIDs []string
+	for _, name := range []string{"Отряд", "Гильдия", "Чужие"} {
+		comp, err := srv.companyStore.Create(company.Company{Name: name})
+		if err != nil {
+			t.Fatalf("create company: %v", err)
+		}
+		if name != "Чужие" {
+			if err := srv.companyStore.AddCharacter(comp.ID, char); err != nil {
+				t.Fatalf("add character: %v", err)
+			}
+		}
+		companyIDs = append(companyIDs, comp.ID)
+	}
+
+	var subs []*events.Subscription
+	for _, companyID := range companyIDs {
+		sub, _ := srv.events.Subscribe(companyID, 0)
+		defer srv.events.Unsubscribe(sub)
+		subs = append(subs, sub)
+	}
+
+	for _, step := range []struct{ path, body string }{
+		{"/damage", `{"amount": 4}`},
+		{"/heal", `{"amount": 1}`},
+		{"/attack", `{"weapon": "rapier"}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/characters/"+char.ID+step.path, strings.NewReader(step.body))
+		rec := httptest.NewRecorder()
+		srv.handleCharacterByID(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", step.path, rec.Code, rec.Body.String())
+		}
+	}
+
+	for i, sub := range subs {
+		replay := drainEvents(sub)
+		if i == 2 {
+			if len(replay) != 0 {
+				t.Fatalf("company without the character got events: %+v", replay)
+			}
+			continue
+		}
+		if len(replay) != 3 {
+			t.Fatalf("expected 3 events, got %d", len(replay))
+		}
+
+		var damage, heal hpEvent
+		if replay[0].Type != events.TypeHPChanged || json.Unmarshal(replay[0].Data, &damage) != nil ||
+			damage.CharacterID != char.ID || damage.Change != -4 || damage.CurrentHitPoints != 5 || damage.MaxHitPoints != 9 {
+			t.Fatalf("unexpected damage event: %s %s", replay[0].Type, replay[0].Data)
+		}
+		if replay[1].Type != events.TypeHPChanged || json.Unmarshal(replay[1].Data, &heal) != nil ||
+			heal.CharacterID != char.ID || heal.Change != 1 || heal.CurrentHitPoints != 6 {
+			t.Fatalf("unexpected heal event: %s %s", replay[1].Type, replay[1].Data)
+		}
+		var roll rollEvent
+		if replay[2].Type != events.TypeRoll || json.Unmarshal(replay[2].Data, &roll) != nil ||
+			roll.CharacterID != char.ID || roll.Attack == nil || roll.Result != nil || roll.Attack.Ability != "dexterity" {
+			t.Fatalf("unexpected roll event: %s %s", replay[2].Type, replay[2].Data)
+		}
+	}
+}
+
+func TestCombatLogUndoRedo(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	if err := srv.companyStore.AddCharacter(comp.ID, characters.CharacterSheet{ID: "pc", Name: "Арн", Level: 3}); err != nil {
+		t.Fatalf("add character: %v", err)
+	}
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) combat.Combat {
+		t.Helper()
+		if rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+		}
+		var c combat.Combat
+		if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		return c
+	}
+
+	body := fmt.Sprintf(`{"name": "Мост", "monsters": [{"monsterId": %q, "count": 1}], "hpMode": "max"}`, troll.ID)
+	c := decode(do(http.MethodPost, "/companies/"+comp.ID+"/combats", body))
+	var trollID, pcID string
+	for _, p := range c.Participants {
+		if p.Instance != nil {
+			trollID = p.ID
+		} else {
+			pcID = p.ID
+		}
+	}
+	base := "/combats/" + c.ID
+
+	decode(do(http.MethodPost, base+"/participants/"+trollID+"/damage", `{"amount": 20}`))
+	decode(do(http.MethodPost, base+"/participants/"+pcID+"/conditions", `{"name": "poisoned"}`))
+	c = decode(do(http.MethodPost, base+"/participants/"+pcID+"/initiative", `{"initiative": 25}`))
+	acting := c.Current().ID
+	if c.Participants[0].ID != pcID {
+		t.Fatalf("expected the character first: %+v", c.Participants)
+	}
+	c = decode(do(http.MethodPost, base+"/next", ""))
+	if c.Current().ID == acting {
+		t.Fatalf("next should pass the turn")
+	}
+
+	if rec := do(http.MethodGet, base, ""); strings.Contains(rec.Body.String(), `"before"`) || strings.Contains(rec.Body.String(), `"log"`) {
+		t.Fatalf("combat response should not include the log: %s", rec.Body.String())
+	}
+
+	sub, _ := srv.events.Subscribe(comp.ID, 0)
+	defer srv.events.Unsubscribe(sub)
+	c = decode(do(http.MethodPost, base+"/undo", ""))
+	if c.Current().ID != acting {
+		t.Fatalf("undo should return the turn to %s", acting)
+	}
+	for i := 0; i < 3; i++ {
+		c = decode(do(http.MethodPost, base+"/undo", ""))
+	}
+	// Клиенты узнают о восстановленных хитах
+	restored := false
+	for len(sub.C) > 0 {
+		e := <-sub.C
+		var hp hpEvent
+		if e.Type == events.TypeHPChanged && json.Unmarshal(e.Data, &hp) == nil && hp.ParticipantID == trollID && hp.Change == 20 {
+			restored = true
+		}
+	}
+	if !restored {
+		t.Fatal("undoing damage should publish hp.changed")
+	}
+	for _, p := range c.Participants {
+		if p.ID == trollID && p.Instance.CurrentHitPoints != p.Instance.MaxHitPoints {
+			t.Fatalf("undo should restore troll hit points: %+v", p.Instance)
+		}
+		if p.ID == pcID && len(p.Conditions) != 0 {
+			t.Fatalf("undo should remove the condition: %+v", p.Conditions)
+		}
+	}
+	if rec := do(http.MethodPost, base+"/undo", ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 with nothing to undo, got %d", rec.Code)
+	}
+	c = decode(do(http.MethodPost, base+"/redo", ""))
+	for _, p := range c.Participants {
+		if p.ID == trollID && p.Instance.CurrentHitPoints != p.Instance.MaxHitPoints-20 {
+			t.Fatalf("redo should reapply damage: %+v", p.Instance)
+		}
+	}
+
+	rec := do(http.MethodGet, base+"/log", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var log combatLogResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &log); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(log.Entries) != 9 || log.Entries[0].Before != nil || len(log.Narrative) != 9 {
+		t.Fatalf("unexpected log: %s", rec.Body.String())
+	}
+	if !strings.Contains(log.Narrative[0], "takes 20 damage") || !strings.Contains(log.Narrative[1], "Арн is poisoned") ||
+		!strings.HasPrefix(log.Narrative[8], "Round 1: Redid:") {
+		t.Fatalf("unexpected narrative: %q", log.Narrative)
+	}
+}
+
+func TestCombatCharacterHitPoints(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	char, err := srv.characterStore.Create(characters.CharacterSheet{
+		Name: "Арн", Class: "Fighter", Level: 3, ProficiencyBonus: 2,
+		AbilityScores: characters.AbilityScores{Strength: 16, Dexterity: 12, Constitution: 14},
+		MaxHitPoints:  28, CurrentHitPoints: 28,
+	})
+	if err != nil {
+		t.Fatalf("create character: %v", err)
+	}
+	if err := srv.companyStore.AddCharacter(comp.ID, char); err != nil {
+		t.Fatalf("add character: %v", err)
+	}
+
+	do := func(path, body string) combat.Combat {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+			t.Fatalf("%s: unexpected status %d: %s", path, rec.Code, rec.Body.String())
+		}
+		var c combat.Combat
+		if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		return c
+	}
+	hitPoints := func() int {
+		t.Helper()
+		sheet, err := srv.characterStore.Get(char.ID)
+		if err != nil {
+			t.Fatalf("get character: %v", err)
+		}
+		return sheet.CurrentHitPoints
+	}
+
+	c := do("/companies/"+comp.ID+"/combats", `{"name": "Мост"}`)
+	base := "/combats/" + c.ID + "/participants/" + c.Participants[0].ID
+
+	c = do(base+"/damage", `{"amount": 10}`)
+	if v := c.Participants[0].Vitals; v == nil || v.CurrentHitPoints != 18 || hitPoints() != 18 {
+		t.Fatalf("damage should reach the sheet: %+v, sheet %d", v, hitPoints())
+	}
+	c = do(base+"/heal", `{"amount": 4}`)
+	if hitPoints() != 22 {
+		t.Fatalf("heal should reach the sheet, got %d", hitPoints())
+	}
+
+	do("/combats/"+c.ID+"/undo", "")
+	if hitPoints() != 18 {
+		t.Fatalf("undo should revert healing on the sheet, got %d", hitPoints())
+	}
+	do("/combats/"+c.ID+"/undo", "")
+	if hitPoints() != 28 {
+		t.Fatalf("undo should revert damage on the sheet, got %d", hitPoints())
+	}
+	do("/combats/"+c.ID+"/redo", "")
+	if hitPoints() != 18 {
+		t.Fatalf("redo should reapply damage on the sheet, got %d", hitPoints())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/combats/"+c.ID+"/log", nil)
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	var log combatLogResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &log); err != nil || len(log.Entries) != 5 {
+		t.Fatalf("unexpected log: %s", rec.Body.String())
+	}
+	if log.Entries[0].Command != "damage" || !strings.Contains(log.Entries[0].Summary, "Арн takes 10 damage (28 → 18 HP)") ||
+		log.Entries[1].Command != "heal" {
+		t.Fatalf("unexpected log entries: %+v", log.Entries[:2])
+	}
+}
+
+func TestConcurrentInstanceDamage(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	comp, err := srv.companyStore.Create(company.Company{Name: "Отряд"})
+	if err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	troll, err := srv.monsterStore.Create(monsters.GetSampleMonsters()[4])
+	if err != nil {
+		t.Fatalf("create monster: %v", err)
+	}
+	instances, err := monsters.NewInstances(troll, 1, monsters.HPMax, nil)
+	if err != nil {
+		t.Fatalf("NewInstances error: %v", err)
+	}
+	if err := srv.companyStore.AddMonsterInstances(comp.ID, troll, instances); err != nil {
+		t.Fatalf("add instances: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/companies/"+comp.ID+"/instances/"+instances[0].ID+"/damage", strings.NewReader(`{"amount": 1}`))
+			rec := httptest.NewRecorder()
+			srv.routes().ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected 200, got %d", rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := srv.companyStore.Get(comp.ID)
+	if hp := got.Instances[0].CurrentHitPoints; hp != instances[0].MaxHitPoints-20 {
+		t.Fatalf("concurrent damage was lost: %d of %d hit points left", hp, instances[0].MaxHitPoints)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/company"
+	"dice-service/internal/jsonpatch"
+	"dice-service/internal/monsters"
+)
+
+// maxPatchSize ограничение размера тела PATCH-запроса.
+const maxPatchSize = 1 << 20
+
+// patchDocument применяет патч из тела запроса к текущему состоянию ресурса
+// и раскладывает результат в target с проверкой неизвестных полей.
+// Формат выбирается по Content-Type: application/json-patch+json - RFC 6902,
+// application/merge-patch+json или application/json - RFC 7386.
+// При ошибке возвращает HTTP-код ответа.
+func patchDocument(w http.ResponseWriter, r *http.Request, current, target any) (int, error) {
+	mediaType := jsonpatch.MediaTypeMergePatch
+	if header := r.Header.Get("Content-Type"); header != "" {
+		parsed, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			return http.StatusUnsupportedMediaType, errors.New("invalid Content-Type")
+		}
+		mediaType = parsed
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return http.StatusRequestEntityTooLarge, errors.New("patch is too large")
+		}
+		return http.StatusBadRequest, errors.New("failed to read request body")
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	var patched []byte
+	switch mediaType {
+	case jsonpatch.MediaTypeMergePatch, "application/json":
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	case jsonpatch.MediaTypeJSONPatch:
+		var ops []jsonpatch.Operation
+		ops, err = jsonpatch.DecodeOperations(patch)
+		if err == nil {
+			patched, err = jsonpatch.Apply(doc, ops)
+		}
+	default:
+		return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported patch format %q, use %s or %s",
+			mediaType, jsonpatch.MediaTypeMergePatch, jsonpatch.MediaTypeJSONPatch)
+	}
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return http.StatusConflict, err
+		}
+		return http.StatusBadRequest, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("patched document is invalid: %w", err)
+	}
+	return http.StatusOK, nil
+}
+
+func (s *server) patchCharacter(w http.ResponseWriter, r *http.Request, id string) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := s.characterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var sheet characters.CharacterSheet
+	if code, err := patchDocument(w, r, existing, &sheet); err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	// Патч применяется к прочитанной версии, если клиент не указал свою
+	sheet.ID = id
+	sheet.Version = existing.Version
+	if version != 0 {
+		sheet.Version = version
+	}
+	if characters.ArmorClassInputsChanged(existing, sheet) {
+		sheet.ArmorClass = characters.ComputeArmorClass(sheet)
+	}
+
+	updated, err := s.characterStore.UpdateLabeled(id, sheet, "patch")
+	if err != nil {
+		if errors.Is(err, characters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		if errors.Is(err, characters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *server) patchMonster(w http.ResponseWriter, r *http.Request, id string) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := s.monsterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var monster monsters.Monster
+	if code, err := patchDocument(w, r, existing, &monster); err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	monster.ID = id
+	monster.Version = existing.Version
+	if version != 0 {
+		monster.Version = version
+	}
+
+	updated, err := s.monsterStore.Update(id, monster)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		if errors.Is(err, monsters.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.regenerateDerived(w, updated)
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *server) patchCompany(w http.ResponseWriter, r *http.Request, id string) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := s.companyStore.Get(id)
+	if err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var comp company.Company
+	if code, err := patchDocument(w, r, existing, &comp); err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	comp.ID = id
+	comp.Version = existing.Version
+	if version != 0 {
+		comp.Version = version
+	}
+
+	if err := s.companyStore.Update(comp); err != nil {
+		if errors.Is(err, company.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "company not found")
+			return
+		}
+		if errors.Is(err, company.ErrVersionConflict) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		if errors.Is(err, company.ErrDuplicateName) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.companyStore.Get(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	setETag(w, updated.Version)
+	writeJSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"dice-service/internal/monsters"
+)
+
+type scaleRequest struct {
+	ChallengeRating *monsters.ChallengeRating `json:"challengeRating"`
+	Save            bool                      `json:"save"`
+}
+
+// scaleMonster пересчитывает монстра под другой показатель опасности.
+// Без save возвращает несохранённую копию, с save - сохраняет её
+// со ссылкой на исходного монстра (scaledFrom).
+func (s *server) scaleMonster(w http.ResponseWriter, r *http.Request, id string) {
+	var payload scaleRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if payload.ChallengeRating == nil {
+		writeError(w, http.StatusBadRequest, "challengeRating is required")
+		return
+	}
+
+	monster, err := s.monsterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scaled, err := monsters.Scale(monster, *payload.ChallengeRating)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !payload.Save {
+		writeJSON(w, http.StatusOK, scaled)
+		return
+	}
+
+	created, err := s.monsterStore.Create(scaled)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setETag(w, created.Version)
+	writeJSON(w, http.StatusCreated, created)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+
+	"dice-service/internal/monsters"
+	"dice-service/internal/render"
+)
+
+// maxStatBlockSize ограничение размера импортируемого блока характеристик.
+const maxStatBlockSize = 64 << 10
+
+type statBlockRequest struct {
+	Text string `json:"text"`
+	Save bool   `json:"save"`
+}
+
+type statBlockResponse struct {
+	Monster  monsters.Monster `json:"monster"`
+	Warnings []string         `json:"warnings"`
+	Saved    bool             `json:"saved"`
+}
+
+// handleImportStatBlock разбирает вставленный текстовый блок характеристик.
+// Принимает text/plain (сохранение - ?save=true) или JSON {"text", "save"}.
+// Без сохранения возвращает разобранного монстра для проверки.
+func (s *server) handleImportStatBlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxStatBlockSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "stat block is too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	payload := statBlockRequest{Text: string(body), Save: r.URL.Query().Get("save") == "true"}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON payload")
+			return
+		}
+	}
+
+	monster, warnings := monsters.ParseStatBlock(payload.Text)
+	if !payload.Save {
+		writeJSON(w, http.StatusOK, statBlockResponse{Monster: monster, Warnings: warnings})
+		return
+	}
+
+	if err := monsters.ValidateHitDice(monster); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	created, err := s.monsterStore.Create(monster)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setETag(w, created.Version)
+	writeJSON(w, http.StatusCreated, statBlockResponse{Monster: created, Warnings: warnings, Saved: true})
+}
+
+// getMonsterStatBlock отдаёт блок характеристик монстра. Формат берётся из
+// параметра ?format=, затем из заголовка Accept; по умолчанию Markdown.
+func (s *server) getMonsterStatBlock(w http.ResponseWriter, r *http.Request, id string) {
+	format := render.Negotiate(r.Header.Get("Accept"))
+	if value := r.URL.Query().Get("format"); value != "" {
+		parsed, err := render.ParseFormat(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		format = parsed
+	}
+	if format == "" {
+		format = render.FormatMarkdown
+	}
+
+	monster, err := s.monsterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeStatBlock(w, monster, format)
+}
+
+func (s *server) writeStatBlock(w http.ResponseWriter, monster monsters.Monster, format string) {
+	var buf bytes.Buffer
+	if err := render.StatBlock(&buf, monster, format); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	setETag(w, monster.Version)
+	w.Header().Set("Content-Type", render.ContentType(format))
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"dice-service/internal/monsters"
+)
+
+type applyTemplateRequest struct {
+	TemplateID string `json:"templateId"`
+	Save       bool   `json:"save"`
+}
+
+// handleMonsterTemplates обрабатывает /monsters/templates: список и создание шаблонов.
+func (s *server) handleMonsterTemplates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.templateStore.List())
+	case http.MethodPost:
+		var t monsters.Template
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&t); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON payload")
+			return
+		}
+		created, err := s.templateStore.Create(t)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		setETag(w, created.Version)
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleMonsterTemplateByID обрабатывает /monsters/templates/{id}. Изменение
+// шаблона пересоздаёт всех монстров, полученных с его помощью; пока такие
+// монстры есть, шаблон нельзя удалить.
+func (s *server) handleMonsterTemplateByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/monsters/templates/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		t, err := s.templateStore.Get(id)
+		if err != nil {
+			writeTemplateError(w, err)
+			return
+		}
+		setETag(w, t.Version)
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodPut:
+		var t monsters.Template
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&t); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON payload")
+			return
+		}
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if version != 0 {
+			t.Version = version
+		}
+		updated, err := s.templateStore.Update(id, t)
+		if err != nil {
+			writeTemplateError(w, err)
+			return
+		}
+		if _, err := monsters.RegenerateTemplate(s.monsterStore, s.templateStore, updated); err != nil {
+			log.Printf("failed to regenerate monsters of template %s: %v", id, err)
+			setRegenerationWarning(w, err)
+		}
+		setETag(w, updated.Version)
+		writeJSON(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := monsters.DeleteTemplate(s.monsterStore, s.templateStore, id, version); err != nil {
+			writeTemplateError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"message": "template deleted"})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// applyMonsterTemplate применяет шаблон к монстру. Без save возвращает
+// несохранённого производного монстра, с save - сохраняет его.
+func (s *server) applyMonsterTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	var payload applyTemplateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	base, err := s.monsterStore.Get(id)
+	if err != nil {
+		if errors.Is(err, monsters.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "monster not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	t, err := s.templateStore.Get(payload.TemplateID)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	derived, err := monsters.ApplyTemplate(base, t)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !payload.Save {
+		writeJSON(w, http.StatusOK, derived)
+		return
+	}
+
+	created, err := s.monsterStore.Create(derived)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setETag(w, created.Version)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// regenerateDerived пересоздаёт монстров, полученных шаблонами из изменённого.
+// Ошибка не отменяет сохранения базы: она записывается в лог и
+// сообщается клиенту заголовком Warning.
+func (s *server) regenerateDerived(w http.ResponseWriter, base monsters.Monster) {
+	if _, err := monsters.RegenerateDerived(s.monsterStore, s.templateStore, base); err != nil {
+		log.Printf("failed to regenerate monsters derived from %s: %v", base.ID, err)
+		setRegenerationWarning(w, err)
+	}
+}
+
+// setRegenerationWarning добавляет по заголовку Warning на каждую
+// ошибку пересоздания производных монстров.
+func setRegenerationWarning(w http.ResponseWriter, err error) {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		w.Header().Add("Warning", fmt.Sprintf("199 - %q", "regeneration failed: "+line))
+	}
+}
+
+func writeTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, monsters.ErrTemplateNotFound):
+		writeError(w, http.StatusNotFound, "template not found")
+	case errors.Is(err, monsters.ErrTemplateReadOnly):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, monsters.ErrVersionConflict):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, monsters.ErrTemplateInUse):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
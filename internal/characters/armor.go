@@ -0,0 +1,113 @@
+package characters
+
+import (
+	"slices"
+
+	"dice-service/internal/items"
+)
+
+// resolveItem находит предмет справочника для записи инвентаря:
+// сначала по ссылке itemId, затем по названию.
+func resolveItem(entry InventoryItem) (items.Item, bool) {
+	if entry.ItemID != "" {
+		item, err := items.Get(entry.ItemID)
+		return item, err == nil
+	}
+	item, err := items.Find(entry.Name)
+	return item, err == nil
+}
+
+// wornArmor надетый доспех или щит вместе с магическим бонусом записи инвентаря.
+type wornArmor struct {
+	items.Item
+	MagicBonus int
+}
+
+// equippedArmor возвращает надетый доспех и щит персонажа, если они есть.
+func equippedArmor(sheet CharacterSheet) (armor *wornArmor, shield *wornArmor) {
+	for _, entry := range sheet.Items {
+		if !entry.Equipped {
+			continue
+		}
+		item, ok := resolveItem(entry)
+		if !ok || item.Armor == nil {
+			continue
+		}
+		found := &wornArmor{Item: item, MagicBonus: entry.MagicBonus}
+		switch item.Category {
+		case items.CategoryArmor:
+			if armor == nil || item.Armor.BaseAC+entry.MagicBonus > armor.Armor.BaseAC+armor.MagicBonus {
+				armor = found
+			}
+		case items.CategoryShield:
+			if shield == nil {
+				shield = found
+			}
+		}
+	}
+	return armor, shield
+}
+
+// hasClass сообщает, есть ли у персонажа уровни в указанном классе
+// (название класса персонажа может быть на русском или английском).
+func hasClass(sheet CharacterSheet, class string) bool {
+	if len(sheet.Classes) == 0 {
+		return canonicalClass(sheet.Class) == class
+	}
+	return classLevelIndex(sheet.Classes, class) >= 0
+}
+
+// ComputeArmorClass считает КД по надетому снаряжению:
+//   - лёгкий доспех: база + модификатор Ловкости
+//   - средний доспех: база + модификатор Ловкости (не больше +2)
+//   - тяжёлый доспех: база без Ловкости
+//   - без доспеха: 10 + Ловкость, у варвара + Телосложение, у монаха без щита + Мудрость
+//
+// Надетый щит добавляет свой бонус, магические доспехи и щиты - свой магический бонус.
+func ComputeArmorClass(sheet CharacterSheet) int {
+	dexMod := abilityModifier(sheet.AbilityScores.Dexterity)
+	armor, shield := equippedArmor(sheet)
+
+	ac := 10 + dexMod
+	switch {
+	case armor != nil:
+		ac = armor.Armor.BaseAC + armor.MagicBonus
+		// Тяжёлый доспех не учитывает Ловкость, даже отрицательную
+		if limit := armor.Armor.DexCap(); limit < 0 {
+			ac += dexMod
+		} else if armor.Armor.Type != items.ArmorHeavy {
+			ac += min(dexMod, limit)
+		}
+	case hasClass(sheet, "barbarian"):
+		ac += abilityModifier(sheet.AbilityScores.Constitution)
+	case hasClass(sheet, "monk") && shield == nil:
+		ac += abilityModifier(sheet.AbilityScores.Wisdom)
+	}
+
+	if shield != nil {
+		ac += shield.Armor.BaseAC + shield.MagicBonus
+	}
+	return ac
+}
+
+// ArmorClassInputsChanged изменилось то, от чего зависит КД: снаряжение,
+// характеристики или состав классов (повышение уровня в тех же классах
+// КД не меняет). Тогда сохранённый КД нужно пересчитать.
+func ArmorClassInputsChanged(before, after CharacterSheet) bool {
+	return !before.Items.Equal(after.Items) ||
+		before.AbilityScores != after.AbilityScores ||
+		!slices.Equal(classNames(before), classNames(after))
+}
+
+// classNames канонические названия классов персонажа по возрастанию.
+func classNames(sheet CharacterSheet) []string {
+	if len(sheet.Classes) == 0 {
+		return []string{canonicalClass(sheet.Class)}
+	}
+	names := make([]string, 0, len(sheet.Classes))
+	for _, cl := range sheet.Classes {
+		names = append(names, canonicalClass(cl.Class))
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,112 @@
+package characters
+
+import "testing"
+
+func TestComputeArmorClass(t *testing.T) {
+	t.Parallel()
+
+	abilities := AbilityScores{Strength: 16, Dexterity: 16, Constitution: 14, Wisdom: 14}
+
+	tests := []struct {
+		name  string
+		class string
+		dex   int // 0 - Ловкость из abilities
+		items Inventory
+		want  int
+	}{
+		{name: "unarmored", class: "Wizard", want: 13},
+		{name: "barbarian unarmored defense", class: "Barbarian", want: 15},
+		{name: "monk unarmored defense", class: "монах", want: 15},
+		{
+			name:  "monk loses unarmored defense with shield",
+			class: "Monk",
+			items: Inventory{{ItemID: "shield", Quantity: 1, Equipped: true}},
+			want:  15,
+		},
+		{
+			name:  "light armor adds full dexterity",
+			class: "Rogue",
+			items: Inventory{{ItemID: "studded-leather", Quantity: 1, Equipped: true}},
+			want:  15,
+		},
+		{
+			name:  "medium armor caps dexterity",
+			class: "Ranger",
+			items: Inventory{{Name: "Half plate", Quantity: 1, Equipped: true}},
+			want:  17,
+		},
+		{
+			name:  "heavy armor and shield",
+			class: "Fighter",
+			items: Inventory{
+				{ItemID: "plate", Quantity: 1, Equipped: true},
+				{ItemID: "shield", Quantity: 1, Equipped: true},
+			},
+			want: 20,
+		},
+		{
+			name:  "heavy armor ignores negative dexterity",
+			class: "Fighter",
+			dex:   8,
+			items: Inventory{{ItemID: "plate", Quantity: 1, Equipped: true}},
+			want:  18,
+		},
+		{
+			name:  "medium armor keeps negative dexterity",
+			class: "Ranger",
+			dex:   8,
+			items: Inventory{{Name: "Half plate", Quantity: 1, Equipped: true}},
+			want:  14,
+		},
+		{
+			name:  "unequipped armor is ignored",
+			class: "Fighter",
+			items: Inventory{{ItemID: "plate", Quantity: 1}},
+			want:  13,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sheet := CharacterSheet{Class: tt.class, AbilityScores: abilities, Items: tt.items}
+			if tt.dex != 0 {
+				sheet.AbilityScores.Dexterity = tt.dex
+			}
+			if got := ComputeArmorClass(sheet); got != tt.want {
+				t.Fatalf("ComputeArmorClass() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArmorClassInputsChanged(t *testing.T) {
+	t.Parallel()
+
+	before := CharacterSheet{
+		Class:         "Fighter",
+		Level:         3,
+		Classes:       []ClassLevel{{Class: "Fighter", Level: 3}},
+		AbilityScores: AbilityScores{Dexterity: 14, Wisdom: 16},
+	}
+
+	leveled := before
+	leveled.Level = 4
+	leveled.Classes = []ClassLevel{{Class: "Fighter", Level: 4}}
+	if ArmorClassInputsChanged(before, leveled) {
+		t.Fatal("a level in the same class should not change AC")
+	}
+
+	multiclassed := before
+	multiclassed.Classes = []ClassLevel{{Class: "Fighter", Level: 3}, {Class: "Monk", Level: 1}}
+	if !ArmorClassInputsChanged(before, multiclassed) {
+		t.Fatal("a new class should change AC")
+	}
+
+	dexterous := before
+	dexterous.AbilityScores.Dexterity = 16
+	if !ArmorClassInputsChanged(before, dexterous) {
+		t.Fatal("a new ability score should change AC")
+	}
+}
@@ -0,0 +1,262 @@
+package characters
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/conditions"
+	"dice-service/internal/dice"
+	"dice-service/internal/items"
+)
+
+var ErrWeaponNotFound = errors.New("weapon not found")
+
+// AttackOptions параметры атаки оружием.
+type AttackOptions struct {
+	Weapon    string // ID записи инвентаря, ID предмета справочника или название
+	TargetAC  int
+	Mode      string // normal, advantage, disadvantage
+	TwoHanded bool   // для универсального оружия - хват двумя руками
+}
+
+// DamageRoll бросок урона.
+type DamageRoll struct {
+	Expression string `json:"expression"`
+	Rolls      []int  `json:"rolls"`
+	Modifier   int    `json:"modifier"`
+	Total      int    `json:"total"`
+	Type       string `json:"type"`
+}
+
+// AttackResult результат атаки оружием.
+type AttackResult struct {
+	Weapon      string         `json:"weapon"`
+	Ability     string         `json:"ability"` // strength или dexterity
+	AttackBonus int            `json:"attackBonus"`
+	Proficient  bool           `json:"proficient"`
+	D20         dice.D20Result `json:"d20"`
+	AttackTotal int            `json:"attackTotal"`
+	TargetAC    int            `json:"targetAC"`
+	Hit         bool           `json:"hit"`
+	Critical    bool           `json:"critical"`
+	Damage      *DamageRoll    `json:"damage,omitempty"`
+}
+
+// findWeapon ищет оружие в инвентаре (по ID записи, ссылке на справочник или названию),
+// а если его там нет - в справочнике снаряжения.
+func findWeapon(sheet CharacterSheet, ref string) (items.Item, int, error) {
+	ref = strings.TrimSpace(ref)
+	for _, entry := range sheet.Items {
+		if entry.ID != ref && !strings.EqualFold(entry.ItemID, ref) && !strings.EqualFold(entry.Name, ref) {
+			continue
+		}
+		item, ok := resolveItem(entry)
+		if !ok || item.Weapon == nil {
+			return items.Item{}, 0, fmt.Errorf("%q is not a weapon", ref)
+		}
+		return item, entry.MagicBonus, nil
+	}
+
+	item, err := items.Find(ref)
+	if err != nil {
+		return items.Item{}, 0, ErrWeaponNotFound
+	}
+	if item.Weapon == nil {
+		return items.Item{}, 0, fmt.Errorf("%q is not a weapon", ref)
+	}
+	return item, 0, nil
+}
+
+// classWeaponProficiencies владение оружием по классам: "simple" и "martial" означают
+// всю группу, остальные значения - ID конкретного оружия.
+var classWeaponProficiencies = map[string][]string{
+	"barbarian": {items.WeaponSimple, items.WeaponMartial},
+	"bard":      {items.WeaponSimple, "hand-crossbow", "longsword", "rapier", "shortsword"},
+	"cleric":    {items.WeaponSimple},
+	"druid":     {"club", "dagger", "dart", "javelin", "mace", "quarterstaff", "scimitar", "sickle", "sling", "spear"},
+	"fighter":   {items.WeaponSimple, items.WeaponMartial},
+	"monk":      {items.WeaponSimple, "shortsword"},
+	"paladin":   {items.WeaponSimple, items.WeaponMartial},
+	"ranger":    {items.WeaponSimple, items.WeaponMartial},
+	"rogue":     {items.WeaponSimple, "hand-crossbow", "longsword", "rapier", "shortsword"},
+	"sorcerer":  {"dagger", "dart", "sling", "quarterstaff", "light-crossbow"},
+	"warlock":   {items.WeaponSimple},
+	"wizard":    {"dagger", "dart", "sling", "quarterstaff", "light-crossbow"},
+	"artificer": {items.WeaponSimple},
+}
+
+// classAliases русские названия классов.
+var classAliases = map[string]string{
+	"варвар":       "barbarian",
+	"бард":         "bard",
+	"жрец":         "cleric",
+	"друид":        "druid",
+	"воин":         "fighter",
+	"монах":        "monk",
+	"паладин":      "paladin",
+	"следопыт":     "ranger",
+	"плут":         "rogue",
+	"чародей":      "sorcerer",
+	"колдун":       "warlock",
+	"волшебник":    "wizard",
+	"изобретатель": "artificer",
+}
+
+// canonicalClass приводит название класса к английскому виду в нижнем регистре.
+func canonicalClass(class string) string {
+	lower := strings.ToLower(strings.TrimSpace(class))
+	if canonical, ok := classAliases[lower]; ok {
+		return canonical
+	}
+	return lower
+}
+
+// multiclassWeaponProficiencies владение оружием, которое даёт класс,
+// взятый не первым (при мультиклассе).
+var multiclassWeaponProficiencies = map[string][]string{
+	"barbarian": {items.WeaponSimple, items.WeaponMartial},
+	"fighter":   {items.WeaponSimple, items.WeaponMartial},
+	"monk":      {items.WeaponSimple, "shortsword"},
+	"paladin":   {items.WeaponSimple, items.WeaponMartial},
+	"ranger":    {items.WeaponSimple, items.WeaponMartial},
+}
+
+func isProficientWith(sheet CharacterSheet, weapon items.Item) bool {
+	proficiencies := append([]string(nil), classWeaponProficiencies[canonicalClass(sheet.Class)]...)
+	for i, cl := range sheet.Classes {
+		if i > 0 {
+			proficiencies = append(proficiencies, multiclassWeaponProficiencies[canonicalClass(cl.Class)]...)
+		}
+	}
+	for _, p := range proficiencies {
+		if p == weapon.Weapon.Group || p == weapon.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Attack совершает атаку оружием: бросок d20 с преимуществом или помехой
+// (с учётом состояний атакующего), бонус атаки от Силы или Ловкости
+// (фехтовальное оружие использует лучшую из них), бонуса мастерства и магического бонуса.
+// При попадании бросается урон; на критическом попадании кости урона удваиваются.
+func Attack(sheet CharacterSheet, opts AttackOptions) (AttackResult, error) {
+	if opts.TargetAC < 0 {
+		return AttackResult{}, errors.New("target AC must not be negative")
+	}
+	effects := conditions.Summarize(sheet.Conditions)
+	if currentState(sheet) != StateConscious || effects.Incapacitated {
+		return AttackResult{}, errors.New("character is incapacitated and cannot attack")
+	}
+
+	weapon, magicBonus, err := findWeapon(sheet, opts.Weapon)
+	if err != nil {
+		return AttackResult{}, err
+	}
+	stats := weapon.Weapon
+
+	strMod := abilityModifier(sheet.AbilityScores.Strength)
+	dexMod := abilityModifier(sheet.AbilityScores.Dexterity)
+	ability, abilityMod := "strength", strMod
+	switch {
+	case stats.HasProperty(items.PropertyFinesse):
+		if dexMod > strMod {
+			ability, abilityMod = "dexterity", dexMod
+		}
+	case stats.Ranged:
+		ability, abilityMod = "dexterity", dexMod
+	}
+
+	result := AttackResult{
+		Weapon:     weapon.Name,
+		Ability:    ability,
+		Proficient: isProficientWith(sheet, weapon),
+		TargetAC:   opts.TargetAC,
+	}
+	result.AttackBonus = abilityMod + magicBonus
+	if result.Proficient {
+		result.AttackBonus += sheet.ProficiencyBonus
+	}
+
+	mode := opts.Mode
+	switch mode {
+	case "", dice.ModeNormal, dice.ModeAdvantage, dice.ModeDisadvantage:
+	default:
+		return AttackResult{}, fmt.Errorf("unknown advantage state %q", opts.Mode)
+	}
+	modes := []string{mode}
+	if effects.AttackAdvantage {
+		modes = append(modes, dice.ModeAdvantage)
+	}
+	if effects.AttackDisadvantage {
+		modes = append(modes, dice.ModeDisadvantage)
+	}
+	mode = dice.CombineModes(modes...)
+
+	d20, err := dice.RollD20(mode)
+	if err != nil {
+		return AttackResult{}, err
+	}
+	result.D20 = d20
+	result.AttackTotal = d20.Natural + result.AttackBonus
+	result.Critical = d20.Natural == 20
+	result.Hit = result.Critical || (d20.Natural != 1 && result.AttackTotal >= opts.TargetAC)
+	if !result.Hit {
+		return result, nil
+	}
+
+	damageDice := stats.Damage
+	if opts.TwoHanded && stats.VersatileDamage != "" {
+		damageDice = stats.VersatileDamage
+	}
+	damage, err := rollWeaponDamage(damageDice, abilityMod+magicBonus, result.Critical)
+	if err != nil {
+		return AttackResult{}, err
+	}
+	damage.Type = stats.DamageType
+	result.Damage = &damage
+	return result, nil
+}
+
+// rollWeaponDamage бросает кости урона оружия с модификатором. Урон без костей
+// (например, "1" у духовой трубки) считается фиксированным.
+func rollWeaponDamage(damageDice string, modifier int, critical bool) (DamageRoll, error) {
+	roll := DamageRoll{Modifier: modifier}
+
+	expr, err := dice.ParseExpression(damageDice)
+	if err != nil {
+		flat, convErr := strconv.Atoi(strings.TrimSpace(damageDice))
+		if convErr != nil {
+			return DamageRoll{}, fmt.Errorf("invalid weapon damage %q: %w", damageDice, err)
+		}
+		roll.Expression = formatDamage(damageDice, modifier)
+		roll.Total = max(1, flat+modifier)
+		return roll, nil
+	}
+
+	if critical {
+		expr = expr.DoubleDice()
+	}
+	expr.Modifier += modifier
+	rolled, err := dice.Roll(expr)
+	if err != nil {
+		return DamageRoll{}, err
+	}
+
+	roll.Expression = expr.String()
+	roll.Rolls = rolled.Rolls
+	roll.Total = max(1, rolled.Total)
+	return roll, nil
+}
+
+func formatDamage(diceText string, modifier int) string {
+	switch {
+	case modifier > 0:
+		return fmt.Sprintf("%s+%d", diceText, modifier)
+	case modifier < 0:
+		return fmt.Sprintf("%s%d", diceText, modifier)
+	}
+	return diceText
+}
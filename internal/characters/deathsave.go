@@ -0,0 +1,213 @@
+package characters
+
+import (
+	"errors"
+	"fmt"
+
+	"dice-service/internal/dice"
+)
+
+// Состояния персонажа, связанные с хитами.
+const (
+	StateConscious   = "conscious"   // в сознании, хиты больше 0
+	StateUnconscious = "unconscious" // 0 хитов, персонаж совершает спасброски от смерти
+	StateStable      = "stable"      // 0 хитов, но персонаж стабилизирован
+	StateDead        = "dead"        // персонаж мёртв
+)
+
+// Исходы спасброска от смерти.
+const (
+	DeathSaveSuccess         = "success"
+	DeathSaveFailure         = "failure"
+	DeathSaveCriticalSuccess = "critical_success" // натуральная 20: персонаж приходит в себя с 1 хитом
+	DeathSaveCriticalFailure = "critical_failure" // натуральная 1: два провала
+)
+
+var (
+	ErrNotDying = errors.New("character is not dying")
+	ErrDead     = errors.New("character is dead")
+)
+
+// DeathSaves счётчики спасбросков от смерти.
+type DeathSaves struct {
+	Successes int `json:"successes"`
+	Failures  int `json:"failures"`
+}
+
+// DeathSaveResult результат одного спасброска от смерти.
+type DeathSaveResult struct {
+	Roll    int    `json:"roll"`
+	Outcome string `json:"outcome"`
+	State   string `json:"state"`
+}
+
+// DamageResult результат получения урона.
+type DamageResult struct {
+	Amount            int    `json:"amount"`
+	AbsorbedByTemp    int    `json:"absorbedByTemp"`
+	DeathSaveFailures int    `json:"deathSaveFailures"` // провалы, добавленные уроном при 0 хитов
+	InstantDeath      bool   `json:"instantDeath"`
+	State             string `json:"state"`
+}
+
+func isValidState(state string) bool {
+	switch state {
+	case "", StateConscious, StateUnconscious, StateStable, StateDead:
+		return true
+	}
+	return false
+}
+
+// currentState возвращает явное состояние персонажа, вычисляя его по хитам
+// для листов, сохранённых до появления поля state.
+func currentState(sheet CharacterSheet) string {
+	if sheet.State != "" {
+		return sheet.State
+	}
+	if sheet.CurrentHitPoints <= 0 {
+		return StateUnconscious
+	}
+	return StateConscious
+}
+
+// RollDeathSave бросает d20 спасбросок от смерти для персонажа с 0 хитов:
+// 10 и выше - успех, ниже - провал, натуральная 20 возвращает 1 хит,
+// натуральная 1 считается двумя провалами. Три успеха стабилизируют персонажа,
+// три провала - смерть.
+func RollDeathSave(sheet CharacterSheet) (CharacterSheet, DeathSaveResult, error) {
+	switch currentState(sheet) {
+	case StateDead:
+		return CharacterSheet{}, DeathSaveResult{}, ErrDead
+	case StateUnconscious:
+	default:
+		return CharacterSheet{}, DeathSaveResult{}, ErrNotDying
+	}
+
+	expr, err := dice.ParseExpression("1d20")
+	if err != nil {
+		return CharacterSheet{}, DeathSaveResult{}, err
+	}
+	rolled, err := dice.Roll(expr)
+	if err != nil {
+		return CharacterSheet{}, DeathSaveResult{}, fmt.Errorf("failed to roll death save: %w", err)
+	}
+	sheet, result := applyDeathSave(sheet, rolled.Total)
+	return sheet, result, nil
+}
+
+// applyDeathSave применяет к листу выпавшее на d20 значение спасброска.
+func applyDeathSave(sheet CharacterSheet, roll int) (CharacterSheet, DeathSaveResult) {
+	result := DeathSaveResult{Roll: roll}
+	switch {
+	case roll == 20:
+		result.Outcome = DeathSaveCriticalSuccess
+		sheet.CurrentHitPoints = 1
+		sheet.DeathSaves = DeathSaves{}
+		sheet.State = StateConscious
+	case roll == 1:
+		result.Outcome = DeathSaveCriticalFailure
+		sheet = addDeathSaveFailures(sheet, 2)
+	case roll >= 10:
+		result.Outcome = DeathSaveSuccess
+		sheet.DeathSaves.Successes++
+		sheet.State = StateUnconscious
+		if sheet.DeathSaves.Successes >= 3 {
+			sheet.DeathSaves = DeathSaves{}
+			sheet.State = StateStable
+		}
+	default:
+		result.Outcome = DeathSaveFailure
+		sheet = addDeathSaveFailures(sheet, 1)
+	}
+
+	result.State = sheet.State
+	return sheet, result
+}
+
+func addDeathSaveFailures(sheet CharacterSheet, n int) CharacterSheet {
+	sheet.DeathSaves.Failures += n
+	sheet.State = StateUnconscious
+	if sheet.DeathSaves.Failures >= 3 {
+		sheet.DeathSaves.Failures = 3
+		sheet.State = StateDead
+	}
+	return sheet
+}
+
+// ApplyDamage наносит урон персонажу: сначала расходуются временные хиты,
+// затем обычные. Урон при 0 хитов засчитывается как провал спасброска от смерти
+// (критическое попадание - как два провала), а урон, оставшийся после падения до 0
+// и не меньший максимума хитов, убивает персонажа мгновенно.
+func ApplyDamage(sheet CharacterSheet, amount int, critical bool) (CharacterSheet, DamageResult, error) {
+	if amount < 0 {
+		return CharacterSheet{}, DamageResult{}, errors.New("damage amount must not be negative")
+	}
+	state := currentState(sheet)
+	if state == StateDead {
+		return CharacterSheet{}, DamageResult{}, ErrDead
+	}
+
+	result := DamageResult{Amount: amount}
+	remaining := amount
+	if sheet.TemporaryHitPoints > 0 {
+		absorbed := min(sheet.TemporaryHitPoints, remaining)
+		sheet.TemporaryHitPoints -= absorbed
+		remaining -= absorbed
+		result.AbsorbedByTemp = absorbed
+	}
+
+	if remaining > 0 {
+		if sheet.CurrentHitPoints <= 0 {
+			// Персонаж уже без сознания
+			if remaining >= sheet.MaxHitPoints {
+				sheet.DeathSaves.Failures = 3
+				sheet.State = StateDead
+				result.InstantDeath = true
+			} else {
+				failures := 1
+				if critical {
+					failures = 2
+				}
+				result.DeathSaveFailures = failures
+				sheet = addDeathSaveFailures(sheet, failures)
+			}
+		} else if remaining >= sheet.CurrentHitPoints {
+			overflow := remaining - sheet.CurrentHitPoints
+			sheet.CurrentHitPoints = 0
+			sheet.DeathSaves = DeathSaves{}
+			if overflow >= sheet.MaxHitPoints {
+				sheet.DeathSaves.Failures = 3
+				sheet.State = StateDead
+				result.InstantDeath = true
+			} else {
+				sheet.State = StateUnconscious
+			}
+		} else {
+			sheet.CurrentHitPoints -= remaining
+			sheet.State = StateConscious
+		}
+	} else if sheet.State == "" {
+		sheet.State = state
+	}
+
+	result.State = sheet.State
+	return sheet, result, nil
+}
+
+// ApplyHealing восстанавливает хиты (не выше максимума с учётом истощения). Персонаж с 0 хитов,
+// получивший лечение, приходит в сознание, а счётчики спасбросков сбрасываются.
+func ApplyHealing(sheet CharacterSheet, amount int) (CharacterSheet, error) {
+	if amount < 0 {
+		return CharacterSheet{}, errors.New("healing amount must not be negative")
+	}
+	if currentState(sheet) == StateDead {
+		return CharacterSheet{}, ErrDead
+	}
+
+	sheet.CurrentHitPoints = min(effectiveMaxHitPoints(sheet), max(0, sheet.CurrentHitPoints)+amount)
+	if sheet.CurrentHitPoints > 0 {
+		sheet.DeathSaves = DeathSaves{}
+		sheet.State = StateConscious
+	}
+	return sheet, nil
+}
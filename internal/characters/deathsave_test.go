@@ -0,0 +1,112 @@
+package characters
+
+import (
+	"errors"
+	"testing"
+
+	"dice-service/internal/conditions"
+)
+
+func TestApplyDeathSave(t *testing.T) {
+	t.Parallel()
+
+	dying := CharacterSheet{MaxHitPoints: 20, State: StateUnconscious, DeathSaves: DeathSaves{Successes: 1, Failures: 1}}
+
+	tests := []struct {
+		name     string
+		sheet    CharacterSheet
+		roll     int
+		outcome  string
+		state    string
+		hp       int
+		failures int
+	}{
+		{name: "natural 20 regains 1 hit point", sheet: dying, roll: 20, outcome: DeathSaveCriticalSuccess, state: StateConscious, hp: 1},
+		{name: "natural 1 counts as two failures", sheet: dying, roll: 1, outcome: DeathSaveCriticalFailure, state: StateDead, failures: 3},
+		{name: "success", sheet: dying, roll: 10, outcome: DeathSaveSuccess, state: StateUnconscious, failures: 1},
+		{name: "failure", sheet: dying, roll: 9, outcome: DeathSaveFailure, state: StateUnconscious, failures: 2},
+		{
+			name:    "third success stabilizes",
+			sheet:   CharacterSheet{MaxHitPoints: 20, State: StateUnconscious, DeathSaves: DeathSaves{Successes: 2, Failures: 2}},
+			roll:    15,
+			outcome: DeathSaveSuccess,
+			state:   StateStable,
+		},
+		{
+			name:     "third failure kills",
+			sheet:    CharacterSheet{MaxHitPoints: 20, State: StateUnconscious, DeathSaves: DeathSaves{Successes: 2, Failures: 2}},
+			roll:     5,
+			outcome:  DeathSaveFailure,
+			state:    StateDead,
+			failures: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sheet, result := applyDeathSave(tt.sheet, tt.roll)
+			if result.Roll != tt.roll || result.Outcome != tt.outcome || result.State != tt.state || sheet.State != tt.state {
+				t.Fatalf("unexpected result %+v, state %s", result, sheet.State)
+			}
+			if sheet.CurrentHitPoints != tt.hp || sheet.DeathSaves.Failures != tt.failures {
+				t.Fatalf("expected hp %d and %d failures, got %d and %+v", tt.hp, tt.failures, sheet.CurrentHitPoints, sheet.DeathSaves)
+			}
+		})
+	}
+}
+
+func TestRollDeathSaveRequiresDyingCharacter(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := RollDeathSave(CharacterSheet{CurrentHitPoints: 5, MaxHitPoints: 20}); !errors.Is(err, ErrNotDying) {
+		t.Fatalf("expected ErrNotDying, got %v", err)
+	}
+	if _, _, err := RollDeathSave(CharacterSheet{MaxHitPoints: 20, State: StateDead}); !errors.Is(err, ErrDead) {
+		t.Fatalf("expected ErrDead, got %v", err)
+	}
+	sheet, result, err := RollDeathSave(CharacterSheet{MaxHitPoints: 20, State: StateUnconscious})
+	if err != nil || result.Roll < 1 || result.Roll > 20 || sheet.State != result.State {
+		t.Fatalf("unexpected death save %+v: %v", result, err)
+	}
+}
+
+func TestApplyDamageAtZeroHitPoints(t *testing.T) {
+	t.Parallel()
+
+	dying := CharacterSheet{MaxHitPoints: 20, State: StateUnconscious, DeathSaves: DeathSaves{Successes: 2}}
+
+	sheet, result, err := ApplyDamage(dying, 5, false)
+	if err != nil || result.DeathSaveFailures != 1 || sheet.DeathSaves.Failures != 1 || sheet.State != StateUnconscious {
+		t.Fatalf("damage at 0 hp should add a failure: %+v, %+v, %v", result, sheet.DeathSaves, err)
+	}
+	sheet, result, err = ApplyDamage(sheet, 5, true)
+	if err != nil || result.DeathSaveFailures != 2 || sheet.State != StateDead {
+		t.Fatalf("critical damage at 0 hp should add two failures: %+v, %+v, %v", result, sheet.DeathSaves, err)
+	}
+	if _, _, err := ApplyDamage(sheet, 1, false); !errors.Is(err, ErrDead) {
+		t.Fatalf("expected ErrDead, got %v", err)
+	}
+
+	sheet, result, err = ApplyDamage(dying, 20, false)
+	if err != nil || !result.InstantDeath || sheet.State != StateDead {
+		t.Fatalf("damage of at least max hp at 0 hp should kill: %+v, %v", result, err)
+	}
+}
+
+func TestExhaustionClampsHitPoints(t *testing.T) {
+	t.Parallel()
+
+	sheet := CharacterSheet{MaxHitPoints: 30, CurrentHitPoints: 25, State: StateConscious,
+		Conditions: []conditions.Condition{{Name: conditions.Exhaustion, Level: 4}}}
+	if got := Normalize(sheet).CurrentHitPoints; got != 15 {
+		t.Fatalf("expected hit points clamped to 15, got %d", got)
+	}
+	sheet.CurrentHitPoints = 10
+	healed, err := ApplyHealing(sheet, 20)
+	if err != nil || healed.CurrentHitPoints != 15 {
+		t.Fatalf("healing should stop at the halved maximum: %d, %v", healed.CurrentHitPoints, err)
+	}
+}
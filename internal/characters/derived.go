@@ -0,0 +1,42 @@
+package characters
+
+import "dice-service/internal/conditions"
+
+// DerivedStats итоговые параметры персонажа с учётом наложенных состояний.
+type DerivedStats struct {
+	Speed        int                `json:"speed"`
+	ArmorClass   int                `json:"armorClass"`
+	MaxHitPoints int                `json:"maxHitPoints"`
+	Encumbrance  Encumbrance        `json:"encumbrance"`
+	Effects      conditions.Effects `json:"effects"`
+}
+
+// Derive вычисляет итоговые параметры персонажа с учётом нагрузки и состояний.
+// Персонаж с 0 хитов считается находящимся в состоянии "без сознания",
+// даже если оно не наложено явно.
+func Derive(sheet CharacterSheet) DerivedStats {
+	active := sheet.Conditions
+	switch currentState(sheet) {
+	case StateUnconscious, StateStable, StateDead:
+		if !conditions.Has(active, conditions.Unconscious) {
+			active = append(append([]conditions.Condition{}, active...), conditions.Condition{Name: conditions.Unconscious})
+		}
+	}
+
+	effects := conditions.Summarize(active)
+	encumbrance := ComputeEncumbrance(sheet)
+
+	// Тяжёлый доспех без достаточной Силы снижает скорость на 10 футов
+	speed := encumbrance.applySpeed(sheet.Speed)
+	if armor, _ := equippedArmor(sheet); armor != nil && sheet.AbilityScores.Strength < armor.Armor.StrengthRequirement {
+		speed = max(0, speed-10)
+	}
+
+	return DerivedStats{
+		Speed:        effects.Speed(speed),
+		ArmorClass:   sheet.ArmorClass,
+		MaxHitPoints: effects.MaxHitPoints(sheet.MaxHitPoints),
+		Encumbrance:  encumbrance,
+		Effects:      effects,
+	}
+}
@@ -0,0 +1,62 @@
+package characters
+
+import (
+	"errors"
+	"fmt"
+)
+
+// xpThresholds опыт, необходимый для достижения уровня (индекс - уровень, SRD).
+var xpThresholds = [MaxLevel + 1]int{
+	0, 0, 300, 900, 2700, 6500, 14000, 23000, 34000, 48000, 64000,
+	85000, 100000, 120000, 140000, 165000, 195000, 225000, 265000, 305000, 355000,
+}
+
+// XPForLevel возвращает порог опыта для уровня; для уровней выше 20 - порог 20-го.
+func XPForLevel(level int) int {
+	if level < 1 {
+		return 0
+	}
+	return xpThresholds[min(level, MaxLevel)]
+}
+
+// LevelForXP возвращает уровень, которому соответствует количество опыта.
+func LevelForXP(xp int) int {
+	level := 1
+	for level < MaxLevel && xp >= xpThresholds[level+1] {
+		level++
+	}
+	return level
+}
+
+// readyToLevel сообщает, набрал ли персонаж опыт для следующего уровня.
+func readyToLevel(sheet CharacterSheet) bool {
+	return sheet.Level < MaxLevel && sheet.Experience >= XPForLevel(sheet.Level+1)
+}
+
+// ErrAutoLevel автоматическое повышение уровня при начислении опыта не
+// удалось; опыт и уже полученные уровни при этом начислены.
+var ErrAutoLevel = errors.New("automatic level up failed")
+
+// AwardXP начисляет персонажу опыт. При autoLevel персонаж сразу повышает
+// уровни в классе class (пусто - основной класс), пока хватает опыта.
+// Возвращает лист и число полученных уровней. Если очередное повышение не
+// удалось, лист с начисленным опытом возвращается вместе с ошибкой,
+// оборачивающей ErrAutoLevel.
+func AwardXP(sheet CharacterSheet, amount int, autoLevel bool, class string) (CharacterSheet, int, error) {
+	if amount < 1 {
+		return CharacterSheet{}, 0, errors.New("amount must be positive")
+	}
+	sheet.Experience += amount
+	sheet.ReadyToLevel = readyToLevel(sheet)
+
+	gained := 0
+	for autoLevel && sheet.ReadyToLevel {
+		leveled, err := LevelUp(sheet, class)
+		if err != nil {
+			return sheet, gained, fmt.Errorf("%w: %v", ErrAutoLevel, err)
+		}
+		sheet = leveled
+		gained++
+	}
+	return sheet, gained, nil
+}
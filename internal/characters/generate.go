@@ -114,15 +114,18 @@ func GenerateCharacterSheet(name, class, race, background, alignment string, lev
 		Alignment:          alignment,
 		AbilityScores:      abilities,
 		ProficiencyBonus:   prof,
-		ArmorClass:         10 + dexMod,
 		Speed:              30,
 		Initiative:         dexMod,
 		MaxHitPoints:       maxHP,
 		CurrentHitPoints:   maxHP,
 		TemporaryHitPoints: 0,
+		State:              StateConscious,
 		Skills:             allSkills,
 	}
 
+	// КД без доспехов с учётом защиты без доспехов варвара и монаха
+	sheet.ArmorClass = ComputeArmorClass(sheet)
+
 	if err := sheet.Validate(); err != nil {
 		return CharacterSheet{}, err
 	}
@@ -371,12 +374,35 @@ func mergeSkills(classSkills []string, userSkills []string) []string {
 	return result
 }
 
-// LevelUp повышает уровень персонажа на 1 и пересчитывает все зависимые параметры
-func LevelUp(sheet CharacterSheet) (CharacterSheet, error) {
-	if sheet.Level >= 20 {
+// LevelUp повышает уровень персонажа на 1 в указанном классе и пересчитывает все зависимые параметры.
+// Пустой class означает основной класс персонажа. Взятие нового класса (мультикласс)
+// требует выполнения требований к характеристикам и для нового, и для всех текущих классов.
+func LevelUp(sheet CharacterSheet, class string) (CharacterSheet, error) {
+	sheet = normalizeClasses(sheet)
+	if sheet.Level >= MaxLevel {
 		return CharacterSheet{}, fmt.Errorf("character is already at maximum level (20)")
 	}
 
+	if strings.TrimSpace(class) == "" {
+		class = sheet.Class
+	}
+	idx := classLevelIndex(sheet.Classes, class)
+	if idx < 0 {
+		// Новый класс: проверяем требования мультикласса
+		for _, cl := range sheet.Classes {
+			if err := meetsMulticlassPrerequisites(sheet.AbilityScores, cl.Class); err != nil {
+				return CharacterSheet{}, err
+			}
+		}
+		if err := meetsMulticlassPrerequisites(sheet.AbilityScores, class); err != nil {
+			return CharacterSheet{}, err
+		}
+		sheet.Classes = append(sheet.Classes, ClassLevel{Class: strings.TrimSpace(class), Level: 0})
+		idx = len(sheet.Classes) - 1
+	}
+	sheet.Classes[idx].Level++
+	leveledClass := sheet.Classes[idx]
+
 	newLevel := sheet.Level + 1
 	
 	// Пересчитываем бонус мастерства
@@ -387,8 +413,8 @@ func LevelUp(sheet CharacterSheet) (CharacterSheet, error) {
 	// Упрощённая модель: среднее значение Hit Die + модификатор CON
 	conMod := abilityModifier(sheet.AbilityScores.Constitution)
 	
-	// Определяем Hit Die для класса
-	hitDie := getClassHitDie(sheet.Class)
+	// Определяем Hit Die для класса, в котором получен уровень
+	hitDie := getClassHitDie(leveledClass.Class)
 	
 	// Добавляем новые хиты (среднее значение Hit Die + модификатор CON, минимум 1)
 	hpGain := max(1, (hitDie/2+1)+conMod) // среднее значение (например, для d8 это 5)
@@ -400,11 +426,13 @@ func LevelUp(sheet CharacterSheet) (CharacterSheet, error) {
 		newCurrentHP = newMaxHP
 	}
 	
-	// Получаем навыки для нового уровня
-	newClassSkills := getClassSkills(sheet.Class, newLevel)
-	
-	// Объединяем старые навыки с новыми (если появились новые)
-	allSkills := mergeSkills(newClassSkills, sheet.Skills)
+	// Навыки основного класса зависят от его уровня
+	allSkills := sheet.Skills
+	if idx == 0 {
+		newClassSkills := getClassSkills(leveledClass.Class, leveledClass.Level)
+		// Объединяем старые навыки с новыми (если появились новые)
+		allSkills = mergeSkills(newClassSkills, sheet.Skills)
+	}
 	
 	// Обновляем лист персонажа
 	sheet.Level = newLevel
@@ -413,6 +441,8 @@ func LevelUp(sheet CharacterSheet) (CharacterSheet, error) {
 	sheet.CurrentHitPoints = newCurrentHP
 	sheet.Skills = allSkills
 	
+	sheet = normalizeClasses(sheet)
+	sheet.ReadyToLevel = readyToLevel(sheet)
 	return sheet, nil
 }
 
@@ -0,0 +1,123 @@
+package characters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Метки ревизий, которые ставит само хранилище.
+const (
+	LabelCreated = "created"
+	LabelUpdate  = "update"
+)
+
+// RevertLabel метка ревизии, созданной откатом к ревизии rev.
+func RevertLabel(rev int) string {
+	return fmt.Sprintf("revert to %d", rev)
+}
+
+// FieldChange изменение одного поля листа. Path - путь через точку
+// (например, "abilityScores.strength"), значения - в JSON.
+type FieldChange struct {
+	Path string          `json:"path"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// RevisionSummary запись в истории изменений персонажа без самого листа.
+type RevisionSummary struct {
+	Rev       int           `json:"rev"`
+	Timestamp time.Time     `json:"timestamp"`
+	Label     string        `json:"label"`
+	Diff      []FieldChange `json:"diff"`
+}
+
+// Revision сохранённое состояние листа персонажа после изменения.
+type Revision struct {
+	RevisionSummary
+	Sheet CharacterSheet `json:"sheet"`
+}
+
+// newRevision создаёт ревизию с изменениями относительно предыдущего состояния.
+func newRevision(rev int, label string, before, after CharacterSheet) (Revision, error) {
+	diff, err := Diff(before, after)
+	if err != nil {
+		return Revision{}, err
+	}
+	return Revision{
+		RevisionSummary: RevisionSummary{
+			Rev:       rev,
+			Timestamp: time.Now().UTC(),
+			Label:     label,
+			Diff:      diff,
+		},
+		Sheet: after,
+	}, nil
+}
+
+// Diff сравнивает два листа персонажа по JSON-представлению. Вложенные объекты
+// сравниваются по полям, списки (предметы, состояния, навыки) - целиком.
+// Номер версии в сравнении не участвует.
+func Diff(before, after CharacterSheet) ([]FieldChange, error) {
+	before.Version, after.Version = 0, 0
+	oldFields, err := toJSONObject(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := toJSONObject(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []FieldChange{}
+	diffObjects("", oldFields, newFields, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func toJSONObject(v any) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal character: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal character: %w", err)
+	}
+	return fields, nil
+}
+
+func diffObjects(prefix string, before, after map[string]json.RawMessage, changes *[]FieldChange) {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		oldValue, newValue := before[key], after[key]
+		if bytes.Equal(oldValue, newValue) {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		var oldObject, newObject map[string]json.RawMessage
+		if json.Unmarshal(oldValue, &oldObject) == nil && json.Unmarshal(newValue, &newObject) == nil &&
+			oldObject != nil && newObject != nil {
+			diffObjects(path, oldObject, newObject, changes)
+			continue
+		}
+		*changes = append(*changes, FieldChange{Path: path, Old: oldValue, New: newValue})
+	}
+}
@@ -0,0 +1,322 @@
+package characters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dice-service/internal/items"
+)
+
+// Статусы нагрузки (вариантное правило SRD).
+const (
+	EncumbranceNone         = "unencumbered"
+	EncumbranceEncumbered   = "encumbered"         // вес больше 5 × СИЛ: скорость -10
+	EncumbranceHeavy        = "heavily_encumbered" // вес больше 10 × СИЛ: скорость -20
+	EncumbranceOverCapacity = "over_capacity"      // вес больше грузоподъёмности: скорость 5 фт.
+)
+
+// maxAttunedItems максимальное число предметов, настроенных на персонажа.
+const maxAttunedItems = 3
+
+// coinsPerPound монет в одном фунте веса.
+const coinsPerPound = 50
+
+// InventoryItem запись в инвентаре персонажа.
+type InventoryItem struct {
+	ID          string  `json:"id"`
+	ItemID      string  `json:"itemId,omitempty"` // ссылка на предмет из справочника
+	Name        string  `json:"name"`
+	Quantity    int     `json:"quantity"`
+	Weight      float64 `json:"weight"` // вес одной штуки в фунтах
+	Equipped    bool    `json:"equipped"`
+	Attuned     bool    `json:"attuned"`
+	MagicBonus  int     `json:"magicBonus,omitempty"`  // +1/+2/+3 к атаке и урону оружия или к КД доспеха
+	ContainerID string  `json:"containerId,omitempty"` // ID записи-контейнера (рюкзак, сундук)
+	Notes       string  `json:"notes,omitempty"`
+}
+
+// Inventory список предметов персонажа.
+type Inventory []InventoryItem
+
+// UnmarshalJSON принимает как структурированные записи, так и старый формат
+// листа персонажа, где предметы хранились списком строк.
+func (inv *Inventory) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*inv = nil
+		return nil
+	}
+
+	result := make(Inventory, 0, len(raw))
+	for _, element := range raw {
+		var name string
+		if err := json.Unmarshal(element, &name); err == nil {
+			result = append(result, InventoryItem{Name: name, Quantity: 1})
+			continue
+		}
+
+		// Отдельный тип без UnmarshalJSON, чтобы сохранить строгий разбор полей
+		type plainItem InventoryItem
+		var item plainItem
+		decoder := json.NewDecoder(strings.NewReader(string(element)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&item); err != nil {
+			return fmt.Errorf("invalid inventory item: %w", err)
+		}
+		result = append(result, InventoryItem(item))
+	}
+	*inv = result
+	return nil
+}
+
+// NormalizeInventory проставляет идентификаторы новым записям и количество 1
+// записям без количества (в том числе перенесённым из старого строкового формата).
+// Записи, ссылающиеся на справочник без названия, получают название и вес из справочника.
+func NormalizeInventory(inv Inventory) Inventory {
+	if inv == nil {
+		return nil
+	}
+	result := make(Inventory, len(inv))
+	for i, item := range inv {
+		item.Name = strings.TrimSpace(item.Name)
+		if item.Name == "" && item.ItemID != "" {
+			if ref, err := items.Get(item.ItemID); err == nil {
+				item.Name = ref.Name
+				item.Weight = ref.Weight
+			}
+		}
+		if item.ID == "" {
+			item.ID = generateID()
+		}
+		if item.Quantity == 0 {
+			item.Quantity = 1
+		}
+		result[i] = item
+	}
+	return result
+}
+
+// Validate проверяет инвентарь: количество и вес, ссылки на контейнеры без циклов
+// и лимит настройки на магические предметы.
+func (inv Inventory) Validate() error {
+	byID := make(map[string]InventoryItem, len(inv))
+	attuned := 0
+	for _, item := range inv {
+		if item.Name == "" && item.ItemID == "" {
+			return errors.New("inventory item must have a name or item reference")
+		}
+		if item.Quantity < 1 {
+			return fmt.Errorf("inventory item %q must have a positive quantity", item.Name)
+		}
+		if item.Weight < 0 {
+			return fmt.Errorf("inventory item %q must not have negative weight", item.Name)
+		}
+		if item.ItemID != "" {
+			if _, err := items.Get(item.ItemID); err != nil {
+				return fmt.Errorf("inventory item %q references unknown item %s", item.Name, item.ItemID)
+			}
+		}
+		if item.ID != "" {
+			if _, exists := byID[item.ID]; exists {
+				return fmt.Errorf("duplicate inventory item id %s", item.ID)
+			}
+			byID[item.ID] = item
+		}
+		if item.Attuned {
+			attuned++
+		}
+	}
+	if attuned > maxAttunedItems {
+		return fmt.Errorf("character can be attuned to at most %d items", maxAttunedItems)
+	}
+
+	for _, item := range inv {
+		seen := map[string]bool{item.ID: true}
+		parent := item.ContainerID
+		for parent != "" {
+			if seen[parent] {
+				return fmt.Errorf("inventory item %q is nested inside itself", item.Name)
+			}
+			container, ok := byID[parent]
+			if !ok {
+				return fmt.Errorf("inventory item %q references unknown container %s", item.Name, parent)
+			}
+			seen[parent] = true
+			parent = container.ContainerID
+		}
+	}
+	return nil
+}
+
+// Weight общий вес инвентаря в фунтах (включая содержимое контейнеров).
+func (inv Inventory) Weight() float64 {
+	total := 0.0
+	for _, item := range inv {
+		total += item.Weight * float64(item.Quantity)
+	}
+	return total
+}
+
+// Contents возвращает записи, лежащие непосредственно в контейнере.
+// Пустой containerID возвращает предметы верхнего уровня.
+func (inv Inventory) Contents(containerID string) Inventory {
+	var result Inventory
+	for _, item := range inv {
+		if item.ContainerID == containerID {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Equal сообщает, совпадают ли два инвентаря.
+func (inv Inventory) Equal(other Inventory) bool {
+	if len(inv) != len(other) {
+		return false
+	}
+	for i := range inv {
+		if inv[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Номиналы монет.
+const (
+	CoinCopper   = "cp"
+	CoinSilver   = "sp"
+	CoinElectrum = "ep"
+	CoinGold     = "gp"
+	CoinPlatinum = "pp"
+)
+
+// coinValues стоимость монет в медных.
+var coinValues = map[string]int{
+	CoinCopper:   1,
+	CoinSilver:   10,
+	CoinElectrum: 50,
+	CoinGold:     100,
+	CoinPlatinum: 1000,
+}
+
+// Purse кошелёк персонажа.
+type Purse struct {
+	CP int `json:"cp"`
+	SP int `json:"sp"`
+	EP int `json:"ep"`
+	GP int `json:"gp"`
+	PP int `json:"pp"`
+}
+
+func (p Purse) Validate() error {
+	if p.CP < 0 || p.SP < 0 || p.EP < 0 || p.GP < 0 || p.PP < 0 {
+		return errors.New("purse must not contain negative amounts")
+	}
+	return nil
+}
+
+func (p *Purse) coins(denomination string) (*int, error) {
+	switch strings.ToLower(strings.TrimSpace(denomination)) {
+	case CoinCopper:
+		return &p.CP, nil
+	case CoinSilver:
+		return &p.SP, nil
+	case CoinElectrum:
+		return &p.EP, nil
+	case CoinGold:
+		return &p.GP, nil
+	case CoinPlatinum:
+		return &p.PP, nil
+	}
+	return nil, fmt.Errorf("unknown coin denomination %q", denomination)
+}
+
+// TotalCopper стоимость содержимого кошелька в медных монетах.
+func (p Purse) TotalCopper() int {
+	return p.CP*coinValues[CoinCopper] + p.SP*coinValues[CoinSilver] + p.EP*coinValues[CoinElectrum] +
+		p.GP*coinValues[CoinGold] + p.PP*coinValues[CoinPlatinum]
+}
+
+// Weight вес монет в фунтах (50 монет весят 1 фунт).
+func (p Purse) Weight() float64 {
+	return float64(p.CP+p.SP+p.EP+p.GP+p.PP) / coinsPerPound
+}
+
+// Convert обменивает amount монет номинала from на монеты номинала to.
+// Остаток, который нельзя выразить целыми монетами to, возвращается монетами from.
+func (p Purse) Convert(from, to string, amount int) (Purse, error) {
+	if amount < 1 {
+		return Purse{}, errors.New("amount must be positive")
+	}
+	src, err := p.coins(from)
+	if err != nil {
+		return Purse{}, err
+	}
+	dst, err := p.coins(to)
+	if err != nil {
+		return Purse{}, err
+	}
+	if *src < amount {
+		return Purse{}, fmt.Errorf("not enough %s: have %d, need %d", from, *src, amount)
+	}
+
+	fromValue := coinValues[strings.ToLower(strings.TrimSpace(from))]
+	toValue := coinValues[strings.ToLower(strings.TrimSpace(to))]
+	copper := amount * fromValue
+	converted := copper / toValue
+	if converted == 0 {
+		return Purse{}, fmt.Errorf("%d %s is not enough for a single %s", amount, from, to)
+	}
+	leftover := (copper - converted*toValue) / fromValue
+
+	*src -= amount - leftover
+	*dst += converted
+	return p, nil
+}
+
+// Encumbrance нагрузка персонажа.
+type Encumbrance struct {
+	CarriedWeight    float64 `json:"carriedWeight"`
+	CarryingCapacity int     `json:"carryingCapacity"` // 15 × СИЛ
+	PushDragLift     int     `json:"pushDragLift"`     // 30 × СИЛ
+	Status           string  `json:"status"`
+	SpeedPenalty     int     `json:"speedPenalty"`
+}
+
+// ComputeEncumbrance считает переносимый вес (предметы и монеты) и нагрузку
+// по значению Силы.
+func ComputeEncumbrance(sheet CharacterSheet) Encumbrance {
+	str := sheet.AbilityScores.Strength
+	enc := Encumbrance{
+		CarriedWeight:    sheet.Items.Weight() + sheet.Purse.Weight(),
+		CarryingCapacity: str * 15,
+		PushDragLift:     str * 30,
+		Status:           EncumbranceNone,
+	}
+
+	switch {
+	case enc.CarriedWeight > float64(enc.CarryingCapacity):
+		enc.Status = EncumbranceOverCapacity
+	case enc.CarriedWeight > float64(str*10):
+		enc.Status = EncumbranceHeavy
+		enc.SpeedPenalty = 20
+	case enc.CarriedWeight > float64(str*5):
+		enc.Status = EncumbranceEncumbered
+		enc.SpeedPenalty = 10
+	}
+	return enc
+}
+
+// applySpeed применяет нагрузку к скорости.
+func (e Encumbrance) applySpeed(speed int) int {
+	if e.Status == EncumbranceOverCapacity {
+		return min(speed, 5)
+	}
+	return max(0, speed-e.SpeedPenalty)
+}
@@ -0,0 +1,74 @@
+package characters
+
+import "testing"
+
+func TestPurseConvert(t *testing.T) {
+	t.Parallel()
+
+	purse := Purse{EP: 3, GP: 1}
+
+	got, err := purse.Convert(CoinElectrum, CoinGold, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (Purse{EP: 1, GP: 2}); got != want {
+		t.Fatalf("unexpected purse %+v, want %+v", got, want)
+	}
+	if got.TotalCopper() != purse.TotalCopper() {
+		t.Fatalf("conversion changed purse value: %d -> %d", purse.TotalCopper(), got.TotalCopper())
+	}
+
+	if _, err := purse.Convert(CoinGold, CoinPlatinum, 1); err == nil {
+		t.Fatalf("expected error when amount is too small for target coin")
+	}
+	if _, err := purse.Convert(CoinGold, CoinCopper, 2); err == nil {
+		t.Fatalf("expected error when spending more coins than available")
+	}
+}
+
+func TestComputeEncumbrance(t *testing.T) {
+	t.Parallel()
+
+	sheet := CharacterSheet{
+		AbilityScores:    AbilityScores{Strength: 10},
+		Speed:            30,
+		CurrentHitPoints: 10,
+		Items: Inventory{
+			{ID: "pack", Name: "Рюкзак", Quantity: 1, Weight: 5},
+			{ID: "rope", Name: "Верёвка", Quantity: 1, Weight: 10, ContainerID: "pack"},
+			{ID: "ingot", Name: "Слиток", Quantity: 4, Weight: 10},
+		},
+		Purse: Purse{GP: 250},
+	}
+
+	enc := ComputeEncumbrance(sheet)
+	if enc.CarriedWeight != 60 {
+		t.Fatalf("carried weight = %v, want 60", enc.CarriedWeight)
+	}
+	if enc.CarryingCapacity != 150 {
+		t.Fatalf("carrying capacity = %d, want 150", enc.CarryingCapacity)
+	}
+	if enc.Status != EncumbranceEncumbered {
+		t.Fatalf("status = %q, want %q", enc.Status, EncumbranceEncumbered)
+	}
+	if speed := Derive(sheet).Speed; speed != 20 {
+		t.Fatalf("derived speed = %d, want 20", speed)
+	}
+}
+
+func TestInventoryValidateContainers(t *testing.T) {
+	t.Parallel()
+
+	cyclic := Inventory{
+		{ID: "a", Name: "Сундук", Quantity: 1, ContainerID: "b"},
+		{ID: "b", Name: "Мешок", Quantity: 1, ContainerID: "a"},
+	}
+	if err := cyclic.Validate(); err == nil {
+		t.Fatalf("expected error for cyclic containers")
+	}
+
+	missing := Inventory{{ID: "a", Name: "Свиток", Quantity: 1, ContainerID: "nope"}}
+	if err := missing.Validate(); err == nil {
+		t.Fatalf("expected error for unknown container")
+	}
+}
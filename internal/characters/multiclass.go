@@ -0,0 +1,253 @@
+package characters
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxLevel максимальный суммарный уровень персонажа.
+const MaxLevel = 20
+
+// ClassLevel уровни персонажа в одном классе.
+type ClassLevel struct {
+	Class    string `json:"class"`
+	Level    int    `json:"level"`
+	Subclass string `json:"subclass,omitempty"`
+}
+
+// PactMagic ячейки магии договора колдуна (восстанавливаются на коротком отдыхе).
+type PactMagic struct {
+	Slots     int `json:"slots"`
+	SlotLevel int `json:"slotLevel"`
+}
+
+// multiclassPrerequisites минимальные характеристики для мультикласса:
+// достаточно выполнить любой из вариантов, внутри варианта - все характеристики.
+var multiclassPrerequisites = map[string][][]string{
+	"barbarian": {{"strength"}},
+	"bard":      {{"charisma"}},
+	"cleric":    {{"wisdom"}},
+	"druid":     {{"wisdom"}},
+	"fighter":   {{"strength"}, {"dexterity"}},
+	"monk":      {{"dexterity", "wisdom"}},
+	"paladin":   {{"strength", "charisma"}},
+	"ranger":    {{"dexterity", "wisdom"}},
+	"rogue":     {{"dexterity"}},
+	"sorcerer":  {{"charisma"}},
+	"warlock":   {{"charisma"}},
+	"wizard":    {{"intelligence"}},
+	"artificer": {{"intelligence"}},
+}
+
+const multiclassMinimumScore = 13
+
+// Доля уровней класса, идущая в уровень заклинателя при мультиклассе.
+const (
+	casterNone = iota
+	casterFull
+	casterHalf
+	casterHalfRoundUp // изобретатель округляет половину уровня вверх
+)
+
+var classCasterProgression = map[string]int{
+	"bard":      casterFull,
+	"cleric":    casterFull,
+	"druid":     casterFull,
+	"sorcerer":  casterFull,
+	"wizard":    casterFull,
+	"paladin":   casterHalf,
+	"ranger":    casterHalf,
+	"artificer": casterHalfRoundUp,
+}
+
+// spellSlotsByCasterLevel ячейки заклинаний 1-9 уровней по уровню заклинателя (SRD).
+var spellSlotsByCasterLevel = [21][9]int{
+	{},
+	{2},
+	{3},
+	{4, 2},
+	{4, 3},
+	{4, 3, 2},
+	{4, 3, 3},
+	{4, 3, 3, 1},
+	{4, 3, 3, 2},
+	{4, 3, 3, 3, 1},
+	{4, 3, 3, 3, 2},
+	{4, 3, 3, 3, 2, 1},
+	{4, 3, 3, 3, 2, 1},
+	{4, 3, 3, 3, 2, 1, 1},
+	{4, 3, 3, 3, 2, 1, 1},
+	{4, 3, 3, 3, 2, 1, 1, 1},
+	{4, 3, 3, 3, 2, 1, 1, 1},
+	{4, 3, 3, 3, 2, 1, 1, 1, 1},
+	{4, 3, 3, 3, 3, 1, 1, 1, 1},
+	{4, 3, 3, 3, 3, 2, 1, 1, 1},
+	{4, 3, 3, 3, 3, 2, 2, 1, 1},
+}
+
+func abilityScore(scores AbilityScores, ability string) int {
+	switch ability {
+	case "strength":
+		return scores.Strength
+	case "dexterity":
+		return scores.Dexterity
+	case "constitution":
+		return scores.Constitution
+	case "intelligence":
+		return scores.Intelligence
+	case "wisdom":
+		return scores.Wisdom
+	case "charisma":
+		return scores.Charisma
+	}
+	return 0
+}
+
+// meetsMulticlassPrerequisites проверяет требования к характеристикам для класса.
+// Классы без известных требований (homebrew) разрешены всегда.
+func meetsMulticlassPrerequisites(scores AbilityScores, class string) error {
+	options, ok := multiclassPrerequisites[canonicalClass(class)]
+	if !ok {
+		return nil
+	}
+	for _, option := range options {
+		satisfied := true
+		for _, ability := range option {
+			if abilityScore(scores, ability) < multiclassMinimumScore {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return nil
+		}
+	}
+
+	alternatives := make([]string, 0, len(options))
+	for _, option := range options {
+		alternatives = append(alternatives, strings.Join(option, " and "))
+	}
+	return fmt.Errorf("multiclassing into or out of %s requires %s of at least %d",
+		class, strings.Join(alternatives, " or "), multiclassMinimumScore)
+}
+
+// normalizeClasses согласует список классов с полями class и level.
+// Для персонажа с одним классом (в том числе старых листов без списка классов)
+// главными считаются поля class и level; у мультиклассового персонажа
+// class - первый класс, а level - сумма уровней.
+func normalizeClasses(sheet CharacterSheet) CharacterSheet {
+	if len(sheet.Classes) <= 1 {
+		if sheet.Class == "" {
+			return sheet
+		}
+		entry := ClassLevel{Class: sheet.Class, Level: sheet.Level}
+		if len(sheet.Classes) == 1 && strings.EqualFold(sheet.Classes[0].Class, sheet.Class) {
+			entry.Subclass = sheet.Classes[0].Subclass
+		}
+		sheet.Classes = []ClassLevel{entry}
+	} else {
+		sheet.Classes = append([]ClassLevel(nil), sheet.Classes...)
+		sheet.Class = sheet.Classes[0].Class
+		sheet.Level = 0
+		for _, cl := range sheet.Classes {
+			sheet.Level += cl.Level
+		}
+	}
+
+	sheet.SpellSlots, sheet.PactMagic = computeSpellSlots(sheet.Classes)
+	return sheet
+}
+
+func validateClasses(classes []ClassLevel) error {
+	seen := make(map[string]bool, len(classes))
+	total := 0
+	for _, cl := range classes {
+		if strings.TrimSpace(cl.Class) == "" {
+			return errors.New("class name is required for every class level entry")
+		}
+		if cl.Level < 1 {
+			return fmt.Errorf("level in %s must be at least 1", cl.Class)
+		}
+		key := canonicalClass(cl.Class)
+		if seen[key] {
+			return fmt.Errorf("class %s is listed more than once", cl.Class)
+		}
+		seen[key] = true
+		total += cl.Level
+	}
+	if total > MaxLevel {
+		return fmt.Errorf("total level must not exceed %d", MaxLevel)
+	}
+	return nil
+}
+
+// computeSpellSlots считает ячейки заклинаний по правилам мультикласса:
+// уровни полных заклинателей идут целиком, паладина и следопыта - наполовину
+// (с округлением вниз), изобретателя - наполовину с округлением вверх.
+// Единственный класс с ячейками использует собственную таблицу: паладин и
+// следопыт получают ячейки со 2 уровня и округляют половину уровня вверх.
+// Магия договора колдуна считается отдельно.
+func computeSpellSlots(classes []ClassLevel) ([]int, PactMagic) {
+	casterLevel := 0
+	warlockLevel := 0
+	casters := 0
+	for _, cl := range classes {
+		if class := canonicalClass(cl.Class); class != "warlock" && classCasterProgression[class] != casterNone {
+			casters++
+		}
+	}
+	for _, cl := range classes {
+		class := canonicalClass(cl.Class)
+		if class == "warlock" {
+			warlockLevel = cl.Level
+			continue
+		}
+		switch classCasterProgression[class] {
+		case casterFull:
+			casterLevel += cl.Level
+		case casterHalf:
+			if casters > 1 {
+				casterLevel += cl.Level / 2
+			} else if cl.Level >= 2 {
+				casterLevel += (cl.Level + 1) / 2
+			}
+		case casterHalfRoundUp:
+			casterLevel += (cl.Level + 1) / 2
+		}
+	}
+	casterLevel = min(casterLevel, MaxLevel)
+
+	var slots []int
+	if casterLevel > 0 {
+		table := spellSlotsByCasterLevel[casterLevel]
+		slots = append([]int(nil), table[:]...)
+	}
+	return slots, pactMagicForLevel(warlockLevel)
+}
+
+func pactMagicForLevel(level int) PactMagic {
+	switch {
+	case level <= 0:
+		return PactMagic{}
+	case level == 1:
+		return PactMagic{Slots: 1, SlotLevel: 1}
+	case level <= 10:
+		return PactMagic{Slots: 2, SlotLevel: min(5, (level+1)/2)}
+	case level <= 16:
+		return PactMagic{Slots: 3, SlotLevel: 5}
+	default:
+		return PactMagic{Slots: 4, SlotLevel: 5}
+	}
+}
+
+// classLevelIndex возвращает индекс класса в списке или -1.
+func classLevelIndex(classes []ClassLevel, class string) int {
+	key := canonicalClass(class)
+	for i, cl := range classes {
+		if canonicalClass(cl.Class) == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,122 @@
+package characters
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeSpellSlots(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		classes []ClassLevel
+		slots   []int
+		pact    PactMagic
+	}{
+		{name: "non-caster", classes: []ClassLevel{{Class: "Fighter", Level: 5}}},
+		{
+			name:    "full casters add levels",
+			classes: []ClassLevel{{Class: "Wizard", Level: 3}, {Class: "Cleric", Level: 2}},
+			slots:   []int{4, 3, 2, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:    "half caster rounds down",
+			classes: []ClassLevel{{Class: "Paladin", Level: 3}, {Class: "Sorcerer", Level: 1}},
+			slots:   []int{3, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{name: "paladin 1 has no slots", classes: []ClassLevel{{Class: "Paladin", Level: 1}}},
+		{
+			name:    "single paladin uses own table at level 3",
+			classes: []ClassLevel{{Class: "Paladin", Level: 3}},
+			slots:   []int{3, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:    "single paladin uses own table at level 5",
+			classes: []ClassLevel{{Class: "Paladin", Level: 5}},
+			slots:   []int{4, 2, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:    "ranger with a non-caster class uses own table",
+			classes: []ClassLevel{{Class: "Ranger", Level: 5}, {Class: "Fighter", Level: 1}},
+			slots:   []int{4, 2, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:    "warlock keeps pact magic separately",
+			classes: []ClassLevel{{Class: "Fighter", Level: 2}, {Class: "колдун", Level: 3}},
+			pact:    PactMagic{Slots: 2, SlotLevel: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			slots, pact := computeSpellSlots(tt.classes)
+			if !reflect.DeepEqual(slots, tt.slots) {
+				t.Fatalf("expected slots %v, got %v", tt.slots, slots)
+			}
+			if pact != tt.pact {
+				t.Fatalf("expected pact magic %+v, got %+v", tt.pact, pact)
+			}
+		})
+	}
+}
+
+func TestLevelUpMulticlass(t *testing.T) {
+	t.Parallel()
+
+	sheet := CharacterSheet{
+		Name:             "Тест",
+		Class:            "Fighter",
+		Level:            3,
+		AbilityScores:    AbilityScores{Strength: 15, Constitution: 14, Intelligence: 12},
+		MaxHitPoints:     28,
+		CurrentHitPoints: 28,
+	}
+
+	if _, err := LevelUp(sheet, "Wizard"); err == nil {
+		t.Fatal("expected multiclass prerequisites error for wizard with intelligence 12")
+	}
+
+	sheet.AbilityScores.Intelligence = 13
+	leveled, err := LevelUp(sheet, "Wizard")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantClasses := []ClassLevel{{Class: "Fighter", Level: 3}, {Class: "Wizard", Level: 1}}
+	if !reflect.DeepEqual(leveled.Classes, wantClasses) {
+		t.Fatalf("expected classes %+v, got %+v", wantClasses, leveled.Classes)
+	}
+	if leveled.Class != "Fighter" || leveled.Level != 4 {
+		t.Fatalf("expected Fighter level 4, got %s level %d", leveled.Class, leveled.Level)
+	}
+	// Кость хитов волшебника d6: 4 + 2 (Телосложение)
+	if leveled.MaxHitPoints != 34 {
+		t.Fatalf("expected max HP 34, got %d", leveled.MaxHitPoints)
+	}
+	if want := []int{2, 0, 0, 0, 0, 0, 0, 0, 0}; !reflect.DeepEqual(leveled.SpellSlots, want) {
+		t.Fatalf("expected spell slots %v, got %v", want, leveled.SpellSlots)
+	}
+
+	primary, err := LevelUp(leveled, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if primary.Classes[0].Level != 4 || primary.Level != 5 {
+		t.Fatalf("expected primary class to level up, got %+v", primary.Classes)
+	}
+}
+
+func TestNormalizeLegacySingleClass(t *testing.T) {
+	t.Parallel()
+
+	sheet := Normalize(CharacterSheet{Name: "Тест", Class: "Жрец", Level: 5})
+	if want := []ClassLevel{{Class: "Жрец", Level: 5}}; !reflect.DeepEqual(sheet.Classes, want) {
+		t.Fatalf("expected classes %+v, got %+v", want, sheet.Classes)
+	}
+	if want := []int{4, 3, 2, 0, 0, 0, 0, 0, 0}; !reflect.DeepEqual(sheet.SpellSlots, want) {
+		t.Fatalf("expected spell slots %v, got %v", want, sheet.SpellSlots)
+	}
+}
@@ -0,0 +1,171 @@
+package characters
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Поля сортировки списка персонажей.
+const (
+	SortByName  = "name"
+	SortByClass = "class"
+	SortByRace  = "race"
+	SortByLevel = "level"
+)
+
+// sortColumns колонки таблицы characters для полей сортировки.
+var sortColumns = map[string]string{
+	SortByName:  "name",
+	SortByClass: "class",
+	SortByRace:  "race",
+	SortByLevel: "level",
+}
+
+// Query фильтры, сортировка и страница для списка персонажей.
+type Query struct {
+	Name     string // подстрока имени без учёта регистра
+	Class    string
+	Race     string
+	LevelMin int // 0 - без ограничения
+	LevelMax int // 0 - без ограничения
+	Sort     string
+	Desc     bool
+	Limit    int // 0 - все записи
+	Offset   int
+}
+
+// Page страница списка персонажей.
+type Page struct {
+	Items []CharacterSheet
+	Total int // число записей, подходящих под фильтры
+}
+
+func (q Query) Validate() error {
+	if q.Sort != "" {
+		if _, ok := sortColumns[q.Sort]; !ok {
+			return fmt.Errorf("unknown sort field %q", q.Sort)
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return errors.New("limit and offset must not be negative")
+	}
+	if q.LevelMin < 0 || q.LevelMax < 0 || (q.LevelMax > 0 && q.LevelMin > q.LevelMax) {
+		return errors.New("invalid level range")
+	}
+	return nil
+}
+
+func (q Query) matches(sheet CharacterSheet) bool {
+	if q.Name != "" && !strings.Contains(strings.ToLower(sheet.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.Class != "" && !strings.EqualFold(sheet.Class, q.Class) {
+		return false
+	}
+	if q.Race != "" && !strings.EqualFold(sheet.Race, q.Race) {
+		return false
+	}
+	if q.LevelMin > 0 && sheet.Level < q.LevelMin {
+		return false
+	}
+	if q.LevelMax > 0 && sheet.Level > q.LevelMax {
+		return false
+	}
+	return true
+}
+
+// apply фильтрует, сортирует и обрезает список в памяти. Без поля сортировки
+// персонажи упорядочиваются по идентификатору, как в PostgresStore.
+func (q Query) apply(list []CharacterSheet) Page {
+	filtered := make([]CharacterSheet, 0, len(list))
+	for _, sheet := range list {
+		if q.matches(sheet) {
+			filtered = append(filtered, sheet)
+		}
+	}
+
+	// Порядок как в PostgresStore: по полю сортировки, затем по id
+	compare := func(a, b CharacterSheet) int {
+		switch q.Sort {
+		case SortByName:
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case SortByClass:
+			return strings.Compare(strings.ToLower(a.Class), strings.ToLower(b.Class))
+		case SortByRace:
+			return strings.Compare(strings.ToLower(a.Race), strings.ToLower(b.Race))
+		case SortByLevel:
+			return a.Level - b.Level
+		}
+		return 0
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		c := compare(filtered[i], filtered[j])
+		if q.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return filtered[i].ID < filtered[j].ID
+	})
+
+	page := Page{Total: len(filtered)}
+	start := min(q.Offset, len(filtered))
+	end := len(filtered)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, end)
+	}
+	page.Items = filtered[start:end]
+	return page
+}
+
+// likeEscaper экранирует подстановочные символы LIKE в пользовательском вводе.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlWhere строит условие WHERE для фильтров запроса (параметры начиная с $1).
+func (q Query) sqlWhere() (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.Name != "" {
+		add("name ILIKE '%%' || $%d || '%%' ESCAPE '\\'", likeEscaper.Replace(q.Name))
+	}
+	if q.Class != "" {
+		add("LOWER(class) = LOWER($%d)", q.Class)
+	}
+	if q.Race != "" {
+		add("LOWER(race) = LOWER($%d)", q.Race)
+	}
+	if q.LevelMin > 0 {
+		add("level >= $%d", q.LevelMin)
+	}
+	if q.LevelMax > 0 {
+		add("level <= $%d", q.LevelMax)
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// sqlOrder строит ORDER BY; id в конце делает порядок страниц устойчивым.
+func (q Query) sqlOrder() string {
+	column, ok := sortColumns[q.Sort]
+	if !ok {
+		return "id"
+	}
+	if column != "level" {
+		column = "LOWER(" + column + ")"
+	}
+	if q.Desc {
+		return column + " DESC, id"
+	}
+	return column + ", id"
+}
@@ -1,8 +1,13 @@
 package characters
 
-import "errors"
+import (
+	"errors"
+
+	"dice-service/internal/conditions"
+)
 
 var ErrNotFound = errors.New("character not found")
+var ErrVersionConflict = errors.New("character was modified by another request")
 
 type AbilityScores struct {
 	Strength     int `json:"strength"`
@@ -14,23 +19,33 @@ type AbilityScores struct {
 }
 
 type CharacterSheet struct {
-	ID                 string        `json:"id"`
-	Name               string        `json:"name"`
-	Class              string        `json:"class"`
-	Race               string        `json:"race"`
-	Background         string        `json:"background"`
-	Level              int           `json:"level"`
-	Alignment          string        `json:"alignment"`
-	AbilityScores      AbilityScores `json:"abilityScores"`
-	ProficiencyBonus   int           `json:"proficiencyBonus"`
-	ArmorClass         int           `json:"armorClass"`
-	Speed              int           `json:"speed"`
-	Initiative         int           `json:"initiative"`
-	MaxHitPoints       int           `json:"maxHitPoints"`
-	CurrentHitPoints   int           `json:"currentHitPoints"`
-	TemporaryHitPoints int           `json:"temporaryHitPoints"`
-	Skills             []string      `json:"skills"`
-	Items              []string      `json:"items"`
+	ID                 string                 `json:"id"`
+	Version            int                    `json:"version"` // растёт при каждом сохранении; 0 в запросе - без проверки
+	Name               string                 `json:"name"`
+	Class              string                 `json:"class"` // основной (первый) класс
+	Race               string                 `json:"race"`
+	Background         string                 `json:"background"`
+	Level              int                    `json:"level"` // суммарный уровень по всем классам
+	Experience         int                    `json:"experience"`
+	ReadyToLevel       bool                   `json:"readyToLevel"` // опыта хватает для следующего уровня
+	Classes            []ClassLevel           `json:"classes"`
+	Alignment          string                 `json:"alignment"`
+	AbilityScores      AbilityScores          `json:"abilityScores"`
+	ProficiencyBonus   int                    `json:"proficiencyBonus"`
+	SpellSlots         []int                  `json:"spellSlots"` // максимум ячеек заклинаний 1-9 уровней
+	PactMagic          PactMagic              `json:"pactMagic"`
+	ArmorClass         int                    `json:"armorClass"`
+	Speed              int                    `json:"speed"`
+	Initiative         int                    `json:"initiative"`
+	MaxHitPoints       int                    `json:"maxHitPoints"`
+	CurrentHitPoints   int                    `json:"currentHitPoints"`
+	TemporaryHitPoints int                    `json:"temporaryHitPoints"`
+	DeathSaves         DeathSaves             `json:"deathSaves"`
+	State              string                 `json:"state,omitempty"` // conscious, unconscious, stable, dead
+	Conditions         []conditions.Condition `json:"conditions"`
+	Skills             []string               `json:"skills"`
+	Items              Inventory              `json:"items"`
+	Purse              Purse                  `json:"purse"`
 }
 
 func (c CharacterSheet) Validate() error {
@@ -43,5 +58,51 @@ func (c CharacterSheet) Validate() error {
 	if c.Level < 1 {
 		return errors.New("level must be at least 1")
 	}
+	if c.Level > MaxLevel {
+		return errors.New("level must not exceed 20")
+	}
+	if c.Experience < 0 {
+		return errors.New("experience must not be negative")
+	}
+	if err := validateClasses(c.Classes); err != nil {
+		return err
+	}
+	if !isValidState(c.State) {
+		return errors.New("state must be one of conscious, unconscious, stable, dead")
+	}
+	if c.DeathSaves.Successes < 0 || c.DeathSaves.Successes > 3 || c.DeathSaves.Failures < 0 || c.DeathSaves.Failures > 3 {
+		return errors.New("death saves must be between 0 and 3")
+	}
+	if err := c.Items.Validate(); err != nil {
+		return err
+	}
+	if err := c.Purse.Validate(); err != nil {
+		return err
+	}
+	for _, cond := range c.Conditions {
+		if err := cond.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// Normalize приводит лист к каноническому виду перед сохранением:
+// согласует список классов с полями class и level, пересчитывает ячейки
+// заклинаний, отметку о готовности к новому уровню, проставляет
+// идентификаторы записям инвентаря и ограничивает текущие хиты
+// максимумом, уменьшенным истощением.
+func Normalize(sheet CharacterSheet) CharacterSheet {
+	sheet = normalizeClasses(sheet)
+	sheet.ReadyToLevel = readyToLevel(sheet)
+	sheet.Items = NormalizeInventory(sheet.Items)
+	if conditions.Summarize(sheet.Conditions).MaxHitPointsHalved {
+		sheet.CurrentHitPoints = min(sheet.CurrentHitPoints, effectiveMaxHitPoints(sheet))
+	}
+	return sheet
+}
+
+// effectiveMaxHitPoints максимум хитов с учётом истощения.
+func effectiveMaxHitPoints(sheet CharacterSheet) int {
+	return conditions.Summarize(sheet.Conditions).MaxHitPoints(sheet.MaxHitPoints)
+}
@@ -11,23 +11,35 @@ type Store interface {
 	Create(sheet CharacterSheet) (CharacterSheet, error)
 	Get(id string) (CharacterSheet, error)
 	List() []CharacterSheet
+	// Find возвращает страницу списка с учётом фильтров и сортировки
+	Find(q Query) (Page, error)
+	// Update и UpdateLabeled сохраняют лист, только если его версия совпадает
+	// с sheet.Version (0 - без проверки), иначе возвращают ErrVersionConflict
 	Update(id string, sheet CharacterSheet) (CharacterSheet, error)
-	Delete(id string) error
+	// UpdateLabeled обновляет лист и записывает ревизию с указанной меткой
+	UpdateLabeled(id string, sheet CharacterSheet, label string) (CharacterSheet, error)
+	// Delete удаляет персонажа; version 0 - без проверки версии
+	Delete(id string, version int) error
+	History(id string) ([]RevisionSummary, error)
+	Revision(id string, rev int) (Revision, error)
 }
 
 type MemoryStore struct {
-	mu    sync.RWMutex
-	byID  map[string]CharacterSheet
-	order []string
+	mu      sync.RWMutex
+	byID    map[string]CharacterSheet
+	order   []string
+	history map[string][]Revision
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		byID: make(map[string]CharacterSheet),
+		byID:    make(map[string]CharacterSheet),
+		history: make(map[string][]Revision),
 	}
 }
 
 func (s *MemoryStore) Create(sheet CharacterSheet) (CharacterSheet, error) {
+	sheet = Normalize(sheet)
 	if err := sheet.Validate(); err != nil {
 		return CharacterSheet{}, err
 	}
@@ -41,9 +53,15 @@ func (s *MemoryStore) Create(sheet CharacterSheet) (CharacterSheet, error) {
 	if _, exists := s.byID[sheet.ID]; exists {
 		return CharacterSheet{}, fmt.Errorf("character with id %s already exists", sheet.ID)
 	}
+	sheet.Version = 1
 
+	created, err := newRevision(1, LabelCreated, sheet, sheet)
+	if err != nil {
+		return CharacterSheet{}, err
+	}
 	s.byID[sheet.ID] = sheet
 	s.order = append(s.order, sheet.ID)
+	s.history[sheet.ID] = []Revision{created}
 	return sheet, nil
 }
 
@@ -69,8 +87,22 @@ func (s *MemoryStore) List() []CharacterSheet {
 	return result
 }
 
+func (s *MemoryStore) Find(q Query) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	return q.apply(s.List()), nil
+}
+
 // Update replaces an existing character sheet by id.
 func (s *MemoryStore) Update(id string, sheet CharacterSheet) (CharacterSheet, error) {
+	return s.UpdateLabeled(id, sheet, LabelUpdate)
+}
+
+// UpdateLabeled replaces an existing character sheet and records a revision.
+// Updates that change nothing do not create a revision.
+func (s *MemoryStore) UpdateLabeled(id string, sheet CharacterSheet, label string) (CharacterSheet, error) {
+	sheet = Normalize(sheet)
 	if err := sheet.Validate(); err != nil {
 		return CharacterSheet{}, err
 	}
@@ -78,24 +110,42 @@ func (s *MemoryStore) Update(id string, sheet CharacterSheet) (CharacterSheet, e
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.byID[id]; !ok {
+	existing, ok := s.byID[id]
+	if !ok {
 		return CharacterSheet{}, ErrNotFound
 	}
+	if sheet.Version != 0 && sheet.Version != existing.Version {
+		return CharacterSheet{}, ErrVersionConflict
+	}
 
 	sheet.ID = id
+	sheet.Version = existing.Version + 1
+	revisions := s.history[id]
+	rev, err := newRevision(len(revisions)+1, label, existing, sheet)
+	if err != nil {
+		return CharacterSheet{}, err
+	}
+	if len(rev.Diff) > 0 {
+		s.history[id] = append(revisions, rev)
+	}
 	s.byID[id] = sheet
 	return sheet, nil
 }
 
-func (s *MemoryStore) Delete(id string) error {
+func (s *MemoryStore) Delete(id string, version int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.byID[id]; !ok {
+	existing, ok := s.byID[id]
+	if !ok {
 		return ErrNotFound
 	}
+	if version != 0 && version != existing.Version {
+		return ErrVersionConflict
+	}
 
 	delete(s.byID, id)
+	delete(s.history, id)
 	// Удаляем из порядка
 	for i, v := range s.order {
 		if v == id {
@@ -106,6 +156,37 @@ func (s *MemoryStore) Delete(id string) error {
 	return nil
 }
 
+// History возвращает историю изменений персонажа, от старых ревизий к новым.
+func (s *MemoryStore) History(id string) ([]RevisionSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.byID[id]; !ok {
+		return nil, ErrNotFound
+	}
+	revisions := s.history[id]
+	result := make([]RevisionSummary, 0, len(revisions))
+	for _, rev := range revisions {
+		result = append(result, rev.RevisionSummary)
+	}
+	return result, nil
+}
+
+// Revision возвращает сохранённую ревизию листа персонажа.
+func (s *MemoryStore) Revision(id string, rev int) (Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.byID[id]; !ok {
+		return Revision{}, ErrNotFound
+	}
+	revisions := s.history[id]
+	if rev < 1 || rev > len(revisions) {
+		return Revision{}, ErrRevisionNotFound
+	}
+	return revisions[rev-1], nil
+}
+
 func generateID() string {
 	var buf [8]byte
 	if _, err := rand.Read(buf[:]); err != nil {
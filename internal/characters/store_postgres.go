@@ -66,10 +66,55 @@ END $$;`
 		panic(fmt.Errorf("failed to migrate characters table: %w", err))
 	}
 
+	// Миграция: предметы из старого формата (список строк) превращаются в записи инвентаря
+	const migrateItems = `
+UPDATE characters SET data = jsonb_set(data, '{items}', (
+	SELECT COALESCE(jsonb_agg(
+		CASE WHEN jsonb_typeof(elem) = 'string' THEN jsonb_build_object(
+			'id', substr(md5(random()::text || (elem #>> '{}')), 1, 16),
+			'name', elem #>> '{}',
+			'quantity', 1,
+			'weight', 0,
+			'equipped', false,
+			'attuned', false
+		) ELSE elem END
+	), '[]'::jsonb)
+	FROM jsonb_array_elements(data->'items') AS elem
+))
+WHERE jsonb_typeof(data->'items') = 'array'
+	AND EXISTS (SELECT 1 FROM jsonb_array_elements(data->'items') AS e WHERE jsonb_typeof(e) = 'string');`
+
+	if _, err := db.Exec(migrateItems); err != nil {
+		panic(fmt.Errorf("failed to migrate character items: %w", err))
+	}
+
+	// Миграция: номер версии для оптимистичной блокировки
+	const migrateVersion = `ALTER TABLE characters ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`
+	if _, err := db.Exec(migrateVersion); err != nil {
+		panic(fmt.Errorf("failed to add characters version column: %w", err))
+	}
+
+	// История изменений: полный лист и список изменённых полей для каждой ревизии
+	const createRevisions = `
+CREATE TABLE IF NOT EXISTS character_revisions (
+	character_id TEXT NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+	rev          INTEGER NOT NULL,
+	created_at   TIMESTAMP NOT NULL,
+	label        TEXT NOT NULL,
+	diff         JSONB NOT NULL,
+	data         JSONB NOT NULL,
+	PRIMARY KEY (character_id, rev)
+);`
+
+	if _, err := db.Exec(createRevisions); err != nil {
+		panic(fmt.Errorf("failed to create character_revisions table: %w", err))
+	}
+
 	return &PostgresStore{db: db}
 }
 
 func (s *PostgresStore) Create(sheet CharacterSheet) (CharacterSheet, error) {
+	sheet = Normalize(sheet)
 	if err := sheet.Validate(); err != nil {
 		return CharacterSheet{}, err
 	}
@@ -77,25 +122,44 @@ func (s *PostgresStore) Create(sheet CharacterSheet) (CharacterSheet, error) {
 	if sheet.ID == "" {
 		sheet.ID = generateID()
 	}
+	sheet.Version = 1
 
 	data, err := json.Marshal(sheet)
 	if err != nil {
 		return CharacterSheet{}, fmt.Errorf("failed to marshal character: %w", err)
 	}
 
-	const insertQuery = `INSERT INTO characters (id, name, class, race, level, data) VALUES ($1, $2, $3, $4, $5, $6::jsonb);`
-	if _, err := s.db.Exec(insertQuery, sheet.ID, sheet.Name, sheet.Class, sheet.Race, sheet.Level, data); err != nil {
+	created, err := newRevision(1, LabelCreated, sheet, sheet)
+	if err != nil {
+		return CharacterSheet{}, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertQuery = `INSERT INTO characters (id, name, class, race, level, version, data) VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb);`
+	if _, err := tx.Exec(insertQuery, sheet.ID, sheet.Name, sheet.Class, sheet.Race, sheet.Level, sheet.Version, data); err != nil {
 		return CharacterSheet{}, fmt.Errorf("failed to insert character: %w", err)
 	}
+	if err := insertRevision(tx, sheet.ID, created); err != nil {
+		return CharacterSheet{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to commit character: %w", err)
+	}
 
 	return sheet, nil
 }
 
 func (s *PostgresStore) Get(id string) (CharacterSheet, error) {
-	const selectQuery = `SELECT data FROM characters WHERE id = $1;`
+	const selectQuery = `SELECT data, version FROM characters WHERE id = $1;`
 
 	var raw []byte
-	err := s.db.QueryRow(selectQuery, id).Scan(&raw)
+	var version int
+	err := s.db.QueryRow(selectQuery, id).Scan(&raw, &version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return CharacterSheet{}, ErrNotFound
@@ -107,12 +171,13 @@ func (s *PostgresStore) Get(id string) (CharacterSheet, error) {
 	if err := json.Unmarshal(raw, &sheet); err != nil {
 		return CharacterSheet{}, fmt.Errorf("failed to unmarshal character: %w", err)
 	}
+	sheet.Version = version
 
 	return sheet, nil
 }
 
 func (s *PostgresStore) List() []CharacterSheet {
-	const listQuery = `SELECT data FROM characters ORDER BY id;`
+	const listQuery = `SELECT data, version FROM characters ORDER BY id;`
 
 	rows, err := s.db.Query(listQuery)
 	if err != nil {
@@ -124,57 +189,238 @@ func (s *PostgresStore) List() []CharacterSheet {
 	var result []CharacterSheet
 	for rows.Next() {
 		var raw []byte
-		if err := rows.Scan(&raw); err != nil {
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
 			continue
 		}
 		var sheet CharacterSheet
 		if err := json.Unmarshal(raw, &sheet); err != nil {
 			continue
 		}
+		sheet.Version = version
 		result = append(result, sheet)
 	}
 	return result
 }
 
+// Find выполняет фильтрацию, сортировку и постраничную выборку в SQL.
+func (s *PostgresStore) Find(q Query) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	where, args := q.sqlWhere()
+
+	page := Page{Items: []CharacterSheet{}}
+	countQuery := `SELECT COUNT(*) FROM characters WHERE ` + where + `;`
+	if err := s.db.QueryRow(countQuery, args...).Scan(&page.Total); err != nil {
+		return Page{}, fmt.Errorf("failed to count characters: %w", err)
+	}
+
+	selectQuery := `SELECT data, version FROM characters WHERE ` + where + ` ORDER BY ` + q.sqlOrder()
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		selectQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	args = append(args, q.Offset)
+	selectQuery += fmt.Sprintf(" OFFSET $%d;", len(args))
+
+	rows, err := s.db.Query(selectQuery, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to list characters: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
+			return Page{}, fmt.Errorf("failed to scan character: %w", err)
+		}
+		var sheet CharacterSheet
+		if err := json.Unmarshal(raw, &sheet); err != nil {
+			return Page{}, fmt.Errorf("failed to unmarshal character: %w", err)
+		}
+		sheet.Version = version
+		page.Items = append(page.Items, sheet)
+	}
+	return page, rows.Err()
+}
+
 func (s *PostgresStore) Update(id string, sheet CharacterSheet) (CharacterSheet, error) {
+	return s.UpdateLabeled(id, sheet, LabelUpdate)
+}
+
+// UpdateLabeled обновляет лист и в той же транзакции записывает ревизию.
+// Изменения, которые ничего не меняют, ревизию не создают.
+func (s *PostgresStore) UpdateLabeled(id string, sheet CharacterSheet, label string) (CharacterSheet, error) {
+	sheet = Normalize(sheet)
 	if err := sheet.Validate(); err != nil {
 		return CharacterSheet{}, err
 	}
 
 	sheet.ID = id
+	expected := sheet.Version
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокируем строку, чтобы номера ревизий не пересекались
+	const lockQuery = `SELECT data, version FROM characters WHERE id = $1 FOR UPDATE;`
+	var raw []byte
+	var current int
+	if err := tx.QueryRow(lockQuery, id).Scan(&raw, &current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CharacterSheet{}, ErrNotFound
+		}
+		return CharacterSheet{}, fmt.Errorf("failed to get character: %w", err)
+	}
+	if expected == 0 {
+		expected = current
+	}
+	var existing CharacterSheet
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to unmarshal character: %w", err)
+	}
+	existing.Version = current
+	sheet.Version = expected + 1
 
 	data, err := json.Marshal(sheet)
 	if err != nil {
 		return CharacterSheet{}, fmt.Errorf("failed to marshal character: %w", err)
 	}
 
-	const updateQuery = `UPDATE characters SET name = $2, class = $3, race = $4, level = $5, data = $6::jsonb WHERE id = $1;`
-	res, err := s.db.Exec(updateQuery, id, sheet.Name, sheet.Class, sheet.Race, sheet.Level, data)
+	var lastRev int
+	const lastRevQuery = `SELECT COALESCE(MAX(rev), 0) FROM character_revisions WHERE character_id = $1;`
+	if err := tx.QueryRow(lastRevQuery, id).Scan(&lastRev); err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to get last revision: %w", err)
+	}
+	// Персонажи, созданные до появления истории, получают исходную ревизию
+	if lastRev == 0 {
+		initial, err := newRevision(1, LabelCreated, existing, existing)
+		if err != nil {
+			return CharacterSheet{}, err
+		}
+		if err := insertRevision(tx, id, initial); err != nil {
+			return CharacterSheet{}, err
+		}
+		lastRev = 1
+	}
+
+	rev, err := newRevision(lastRev+1, label, existing, sheet)
 	if err != nil {
-		return CharacterSheet{}, fmt.Errorf("failed to update character: %w", err)
+		return CharacterSheet{}, err
 	}
 
+	const updateQuery = `UPDATE characters SET name = $2, class = $3, race = $4, level = $5, data = $6::jsonb, version = version + 1 WHERE id = $1 AND version = $7;`
+	res, err := tx.Exec(updateQuery, id, sheet.Name, sheet.Class, sheet.Race, sheet.Level, data, expected)
+	if err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to update character: %w", err)
+	}
 	affected, err := res.RowsAffected()
 	if err == nil && affected == 0 {
-		return CharacterSheet{}, ErrNotFound
+		return CharacterSheet{}, ErrVersionConflict
+	}
+	if len(rev.Diff) > 0 {
+		if err := insertRevision(tx, id, rev); err != nil {
+			return CharacterSheet{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return CharacterSheet{}, fmt.Errorf("failed to commit character: %w", err)
 	}
 
 	return sheet, nil
 }
 
-func (s *PostgresStore) Delete(id string) error {
-	const deleteQuery = `DELETE FROM characters WHERE id = $1;`
+func (s *PostgresStore) Delete(id string, version int) error {
+	const deleteQuery = `DELETE FROM characters WHERE id = $1 AND ($2 = 0 OR version = $2);`
 
-	res, err := s.db.Exec(deleteQuery, id)
+	res, err := s.db.Exec(deleteQuery, id, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete character: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
 	if err == nil && affected == 0 {
-		return ErrNotFound
+		// Отличаем отсутствующего персонажа от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
 	}
 
 	return nil
 }
 
+// History возвращает историю изменений персонажа, от старых ревизий к новым.
+func (s *PostgresStore) History(id string) ([]RevisionSummary, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+
+	const historyQuery = `SELECT rev, created_at, label, diff FROM character_revisions WHERE character_id = $1 ORDER BY rev;`
+	rows, err := s.db.Query(historyQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	defer rows.Close()
+
+	result := []RevisionSummary{}
+	for rows.Next() {
+		var summary RevisionSummary
+		var diff []byte
+		if err := rows.Scan(&summary.Rev, &summary.Timestamp, &summary.Label, &diff); err != nil {
+			return nil, fmt.Errorf("failed to scan revision: %w", err)
+		}
+		if err := json.Unmarshal(diff, &summary.Diff); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal revision diff: %w", err)
+		}
+		result = append(result, summary)
+	}
+	return result, rows.Err()
+}
+
+// Revision возвращает сохранённую ревизию листа персонажа.
+func (s *PostgresStore) Revision(id string, rev int) (Revision, error) {
+	if _, err := s.Get(id); err != nil {
+		return Revision{}, err
+	}
+
+	const revisionQuery = `SELECT created_at, label, diff, data FROM character_revisions WHERE character_id = $1 AND rev = $2;`
+	revision := Revision{RevisionSummary: RevisionSummary{Rev: rev}}
+	var diff, data []byte
+	err := s.db.QueryRow(revisionQuery, id, rev).Scan(&revision.Timestamp, &revision.Label, &diff, &data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Revision{}, ErrRevisionNotFound
+		}
+		return Revision{}, fmt.Errorf("failed to get revision: %w", err)
+	}
+	if err := json.Unmarshal(diff, &revision.Diff); err != nil {
+		return Revision{}, fmt.Errorf("failed to unmarshal revision diff: %w", err)
+	}
+	if err := json.Unmarshal(data, &revision.Sheet); err != nil {
+		return Revision{}, fmt.Errorf("failed to unmarshal revision: %w", err)
+	}
+	return revision, nil
+}
+
+func insertRevision(tx *sql.Tx, id string, rev Revision) error {
+	diff, err := json.Marshal(rev.Diff)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision diff: %w", err)
+	}
+	data, err := json.Marshal(rev.Sheet)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision: %w", err)
+	}
+
+	const insertQuery = `INSERT INTO character_revisions (character_id, rev, created_at, label, diff, data) VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb);`
+	if _, err := tx.Exec(insertQuery, id, rev.Rev, rev.Timestamp, rev.Label, diff, data); err != nil {
+		return fmt.Errorf("failed to insert revision: %w", err)
+	}
+	return nil
+}
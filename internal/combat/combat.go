@@ -0,0 +1,207 @@
+// Package combat ведёт бои компании: порядок инициативы, раунды и ходы.
+package combat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/conditions"
+	"dice-service/internal/monsters"
+)
+
+var ErrNotFound = errors.New("combat not found")
+var ErrVersionConflict = errors.New("combat was modified by another request")
+var ErrParticipantNotFound = errors.New("participant not found")
+
+// Виды участников боя.
+const (
+	KindCharacter = "character"
+	KindMonster   = "monster"
+)
+
+// ReadiedAction подготовленное действие: выполняется реакцией при триггере.
+type ReadiedAction struct {
+	Action  string `json:"action"`
+	Trigger string `json:"trigger"`
+}
+
+// Vitals хиты персонажа в бою. Хранятся в снимках журнала, поэтому
+// отмена урона или лечения возвращает их, а обработчик переносит их
+// в лист персонажа.
+type Vitals struct {
+	CurrentHitPoints   int                   `json:"currentHitPoints"`
+	MaxHitPoints       int                   `json:"maxHitPoints"`
+	TemporaryHitPoints int                   `json:"temporaryHitPoints,omitempty"`
+	DeathSaves         characters.DeathSaves `json:"deathSaves"`
+	State              string                `json:"state,omitempty"`
+}
+
+// VitalsOf хиты из листа персонажа.
+func VitalsOf(sheet characters.CharacterSheet) Vitals {
+	return Vitals{
+		CurrentHitPoints:   sheet.CurrentHitPoints,
+		MaxHitPoints:       sheet.MaxHitPoints,
+		TemporaryHitPoints: sheet.TemporaryHitPoints,
+		DeathSaves:         sheet.DeathSaves,
+		State:              sheet.State,
+	}
+}
+
+// Apply переносит хиты в лист персонажа. Максимум хитов листа не
+// меняется: он зависит от уровня, а не от боя.
+func (v Vitals) Apply(sheet characters.CharacterSheet) characters.CharacterSheet {
+	sheet.CurrentHitPoints = v.CurrentHitPoints
+	sheet.TemporaryHitPoints = v.TemporaryHitPoints
+	sheet.DeathSaves = v.DeathSaves
+	sheet.State = v.State
+	return sheet
+}
+
+// Participant участник боя.
+type Participant struct {
+	ID              string             `json:"id"` // уникален в пределах боя
+	Kind            string             `json:"kind"`
+	RefID           string             `json:"refId"` // ID персонажа или монстра
+	Name            string             `json:"name"`
+	InitiativeBonus int                `json:"initiativeBonus"`
+	Dexterity       int                `json:"dexterity"`       // значение Ловкости для ничьих
+	Group           string             `json:"group,omitempty"` // участники группы бросают инициативу один раз
+	Roll            int                `json:"roll"`            // выпавшее на d20
+	Initiative      int                `json:"initiative"`
+	Delayed         bool               `json:"delayed,omitempty"` // откладывает ход и пропускается
+	Ready           *ReadiedAction     `json:"ready,omitempty"`
+	ExpiredReady    *ReadiedAction     `json:"expiredReady,omitempty"` // пропавшее в начале хода; Previous его возвращает
+	Instance        *monsters.Instance `json:"instance,omitempty"`     // хиты и состояние монстра
+	Vitals          *Vitals            `json:"vitals,omitempty"`       // хиты персонажа после последнего изменения в бою
+	// Conditions состояния персонажа в бою; у монстра они в Instance
+	Conditions []conditions.Condition `json:"conditions,omitempty"`
+}
+
+// Combat бой компании. Участники хранятся в порядке инициативы, Turn -
+// индекс участника, который сейчас ходит.
+type Combat struct {
+	ID           string        `json:"id"`
+	Version      int           `json:"version"` // растёт при каждом сохранении; 0 в запросе - без проверки
+	CompanyID    string        `json:"companyId"`
+	Name         string        `json:"name"`
+	Round        int           `json:"round"`
+	Turn         int           `json:"turn"`
+	Participants []Participant `json:"participants"`
+	Log          []LogEntry    `json:"log,omitempty"` // команды, изменившие бой
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+func (c Combat) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("combat name is required")
+	}
+	if c.CompanyID == "" {
+		return errors.New("company id is required")
+	}
+	if len(c.Participants) == 0 {
+		return errors.New("combat has no participants")
+	}
+	if c.Round < 1 {
+		return errors.New("round must be at least 1")
+	}
+	if c.Turn < 0 || c.Turn >= len(c.Participants) {
+		return fmt.Errorf("turn %d is out of range", c.Turn)
+	}
+	seen := make(map[string]bool, len(c.Participants))
+	for _, p := range c.Participants {
+		if p.ID == "" || seen[p.ID] {
+			return fmt.Errorf("participant id %q is empty or duplicated", p.ID)
+		}
+		seen[p.ID] = true
+		if p.Kind != KindCharacter && p.Kind != KindMonster {
+			return fmt.Errorf("participant %s: unknown kind %q", p.ID, p.Kind)
+		}
+		if p.Instance != nil {
+			if err := p.Instance.Validate(); err != nil {
+				return fmt.Errorf("participant %s: %w", p.ID, err)
+			}
+		}
+		for _, cond := range p.Conditions {
+			if err := cond.Validate(); err != nil {
+				return fmt.Errorf("participant %s: %w", p.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Current участник, который сейчас ходит.
+func (c Combat) Current() Participant {
+	return c.Participants[c.Turn]
+}
+
+func (c Combat) index(participantID string) (int, error) {
+	for i, p := range c.Participants {
+		if p.ID == participantID {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: %s", ErrParticipantNotFound, participantID)
+}
+
+// Instance экземпляр монстра участника для изменения его состояния.
+func (c *Combat) Instance(participantID string) (*monsters.Instance, error) {
+	i, err := c.index(participantID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Participants[i].Instance == nil {
+		return nil, fmt.Errorf("participant %s is not a monster", c.Participants[i].Name)
+	}
+	return c.Participants[i].Instance, nil
+}
+
+// Conditions список состояний участника для изменения: у монстра -
+// состояния экземпляра, у персонажа - его состояния в бою.
+func (c *Combat) Conditions(participantID string) (*[]conditions.Condition, error) {
+	i, err := c.index(participantID)
+	if err != nil {
+		return nil, err
+	}
+	if inst := c.Participants[i].Instance; inst != nil {
+		return &inst.Conditions, nil
+	}
+	return &c.Participants[i].Conditions, nil
+}
+
+// clone копия боя, не разделяющая участников и журнал с исходным.
+func (c Combat) clone() Combat {
+	c.Participants = cloneParticipants(c.Participants)
+	c.Log = append([]LogEntry(nil), c.Log...)
+	return c
+}
+
+// cloneParticipants копия участников с их подготовленными действиями,
+// экземплярами и состояниями.
+func cloneParticipants(participants []Participant) []Participant {
+	result := append([]Participant(nil), participants...)
+	for i, p := range result {
+		result[i].Conditions = append([]conditions.Condition(nil), p.Conditions...)
+		if p.Ready != nil {
+			ready := *p.Ready
+			result[i].Ready = &ready
+		}
+		if p.ExpiredReady != nil {
+			expired := *p.ExpiredReady
+			result[i].ExpiredReady = &expired
+		}
+		if p.Instance != nil {
+			inst := p.Instance.Clone()
+			result[i].Instance = &inst
+		}
+		if p.Vitals != nil {
+			vitals := *p.Vitals
+			result[i].Vitals = &vitals
+		}
+	}
+	return result
+}
@@ -0,0 +1,254 @@
+package combat
+
+import (
+	"errors"
+	"testing"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/monsters"
+)
+
+// sequence возвращает броски d20 по порядку.
+func sequence(rolls ...int) func() (int, error) {
+	return func() (int, error) {
+		if len(rolls) == 0 {
+			return 0, errors.New("no more rolls")
+		}
+		roll := rolls[0]
+		rolls = rolls[1:]
+		return roll, nil
+	}
+}
+
+func testCombat(t *testing.T) Combat {
+	t.Helper()
+
+	goblin := monsters.Monster{ID: "goblin", Name: "Гоблин", HitPoints: 7, AbilityScores: map[string]int{"DEX": 14}}
+	instances, err := monsters.NewInstances(goblin, 2, monsters.HPFixed, nil)
+	if err != nil {
+		t.Fatalf("NewInstances error: %v", err)
+	}
+	participants := []Participant{
+		CharacterParticipant(characters.CharacterSheet{ID: "arn", Name: "Арн", Initiative: 1, AbilityScores: characters.AbilityScores{Dexterity: 12}}),
+		CharacterParticipant(characters.CharacterSheet{ID: "bea", Name: "Бея", Initiative: 3, AbilityScores: characters.AbilityScores{Dexterity: 16}}),
+	}
+	participants = append(participants, MonsterParticipants(goblin, instances, true)...)
+
+	// Арн 15+1, Бея 10+3, гоблины один раз 11+2
+	c, err := New("company", "Засада", participants, sequence(15, 10, 11))
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return c
+}
+
+func names(c Combat) []string {
+	result := make([]string, 0, len(c.Participants))
+	for _, p := range c.Participants {
+		result = append(result, p.Name)
+	}
+	return result
+}
+
+func TestNewOrdersByInitiative(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	// Бея и гоблины делят 13, Бея ловчее
+	want := []string{"Арн", "Бея", "Гоблин 1", "Гоблин 2"}
+	got := names(c)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+	if c.Participants[2].Roll != 11 || c.Participants[3].Roll != 11 || c.Participants[2].Initiative != 13 {
+		t.Fatalf("group initiative was not shared: %+v", c.Participants[2:])
+	}
+	if c.Round != 1 || c.Current().Name != "Арн" {
+		t.Fatalf("unexpected start: round %d, current %s", c.Round, c.Current().Name)
+	}
+}
+
+func TestNextAndPrevious(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	if err := c.Previous(); err == nil {
+		t.Fatal("expected error before the first turn")
+	}
+	for i := 0; i < 4; i++ {
+		if err := c.Next(); err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+	}
+	if c.Round != 2 || c.Current().Name != "Арн" {
+		t.Fatalf("expected round 2 with Арн, got round %d with %s", c.Round, c.Current().Name)
+	}
+	if err := c.Previous(); err != nil {
+		t.Fatalf("Previous error: %v", err)
+	}
+	if c.Round != 1 || c.Current().Name != "Гоблин 2" {
+		t.Fatalf("expected round 1 with Гоблин 2, got round %d with %s", c.Round, c.Current().Name)
+	}
+}
+
+func TestDelayAndResume(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	arn := c.Current().ID
+	if err := c.Delay(); err != nil {
+		t.Fatalf("Delay error: %v", err)
+	}
+	if c.Current().Name != "Бея" {
+		t.Fatalf("expected Бея after delay, got %s", c.Current().Name)
+	}
+	if err := c.Next(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	// Арн вступает перед первым гоблином и ходит сразу
+	if err := c.Resume(arn); err != nil {
+		t.Fatalf("Resume error: %v", err)
+	}
+	if c.Current().ID != arn || c.Current().Initiative != 13 {
+		t.Fatalf("expected Арн to act at 13, got %+v", c.Current())
+	}
+	want := []string{"Бея", "Арн", "Гоблин 1", "Гоблин 2"}
+	got := names(c)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+	if err := c.Resume(arn); err == nil {
+		t.Fatal("expected error resuming a participant who is not delaying")
+	}
+	if err := c.Resume("missing"); !errors.Is(err, ErrParticipantNotFound) {
+		t.Fatalf("expected ErrParticipantNotFound, got %v", err)
+	}
+}
+
+func TestNextSkipsDelayed(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	for i := 0; i < 3; i++ {
+		if err := c.Delay(); err != nil {
+			t.Fatalf("Delay error: %v", err)
+		}
+	}
+	if c.Current().Name != "Гоблин 2" {
+		t.Fatalf("expected Гоблин 2, got %s", c.Current().Name)
+	}
+	if err := c.Delay(); err == nil {
+		t.Fatal("expected error when nobody else can act")
+	}
+	if c.Current().Delayed {
+		t.Fatal("failed delay must not leave the participant delaying")
+	}
+	if err := c.Next(); err != nil || c.Round != 2 || c.Current().Name != "Гоблин 2" {
+		t.Fatalf("expected Гоблин 2 in round 2, got round %d with %s (%v)", c.Round, c.Current().Name, err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	arn := c.Current().ID
+	if err := c.Ready("", ""); err == nil {
+		t.Fatal("expected error without action and trigger")
+	}
+	if err := c.Ready("Атака копьём", "гоблин подходит"); err != nil {
+		t.Fatalf("Ready error: %v", err)
+	}
+	if c.Current().Name != "Бея" || c.Participants[0].Ready == nil {
+		t.Fatalf("unexpected state after ready: %+v", c.Participants)
+	}
+
+	ready, err := c.UseReady(arn)
+	if err != nil || ready.Action != "Атака копьём" {
+		t.Fatalf("UseReady = %+v, %v", ready, err)
+	}
+	if _, err := c.UseReady(arn); err == nil {
+		t.Fatal("expected error for a spent readied action")
+	}
+
+	// Неиспользованное действие пропадает в начале следующего хода
+	if err := c.Ready("Заклинание", "дверь открывается"); err != nil {
+		t.Fatalf("Ready error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := c.Next(); err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+	}
+	for _, p := range c.Participants {
+		if p.Ready != nil {
+			t.Fatalf("readied action should expire at the start of the turn: %+v", p)
+		}
+	}
+
+	// Возврат хода возвращает и пропавшее действие
+	bea := c.Turn
+	if err := c.Previous(); err != nil {
+		t.Fatalf("Previous error: %v", err)
+	}
+	if ready := c.Participants[bea].Ready; ready == nil || ready.Action != "Заклинание" {
+		t.Fatalf("Previous should restore the readied action: %+v", c.Participants[bea])
+	}
+}
+
+func TestMemoryStoreIsolation(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	created, err := store.Create(testCombat(t))
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	loaded, _ := store.Get(created.ID)
+	loaded.Participants[0].Name = "Изменено"
+	if again, _ := store.Get(created.ID); again.Participants[0].Name == "Изменено" {
+		t.Fatal("store must not share participants with callers")
+	}
+
+	stale := loaded
+	if _, err := store.Update(loaded); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if _, err := store.Update(stale); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+}
+
+func TestInstanceStateResetsOnTurn(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	goblin := c.Participants[2].ID
+	inst, err := c.Instance(goblin)
+	if err != nil {
+		t.Fatalf("Instance error: %v", err)
+	}
+	if err := inst.UseReaction(); err != nil {
+		t.Fatalf("UseReaction error: %v", err)
+	}
+	if err := inst.Damage(5); err != nil || c.Participants[2].Instance.CurrentHitPoints != 2 {
+		t.Fatalf("damage was not applied to the participant: %+v", c.Participants[2].Instance)
+	}
+	if _, err := c.Instance(c.Participants[0].ID); err == nil {
+		t.Fatal("expected error for a character participant")
+	}
+
+	for c.Current().ID != goblin {
+		if err := c.Next(); err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+	}
+	if c.Current().Instance.ReactionUsed {
+		t.Fatal("reaction should be restored at the start of the turn")
+	}
+}
@@ -0,0 +1,107 @@
+package combat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/monsters"
+)
+
+// CharacterParticipant участник боя для персонажа: бонус инициативы берётся
+// из листа персонажа.
+func CharacterParticipant(sheet characters.CharacterSheet) Participant {
+	return Participant{
+		Kind:            KindCharacter,
+		RefID:           sheet.ID,
+		Name:            sheet.Name,
+		InitiativeBonus: sheet.Initiative,
+		Dexterity:       sheet.AbilityScores.Dexterity,
+	}
+}
+
+// MonsterParticipants создаёт участников для экземпляров монстра. При
+// group экземпляры бросают инициативу один раз и ходят подряд.
+func MonsterParticipants(m monsters.Monster, instances []monsters.Instance, group bool) []Participant {
+	dex, ok := m.AbilityScores["DEX"]
+	if !ok {
+		dex = 10
+	}
+	result := make([]Participant, 0, len(instances))
+	for _, inst := range instances {
+		inst := inst.Clone()
+		p := Participant{
+			Kind:            KindMonster,
+			RefID:           m.ID,
+			Name:            inst.Name,
+			InitiativeBonus: monsters.AbilityModifier(dex),
+			Dexterity:       dex,
+			Instance:        &inst,
+		}
+		if group {
+			p.Group = m.ID
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// New начинает бой: участникам назначаются ID, бросается инициатива
+// (d20 + бонус, один бросок на группу), и они упорядочиваются.
+// Первый ход - у участника с наибольшей инициативой в первом раунде.
+func New(companyID, name string, participants []Participant, d20 func() (int, error)) (Combat, error) {
+	if len(participants) == 0 {
+		return Combat{}, errors.New("combat has no participants")
+	}
+	c := Combat{
+		CompanyID:    companyID,
+		Name:         name,
+		Round:        1,
+		Participants: make([]Participant, 0, len(participants)),
+	}
+	groupRolls := make(map[string]int)
+	for i, p := range participants {
+		p.ID = fmt.Sprintf("p%d", i+1)
+		roll, ok := groupRolls[p.Group]
+		if !ok || p.Group == "" {
+			var err error
+			if roll, err = d20(); err != nil {
+				return Combat{}, err
+			}
+			if p.Group != "" {
+				groupRolls[p.Group] = roll
+			}
+		}
+		p.Roll = roll
+		p.Initiative = roll + p.InitiativeBonus
+		c.Participants = append(c.Participants, p)
+	}
+	SortByInitiative(c.Participants)
+	return c, c.Validate()
+}
+
+// SetInitiative меняет инициативу участника и переупорядочивает бой;
+// ход остаётся за тем же участником.
+func (c *Combat) SetInitiative(participantID string, initiative int) error {
+	i, err := c.index(participantID)
+	if err != nil {
+		return err
+	}
+	current := c.Current().ID
+	c.Participants[i].Initiative = initiative
+	SortByInitiative(c.Participants)
+	c.Turn, _ = c.index(current)
+	return nil
+}
+
+// SortByInitiative упорядочивает участников по убыванию инициативы;
+// ничьи разрешаются по Ловкости, при равной Ловкости порядок сохраняется.
+func SortByInitiative(participants []Participant) {
+	sort.SliceStable(participants, func(i, j int) bool {
+		if participants[i].Initiative != participants[j].Initiative {
+			return participants[i].Initiative > participants[j].Initiative
+		}
+		return participants[i].Dexterity > participants[j].Dexterity
+	})
+}
@@ -0,0 +1,145 @@
+package combat
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Команды журнала, отменяющие и повторяющие другие команды.
+const (
+	CommandUndo = "undo"
+	CommandRedo = "redo"
+)
+
+// UndoDepth сколько последних команд можно отменить. Снимки состояния
+// хранятся только у них, остальные записи остаются в журнале как рассказ.
+const UndoDepth = 20
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// State изменяемая часть боя: раунд, ход и участники.
+type State struct {
+	Round        int           `json:"round"`
+	Turn         int           `json:"turn"`
+	Participants []Participant `json:"participants"`
+}
+
+// LogEntry запись журнала боя. Журнал только дополняется: отмена и
+// повтор записываются отдельными записями со ссылкой на команду.
+// Before и After есть только у команд, которые ещё можно отменить
+// или повторить.
+type LogEntry struct {
+	Seq     int       `json:"seq"`
+	Command string    `json:"command"` // damage, heal, condition, next, initiative, ..., undo, redo
+	Summary string    `json:"summary"`
+	Round   int       `json:"round"`            // раунд, в котором выполнена команда
+	Target  int       `json:"target,omitempty"` // undo, redo: Seq команды
+	Before  *State    `json:"before,omitempty"` // состояние до команды
+	After   *State    `json:"after,omitempty"`  // состояние после команды
+	Time    time.Time `json:"time"`
+}
+
+// Snapshot копия изменяемой части боя для записи в журнал.
+func (c Combat) Snapshot() State {
+	return State{Round: c.Round, Turn: c.Turn, Participants: cloneParticipants(c.Participants)}
+}
+
+// restore возвращает бой к сохранённому состоянию.
+func (c *Combat) restore(s State) {
+	c.Round, c.Turn, c.Participants = s.Round, s.Turn, cloneParticipants(s.Participants)
+}
+
+// Record добавляет в журнал команду, изменившую бой из состояния before
+// в текущее. Новая команда делает невозможным повтор отменённых.
+func (c *Combat) Record(command, summary string, before State) LogEntry {
+	after := c.Snapshot()
+	e := c.appendEntry(LogEntry{Command: command, Summary: summary, Round: before.Round, Before: &before, After: &after})
+	c.trim()
+	return e
+}
+
+// trim убирает снимки у команд глубже UndoDepth: отменить их уже нельзя.
+func (c *Combat) trim() {
+	undo, redo := c.stacks()
+	keep := make(map[int]bool, UndoDepth+len(redo))
+	for _, seq := range undo[max(0, len(undo)-UndoDepth):] {
+		keep[seq] = true
+	}
+	for _, seq := range redo {
+		keep[seq] = true
+	}
+	for i := range c.Log {
+		if c.Log[i].Before != nil && !keep[c.Log[i].Seq] {
+			c.Log[i].Before, c.Log[i].After = nil, nil
+		}
+	}
+}
+
+func (c *Combat) appendEntry(e LogEntry) LogEntry {
+	e.Seq = len(c.Log) + 1
+	e.Time = time.Now().UTC()
+	c.Log = append(c.Log, e)
+	return e
+}
+
+// Undo отменяет последнюю действующую команду, возвращая бой к
+// состоянию до неё.
+func (c *Combat) Undo() (LogEntry, error) {
+	undo, _ := c.stacks()
+	if len(undo) == 0 || c.Log[undo[len(undo)-1]-1].Before == nil {
+		return LogEntry{}, ErrNothingToUndo
+	}
+	target := c.Log[undo[len(undo)-1]-1]
+	c.restore(*target.Before)
+	return c.appendEntry(LogEntry{
+		Command: CommandUndo,
+		Summary: "Undid: " + target.Summary,
+		Round:   c.Round,
+		Target:  target.Seq,
+	}), nil
+}
+
+// Redo повторяет последнюю отменённую команду.
+func (c *Combat) Redo() (LogEntry, error) {
+	_, redo := c.stacks()
+	if len(redo) == 0 || c.Log[redo[len(redo)-1]-1].After == nil {
+		return LogEntry{}, ErrNothingToRedo
+	}
+	target := c.Log[redo[len(redo)-1]-1]
+	c.restore(*target.After)
+	return c.appendEntry(LogEntry{
+		Command: CommandRedo,
+		Summary: "Redid: " + target.Summary,
+		Round:   c.Round,
+		Target:  target.Seq,
+	}), nil
+}
+
+// stacks восстанавливает по журналу стеки отмены и повтора (Seq команд).
+func (c Combat) stacks() (undo, redo []int) {
+	for _, e := range c.Log {
+		switch e.Command {
+		case CommandUndo:
+			undo = undo[:len(undo)-1]
+			redo = append(redo, e.Target)
+		case CommandRedo:
+			redo = redo[:len(redo)-1]
+			undo = append(undo, e.Target)
+		default:
+			undo = append(undo, e.Seq)
+			redo = nil
+		}
+	}
+	return undo, redo
+}
+
+// Narrative журнал боя в виде читаемых строк, по одной на запись.
+func (c Combat) Narrative() []string {
+	lines := make([]string, 0, len(c.Log))
+	for _, e := range c.Log {
+		lines = append(lines, fmt.Sprintf("Round %d: %s", e.Round, e.Summary))
+	}
+	return lines
+}
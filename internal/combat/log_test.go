@@ -0,0 +1,109 @@
+package combat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	goblin := c.Participants[2].ID
+
+	before := c.Snapshot()
+	inst, err := c.Instance(goblin)
+	if err != nil {
+		t.Fatalf("Instance error: %v", err)
+	}
+	if err := inst.Damage(5); err != nil {
+		t.Fatalf("Damage error: %v", err)
+	}
+	c.Record("damage", "Гоблин 1 takes 5 damage", before)
+
+	before = c.Snapshot()
+	if err := c.Next(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	c.Record("next", "Арн ends the turn", before)
+
+	if _, err := c.Undo(); err != nil {
+		t.Fatalf("Undo error: %v", err)
+	}
+	if c.Turn != 0 || c.Participants[2].Instance.CurrentHitPoints != 2 {
+		t.Fatalf("undo should restore the turn only: turn %d, hp %d", c.Turn, c.Participants[2].Instance.CurrentHitPoints)
+	}
+	if _, err := c.Undo(); err != nil {
+		t.Fatalf("Undo error: %v", err)
+	}
+	if c.Participants[2].Instance.CurrentHitPoints != 7 {
+		t.Fatalf("undo should restore hit points, got %d", c.Participants[2].Instance.CurrentHitPoints)
+	}
+	if _, err := c.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("expected ErrNothingToUndo, got %v", err)
+	}
+
+	entry, err := c.Redo()
+	if err != nil || entry.Target != 1 || c.Participants[2].Instance.CurrentHitPoints != 2 {
+		t.Fatalf("redo should reapply damage: %+v, %v", entry, err)
+	}
+
+	// Новая команда отменяет возможность повтора
+	before = c.Snapshot()
+	if err := c.SetInitiative(c.Participants[3].ID, 30); err != nil {
+		t.Fatalf("SetInitiative error: %v", err)
+	}
+	c.Record("initiative", "Гоблин 2's initiative changes", before)
+	if _, err := c.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("expected ErrNothingToRedo, got %v", err)
+	}
+
+	if len(c.Log) != 6 || len(c.Narrative()) != 6 || c.Narrative()[2] != "Round 1: Undid: Арн ends the turn" {
+		t.Fatalf("unexpected narrative: %q", c.Narrative())
+	}
+}
+
+func TestSetInitiativeKeepsCurrentTurn(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	if err := c.Next(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if err := c.SetInitiative(c.Participants[3].ID, 20); err != nil {
+		t.Fatalf("SetInitiative error: %v", err)
+	}
+	if got := names(c); got[0] != "Гоблин 2" || c.Current().Name != "Бея" {
+		t.Fatalf("unexpected order %v, current %s", got, c.Current().Name)
+	}
+	if err := c.SetInitiative("missing", 1); !errors.Is(err, ErrParticipantNotFound) {
+		t.Fatalf("expected ErrParticipantNotFound, got %v", err)
+	}
+}
+
+func TestUndoDepth(t *testing.T) {
+	t.Parallel()
+
+	c := testCombat(t)
+	for i := 0; i < UndoDepth+5; i++ {
+		before := c.Snapshot()
+		if err := c.Next(); err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+		c.Record("next", "next", before)
+	}
+	if c.Log[4].Before != nil || c.Log[5].Before == nil {
+		t.Fatalf("only the last %d commands should keep snapshots", UndoDepth)
+	}
+	for i := 0; i < UndoDepth; i++ {
+		if _, err := c.Undo(); err != nil {
+			t.Fatalf("Undo %d error: %v", i+1, err)
+		}
+	}
+	if _, err := c.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("expected ErrNothingToUndo beyond the undo depth, got %v", err)
+	}
+	if _, err := c.Redo(); err != nil {
+		t.Fatalf("Redo error: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package combat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store хранилище боёв.
+type Store interface {
+	Create(c Combat) (Combat, error)
+	Get(id string) (Combat, error)
+	// List возвращает бои компании, новые в конце
+	List(companyID string) []Combat
+	// Update сохраняет бой, только если его версия совпадает
+	// с c.Version (0 - без проверки), иначе возвращает ErrVersionConflict
+	Update(c Combat) (Combat, error)
+	// Delete удаляет бой; version 0 - без проверки версии
+	Delete(id string, version int) error
+}
+
+// MemoryStore хранилище боёв в памяти
+type MemoryStore struct {
+	mu   sync.RWMutex
+	byID map[string]Combat
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		byID: make(map[string]Combat),
+	}
+}
+
+func (s *MemoryStore) Create(c Combat) (Combat, error) {
+	if err := c.Validate(); err != nil {
+		return Combat{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.ID == "" {
+		c.ID = generateID()
+	}
+	if _, exists := s.byID[c.ID]; exists {
+		return Combat{}, fmt.Errorf("combat with id %s already exists", c.ID)
+	}
+	c.Version = 1
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+
+	s.byID[c.ID] = c.clone()
+	return c, nil
+}
+
+func (s *MemoryStore) Get(id string) (Combat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.byID[id]
+	if !ok {
+		return Combat{}, ErrNotFound
+	}
+	return c.clone(), nil
+}
+
+func (s *MemoryStore) List(companyID string) []Combat {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []Combat{}
+	for _, c := range s.byID {
+		if c.CompanyID == companyID {
+			result = append(result, c.clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (s *MemoryStore) Update(c Combat) (Combat, error) {
+	if err := c.Validate(); err != nil {
+		return Combat{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byID[c.ID]
+	if !ok {
+		return Combat{}, ErrNotFound
+	}
+	if c.Version != 0 && c.Version != existing.Version {
+		return Combat{}, ErrVersionConflict
+	}
+
+	c.CompanyID = existing.CompanyID
+	c.Version = existing.Version + 1
+	c.CreatedAt = existing.CreatedAt
+	c.UpdatedAt = time.Now()
+	s.byID[c.ID] = c.clone()
+	return c, nil
+}
+
+func (s *MemoryStore) Delete(id string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && version != existing.Version {
+		return ErrVersionConflict
+	}
+	delete(s.byID, id)
+	return nil
+}
+
+func generateID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(fmt.Errorf("failed to generate id: %w", err))
+	}
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,167 @@
+package combat
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresStore реализует Store для боёв в PostgreSQL.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore создаёт хранилище боёв и гарантирует,
+// что таблица существует.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	const createTable = `
+CREATE TABLE IF NOT EXISTS combats (
+	id         TEXT PRIMARY KEY,
+	company_id TEXT NOT NULL,
+	version    INTEGER NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL,
+	data       JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_combats_company_id ON combats(company_id);`
+
+	if _, err := db.Exec(createTable); err != nil {
+		panic(fmt.Errorf("failed to create combats table: %w", err))
+	}
+
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Create(c Combat) (Combat, error) {
+	if err := c.Validate(); err != nil {
+		return Combat{}, err
+	}
+
+	if c.ID == "" {
+		c.ID = generateID()
+	}
+	c.Version = 1
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return Combat{}, fmt.Errorf("failed to marshal combat: %w", err)
+	}
+
+	const insertQuery = `INSERT INTO combats (id, company_id, version, created_at, data) VALUES ($1, $2, $3, $4, $5::jsonb);`
+	if _, err := s.db.Exec(insertQuery, c.ID, c.CompanyID, c.Version, c.CreatedAt, data); err != nil {
+		return Combat{}, fmt.Errorf("failed to insert combat: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *PostgresStore) Get(id string) (Combat, error) {
+	const selectQuery = `SELECT data, version FROM combats WHERE id = $1;`
+
+	var raw []byte
+	var version int
+	err := s.db.QueryRow(selectQuery, id).Scan(&raw, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Combat{}, ErrNotFound
+		}
+		return Combat{}, fmt.Errorf("failed to get combat: %w", err)
+	}
+
+	var c Combat
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Combat{}, fmt.Errorf("failed to unmarshal combat: %w", err)
+	}
+	c.Version = version
+	return c, nil
+}
+
+func (s *PostgresStore) List(companyID string) []Combat {
+	const listQuery = `SELECT data, version FROM combats WHERE company_id = $1 ORDER BY created_at, id;`
+
+	result := []Combat{}
+	rows, err := s.db.Query(listQuery, companyID)
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
+			continue
+		}
+		var c Combat
+		if err := json.Unmarshal(raw, &c); err != nil {
+			continue
+		}
+		c.Version = version
+		result = append(result, c)
+	}
+	return result
+}
+
+func (s *PostgresStore) Update(c Combat) (Combat, error) {
+	if err := c.Validate(); err != nil {
+		return Combat{}, err
+	}
+
+	existing, err := s.Get(c.ID)
+	if err != nil {
+		return Combat{}, err
+	}
+	expected := c.Version
+	if expected == 0 {
+		expected = existing.Version
+	}
+	c.CompanyID = existing.CompanyID
+	c.Version = expected + 1
+	c.CreatedAt = existing.CreatedAt
+	c.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return Combat{}, fmt.Errorf("failed to marshal combat: %w", err)
+	}
+
+	const updateQuery = `UPDATE combats SET data = $2::jsonb, version = version + 1 WHERE id = $1 AND version = $3;`
+	res, err := s.db.Exec(updateQuery, c.ID, data, expected)
+	if err != nil {
+		return Combat{}, fmt.Errorf("failed to update combat: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		// Отличаем удалённый бой от устаревшей версии
+		if _, err := s.Get(c.ID); err != nil {
+			return Combat{}, err
+		}
+		return Combat{}, ErrVersionConflict
+	}
+
+	return c, nil
+}
+
+func (s *PostgresStore) Delete(id string, version int) error {
+	const deleteQuery = `DELETE FROM combats WHERE id = $1 AND ($2 = 0 OR version = $2);`
+
+	res, err := s.db.Exec(deleteQuery, id, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete combat: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		// Отличаем отсутствующий бой от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+
+	return nil
+}
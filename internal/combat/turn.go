@@ -0,0 +1,118 @@
+package combat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Next передаёт ход следующему участнику, пропуская отложивших ход.
+// После последнего участника начинается новый раунд. Когда наступает
+// ход участника, его подготовленное действие пропадает, а монстр
+// восстанавливает реакцию и легендарные действия.
+func (c *Combat) Next() error {
+	turn, round := c.Turn, c.Round
+	for range c.Participants {
+		turn++
+		if turn == len(c.Participants) {
+			turn, round = 0, round+1
+		}
+		if !c.Participants[turn].Delayed {
+			c.Turn, c.Round = turn, round
+			c.Participants[turn].ExpiredReady = c.Participants[turn].Ready
+			c.Participants[turn].Ready = nil
+			if inst := c.Participants[turn].Instance; inst != nil {
+				inst.StartTurn()
+			}
+			return nil
+		}
+	}
+	return errors.New("every participant is delaying")
+}
+
+// Previous возвращает ход предыдущему участнику, пропуская отложивших ход.
+// Участнику, чей ход отменяется, возвращается подготовленное действие,
+// пропавшее в начале этого хода.
+func (c *Combat) Previous() error {
+	turn, round := c.Turn, c.Round
+	for range c.Participants {
+		turn--
+		if turn < 0 {
+			if round == 1 {
+				return errors.New("combat is at its first turn")
+			}
+			turn, round = len(c.Participants)-1, round-1
+		}
+		if !c.Participants[turn].Delayed {
+			current := &c.Participants[c.Turn]
+			if current.ExpiredReady != nil {
+				current.Ready, current.ExpiredReady = current.ExpiredReady, nil
+			}
+			c.Turn, c.Round = turn, round
+			return nil
+		}
+	}
+	return errors.New("every participant is delaying")
+}
+
+// Delay текущий участник откладывает ход: он пропускается, пока не
+// вернётся в бой через Resume, а ход переходит к следующему.
+func (c *Combat) Delay() error {
+	c.Participants[c.Turn].Delayed = true
+	if err := c.Next(); err != nil {
+		c.Participants[c.Turn].Delayed = false
+		return errors.New("no other participant can act")
+	}
+	return nil
+}
+
+// Resume участник, отложивший ход, вступает в бой перед текущим
+// участником: он получает его инициативу и сразу ходит.
+func (c *Combat) Resume(participantID string) error {
+	i, err := c.index(participantID)
+	if err != nil {
+		return err
+	}
+	p := c.Participants[i]
+	if !p.Delayed {
+		return fmt.Errorf("participant %s is not delaying", p.Name)
+	}
+	p.Delayed = false
+	p.Ready = nil
+	p.Initiative = c.Current().Initiative
+
+	c.Participants = append(c.Participants[:i], c.Participants[i+1:]...)
+	if i < c.Turn {
+		c.Turn--
+	}
+	c.Participants = append(c.Participants[:c.Turn], append([]Participant{p}, c.Participants[c.Turn:]...)...)
+	return nil
+}
+
+// Ready текущий участник готовит действие на триггер и завершает ход.
+func (c *Combat) Ready(action, trigger string) error {
+	if strings.TrimSpace(action) == "" || strings.TrimSpace(trigger) == "" {
+		return errors.New("readied action and trigger are required")
+	}
+	c.Participants[c.Turn].Ready = &ReadiedAction{Action: action, Trigger: trigger}
+	if err := c.Next(); err != nil {
+		c.Participants[c.Turn].Ready = nil
+		return err
+	}
+	return nil
+}
+
+// UseReady участник выполняет подготовленное действие реакцией:
+// действие снимается, очерёдность ходов не меняется.
+func (c *Combat) UseReady(participantID string) (ReadiedAction, error) {
+	i, err := c.index(participantID)
+	if err != nil {
+		return ReadiedAction{}, err
+	}
+	if c.Participants[i].Ready == nil {
+		return ReadiedAction{}, fmt.Errorf("participant %s has no readied action", c.Participants[i].Name)
+	}
+	ready := *c.Participants[i].Ready
+	c.Participants[i].Ready = nil
+	return ready, nil
+}
@@ -10,27 +10,41 @@ import (
 
 var ErrNotFound = errors.New("company not found")
 var ErrDuplicateName = errors.New("company with this name already exists")
+var ErrMonsterNotInCompany = errors.New("monster is not in this company")
+var ErrVersionConflict = errors.New("company was modified by another request")
+var ErrInstanceNotFound = errors.New("monster instance not found")
+
+// Способы развития персонажей в компании
+const (
+	AdvancementXP        = "xp"        // уровни за опыт (по умолчанию)
+	AdvancementMilestone = "milestone" // уровни по вехам сюжета, опыт не начисляется
+)
 
 // Company представляет склад/кампанию, объединяющую персонажей и монстров
 type Company struct {
-	ID          string                       `json:"id"`
-	Name        string                       `json:"name"`
-	Description string                       `json:"description"`
-	CreatedAt   time.Time                    `json:"createdAt"`
-	UpdatedAt   time.Time                    `json:"updatedAt"`
-	Characters  []characters.CharacterSheet  `json:"characters"`  // персонажи в компании
-	Monsters    []monsters.Monster           `json:"monsters"`    // монстры в компании
+	ID          string                      `json:"id"`
+	Version     int                         `json:"version"` // растёт при каждом сохранении; 0 в запросе - без проверки
+	Name        string                      `json:"name"`
+	Description string                      `json:"description"`
+	Advancement string                      `json:"advancement,omitempty"` // xp или milestone
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+	Characters  []characters.CharacterSheet `json:"characters"` // персонажи в компании
+	Monsters    []monsters.Monster          `json:"monsters"`   // монстры в компании
+	Instances   []monsters.Instance         `json:"instances"`  // экземпляры монстров со своими хитами и состояниями
 }
 
 // CompanySummary краткая информация о компании для списка
 type CompanySummary struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	Description     string    `json:"description"`
-	CharacterCount  int       `json:"characterCount"`
-	MonsterCount    int       `json:"monsterCount"`
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Advancement    string    `json:"advancement,omitempty"`
+	CharacterCount int       `json:"characterCount"`
+	MonsterCount   int       `json:"monsterCount"`
+	InstanceCount  int       `json:"instanceCount"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
 // ToSummary конвертирует Company в CompanySummary
@@ -39,8 +53,10 @@ func (c Company) ToSummary() CompanySummary {
 		ID:             c.ID,
 		Name:           c.Name,
 		Description:    c.Description,
+		Advancement:    c.Advancement,
 		CharacterCount: len(c.Characters),
 		MonsterCount:   len(c.Monsters),
+		InstanceCount:  len(c.Instances),
 		CreatedAt:      c.CreatedAt,
 		UpdatedAt:      c.UpdatedAt,
 	}
@@ -50,6 +66,11 @@ func (c Company) Validate() error {
 	if c.Name == "" {
 		return errors.New("company name is required")
 	}
+	switch c.Advancement {
+	case "", AdvancementXP, AdvancementMilestone:
+	default:
+		return errors.New("advancement must be xp or milestone")
+	}
 	return nil
 }
 
@@ -61,6 +82,8 @@ type AddCharacterRequest struct {
 // AddMonsterRequest запрос на добавление монстра в компанию
 type AddMonsterRequest struct {
 	MonsterID string `json:"monsterId"`
+	HPMode    string `json:"hpMode,omitempty"` // fixed, average, roll, max - хиты каждого экземпляра
+	Count     int    `json:"count,omitempty"`  // число экземпляров, 0 - один
 }
 
 
@@ -0,0 +1,142 @@
+package company
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"dice-service/internal/characters"
+)
+
+// Поля сортировки списка компаний.
+const (
+	SortByName      = "name"
+	SortByCreatedAt = "created"
+	SortByUpdatedAt = "updated"
+)
+
+var sortColumns = map[string]string{
+	SortByName:      "LOWER(name)",
+	SortByCreatedAt: "created_at",
+	SortByUpdatedAt: "updated_at",
+}
+
+// Query фильтры, сортировка и страница для списка компаний.
+type Query struct {
+	Name        string // подстрока названия без учёта регистра
+	CharacterID string // только компании, в которых состоит персонаж
+	Advancement string // только компании с этим способом развития
+	Sort        string
+	Desc        bool
+	Limit       int // 0 - все записи
+	Offset      int
+}
+
+// Page страница списка компаний.
+type Page struct {
+	Items []CompanySummary
+	Total int // число записей, подходящих под фильтры
+}
+
+func (q Query) Validate() error {
+	if q.Sort != "" {
+		if _, ok := sortColumns[q.Sort]; !ok {
+			return fmt.Errorf("unknown sort field %q", q.Sort)
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return errors.New("limit and offset must not be negative")
+	}
+	return nil
+}
+
+// matches компания подходит под фильтры запроса.
+func (q Query) matches(c Company) bool {
+	if q.Name != "" && !strings.Contains(strings.ToLower(c.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.Advancement != "" && c.Advancement != q.Advancement {
+		return false
+	}
+	if q.CharacterID != "" && !slices.ContainsFunc(c.Characters, func(char characters.CharacterSheet) bool {
+		return char.ID == q.CharacterID
+	}) {
+		return false
+	}
+	return true
+}
+
+// apply сортирует и обрезает отфильтрованный список в памяти. Без поля
+// сортировки компании упорядочиваются по идентификатору, как в PostgresStore.
+func (q Query) apply(filtered []CompanySummary) Page {
+
+	compare := func(a, b CompanySummary) int {
+		switch q.Sort {
+		case SortByName:
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case SortByCreatedAt:
+			return a.CreatedAt.Compare(b.CreatedAt)
+		case SortByUpdatedAt:
+			return a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		return 0
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		c := compare(filtered[i], filtered[j])
+		if q.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return filtered[i].ID < filtered[j].ID
+	})
+
+	page := Page{Total: len(filtered)}
+	start := min(q.Offset, len(filtered))
+	end := len(filtered)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, end)
+	}
+	page.Items = filtered[start:end]
+	return page
+}
+
+// likeEscaper экранирует подстановочные символы LIKE в пользовательском вводе.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlWhere строит условие WHERE для фильтров запроса (параметры начиная с $1).
+func (q Query) sqlWhere() (string, []any) {
+	var conds []string
+	var args []any
+	if q.Name != "" {
+		args = append(args, likeEscaper.Replace(q.Name))
+		conds = append(conds, fmt.Sprintf("name ILIKE '%%' || $%d || '%%' ESCAPE '\\'", len(args)))
+	}
+	if q.Advancement != "" {
+		args = append(args, q.Advancement)
+		conds = append(conds, fmt.Sprintf("data->>'advancement' = $%d", len(args)))
+	}
+	if q.CharacterID != "" {
+		args = append(args, q.CharacterID)
+		conds = append(conds, fmt.Sprintf("data->'characters' @> jsonb_build_array(jsonb_build_object('id', $%d::text))", len(args)))
+	}
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// sqlOrder строит ORDER BY; id в конце делает порядок страниц устойчивым.
+func (q Query) sqlOrder() string {
+	column, ok := sortColumns[q.Sort]
+	if !ok {
+		return "id"
+	}
+	if q.Desc {
+		return column + " DESC, id"
+	}
+	return column + ", id"
+}
@@ -17,12 +17,26 @@ type Store interface {
 	Create(c Company) (Company, error)
 	Get(id string) (Company, error)
 	List() []CompanySummary
+	// Find возвращает страницу компаний с учётом фильтров и сортировки
+	Find(q Query) (Page, error)
+	// Update сохраняет компанию, только если её версия совпадает
+	// с c.Version (0 - без проверки), иначе возвращает ErrVersionConflict
 	Update(c Company) error
-	Delete(id string) error
+	// Delete удаляет компанию; version 0 - без проверки версии
+	Delete(id string, version int) error
 	AddCharacter(companyID string, char characters.CharacterSheet) error
 	RemoveCharacter(companyID, characterID string) error
 	AddMonster(companyID string, mon monsters.Monster) error
+	UpdateMonster(companyID string, mon monsters.Monster) error
+	// RemoveMonster удаляет монстра вместе с его экземплярами
 	RemoveMonster(companyID, monsterID string) error
+	// AddMonsterInstances добавляет экземпляры монстра (и его копию, если её ещё нет)
+	AddMonsterInstances(companyID string, mon monsters.Monster, instances []monsters.Instance) error
+	// UpdateMonsterInstance изменяет экземпляр функцией apply под блокировкой
+	// компании: параллельные изменения не теряются. Ошибка apply отменяет
+	// изменение; ErrInstanceNotFound, если экземпляра нет
+	UpdateMonsterInstance(companyID, instanceID string, apply func(*monsters.Instance) error) (monsters.Instance, error)
+	RemoveMonsterInstance(companyID, instanceID string) error
 }
 
 // MemoryStore хранилище компаний в памяти
@@ -57,6 +71,7 @@ func (s *MemoryStore) Create(c Company) (Company, error) {
 	if c.ID == "" {
 		c.ID = generateID()
 	}
+	c.Version = 1
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = time.Now()
 	if c.Characters == nil {
@@ -65,6 +80,9 @@ func (s *MemoryStore) Create(c Company) (Company, error) {
 	if c.Monsters == nil {
 		c.Monsters = []monsters.Monster{}
 	}
+	if c.Instances == nil {
+		c.Instances = []monsters.Instance{}
+	}
 
 	s.companies[c.ID] = c
 	return c, nil
@@ -94,6 +112,23 @@ func (s *MemoryStore) List() []CompanySummary {
 	return result
 }
 
+// Find возвращает страницу компаний по запросу
+func (s *MemoryStore) Find(q Query) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filtered := []CompanySummary{}
+	for _, c := range s.companies {
+		if q.matches(c) {
+			filtered = append(filtered, c.ToSummary())
+		}
+	}
+	return q.apply(filtered), nil
+}
+
 // Update обновляет компанию
 func (s *MemoryStore) Update(c Company) error {
 	if err := c.Validate(); err != nil {
@@ -107,6 +142,9 @@ func (s *MemoryStore) Update(c Company) error {
 	if !ok {
 		return ErrNotFound
 	}
+	if c.Version != 0 && c.Version != existing.Version {
+		return ErrVersionConflict
+	}
 
 	// Проверяем уникальность названия, если оно изменилось (исключая текущую компанию)
 	if !strings.EqualFold(existing.Name, c.Name) {
@@ -117,6 +155,7 @@ func (s *MemoryStore) Update(c Company) error {
 		}
 	}
 
+	c.Version = existing.Version + 1
 	c.CreatedAt = existing.CreatedAt
 	c.UpdatedAt = time.Now()
 	// Сохраняем персонажей и монстров если не переданы
@@ -126,19 +165,26 @@ func (s *MemoryStore) Update(c Company) error {
 	if c.Monsters == nil {
 		c.Monsters = existing.Monsters
 	}
+	if c.Instances == nil {
+		c.Instances = existing.Instances
+	}
 
 	s.companies[c.ID] = c
 	return nil
 }
 
 // Delete удаляет компанию
-func (s *MemoryStore) Delete(id string) error {
+func (s *MemoryStore) Delete(id string, version int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.companies[id]; !ok {
+	existing, ok := s.companies[id]
+	if !ok {
 		return ErrNotFound
 	}
+	if version != 0 && version != existing.Version {
+		return ErrVersionConflict
+	}
 
 	delete(s.companies, id)
 	return nil
@@ -162,6 +208,7 @@ func (s *MemoryStore) AddCharacter(companyID string, char characters.CharacterSh
 	}
 
 	c.Characters = append(c.Characters, char)
+	c.Version++
 	c.UpdatedAt = time.Now()
 	s.companies[companyID] = c
 	return nil
@@ -180,6 +227,7 @@ func (s *MemoryStore) RemoveCharacter(companyID, characterID string) error {
 	for i, char := range c.Characters {
 		if char.ID == characterID {
 			c.Characters = append(c.Characters[:i], c.Characters[i+1:]...)
+			c.Version++
 			c.UpdatedAt = time.Now()
 			s.companies[companyID] = c
 			return nil
@@ -207,11 +255,35 @@ func (s *MemoryStore) AddMonster(companyID string, mon monsters.Monster) error {
 	}
 
 	c.Monsters = append(c.Monsters, mon)
+	c.Version++
 	c.UpdatedAt = time.Now()
 	s.companies[companyID] = c
 	return nil
 }
 
+// UpdateMonster заменяет копию монстра в компании (например, после наложения состояния)
+func (s *MemoryStore) UpdateMonster(companyID string, mon monsters.Monster) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.companies[companyID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for i, existing := range c.Monsters {
+		if existing.ID == mon.ID {
+			c.Monsters[i] = mon
+			c.Version++
+			c.UpdatedAt = time.Now()
+			s.companies[companyID] = c
+			return nil
+		}
+	}
+
+	return ErrMonsterNotInCompany
+}
+
 // RemoveMonster удаляет монстра из компании
 func (s *MemoryStore) RemoveMonster(companyID, monsterID string) error {
 	s.mu.Lock()
@@ -225,6 +297,8 @@ func (s *MemoryStore) RemoveMonster(companyID, monsterID string) error {
 	for i, mon := range c.Monsters {
 		if mon.ID == monsterID {
 			c.Monsters = append(c.Monsters[:i], c.Monsters[i+1:]...)
+			c.Instances = withoutMonsterInstances(c.Instances, monsterID)
+			c.Version++
 			c.UpdatedAt = time.Now()
 			s.companies[companyID] = c
 			return nil
@@ -234,6 +308,101 @@ func (s *MemoryStore) RemoveMonster(companyID, monsterID string) error {
 	return nil // монстр не найден, но это не ошибка
 }
 
+// AddMonsterInstances добавляет экземпляры монстра в компанию
+func (s *MemoryStore) AddMonsterInstances(companyID string, mon monsters.Monster, instances []monsters.Instance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.companies[companyID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if !hasMonster(c.Monsters, mon.ID) {
+		c.Monsters = append(c.Monsters, mon)
+	}
+	c.Instances = append(append([]monsters.Instance(nil), c.Instances...), instances...)
+	c.Version++
+	c.UpdatedAt = time.Now()
+	s.companies[companyID] = c
+	return nil
+}
+
+// UpdateMonsterInstance изменяет экземпляр монстра (хиты, состояния...)
+func (s *MemoryStore) UpdateMonsterInstance(companyID, instanceID string, apply func(*monsters.Instance) error) (monsters.Instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.companies[companyID]
+	if !ok {
+		return monsters.Instance{}, ErrNotFound
+	}
+
+	for i, existing := range c.Instances {
+		if existing.ID == instanceID {
+			inst := existing.Clone()
+			if err := apply(&inst); err != nil {
+				return monsters.Instance{}, err
+			}
+			inst.ID = instanceID
+			if err := inst.Validate(); err != nil {
+				return monsters.Instance{}, err
+			}
+			c.Instances = append([]monsters.Instance(nil), c.Instances...)
+			c.Instances[i] = inst
+			c.Version++
+			c.UpdatedAt = time.Now()
+			s.companies[companyID] = c
+			return inst.Clone(), nil
+		}
+	}
+
+	return monsters.Instance{}, ErrInstanceNotFound
+}
+
+// RemoveMonsterInstance удаляет экземпляр монстра из компании
+func (s *MemoryStore) RemoveMonsterInstance(companyID, instanceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.companies[companyID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for i, inst := range c.Instances {
+		if inst.ID == instanceID {
+			c.Instances = append(append([]monsters.Instance(nil), c.Instances[:i]...), c.Instances[i+1:]...)
+			c.Version++
+			c.UpdatedAt = time.Now()
+			s.companies[companyID] = c
+			return nil
+		}
+	}
+
+	return nil // экземпляр не найден, но это не ошибка
+}
+
+func hasMonster(list []monsters.Monster, monsterID string) bool {
+	for _, m := range list {
+		if m.ID == monsterID {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutMonsterInstances экземпляры, не относящиеся к монстру monsterID
+func withoutMonsterInstances(instances []monsters.Instance, monsterID string) []monsters.Instance {
+	result := []monsters.Instance{}
+	for _, inst := range instances {
+		if inst.MonsterID != monsterID {
+			result = append(result, inst)
+		}
+	}
+	return result
+}
+
 // generateID генерирует уникальный ID используя crypto/rand
 func generateID() string {
 	var buf [8]byte
@@ -68,6 +68,12 @@ END $$;`
 		panic(fmt.Errorf("failed to migrate companies table: %w", err))
 	}
 
+	// Миграция: номер версии для оптимистичной блокировки
+	const migrateVersion = `ALTER TABLE companies ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`
+	if _, err := db.Exec(migrateVersion); err != nil {
+		panic(fmt.Errorf("failed to add companies version column: %w", err))
+	}
+
 	return &PostgresStore{db: db}
 }
 
@@ -91,6 +97,7 @@ func (s *PostgresStore) Create(c Company) (Company, error) {
 	if c.ID == "" {
 		c.ID = generateID()
 	}
+	c.Version = 1
 	now := time.Now()
 	c.CreatedAt = now
 	c.UpdatedAt = now
@@ -100,8 +107,8 @@ func (s *PostgresStore) Create(c Company) (Company, error) {
 		return Company{}, fmt.Errorf("failed to marshal company: %w", err)
 	}
 
-	const insertQuery = `INSERT INTO companies (id, name, created_at, updated_at, data) VALUES ($1, $2, $3, $4, $5::jsonb);`
-	if _, err := s.db.Exec(insertQuery, c.ID, c.Name, c.CreatedAt, c.UpdatedAt, data); err != nil {
+	const insertQuery = `INSERT INTO companies (id, name, created_at, updated_at, version, data) VALUES ($1, $2, $3, $4, $5, $6::jsonb);`
+	if _, err := s.db.Exec(insertQuery, c.ID, c.Name, c.CreatedAt, c.UpdatedAt, c.Version, data); err != nil {
 		// Проверяем, не нарушен ли уникальный индекс
 		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "unique constraint") {
 			return Company{}, ErrDuplicateName
@@ -113,10 +120,11 @@ func (s *PostgresStore) Create(c Company) (Company, error) {
 }
 
 func (s *PostgresStore) Get(id string) (Company, error) {
-	const selectQuery = `SELECT data FROM companies WHERE id = $1;`
+	const selectQuery = `SELECT data, version FROM companies WHERE id = $1;`
 
 	var raw []byte
-	err := s.db.QueryRow(selectQuery, id).Scan(&raw)
+	var version int
+	err := s.db.QueryRow(selectQuery, id).Scan(&raw, &version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Company{}, ErrNotFound
@@ -128,6 +136,7 @@ func (s *PostgresStore) Get(id string) (Company, error) {
 	if err := json.Unmarshal(raw, &c); err != nil {
 		return Company{}, fmt.Errorf("failed to unmarshal company: %w", err)
 	}
+	c.Version = version
 
 	return c, nil
 }
@@ -156,22 +165,73 @@ func (s *PostgresStore) List() []CompanySummary {
 	return result
 }
 
+func (s *PostgresStore) Find(q Query) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	where, args := q.sqlWhere()
+
+	page := Page{Items: []CompanySummary{}}
+	countQuery := `SELECT COUNT(*) FROM companies WHERE ` + where + `;`
+	if err := s.db.QueryRow(countQuery, args...).Scan(&page.Total); err != nil {
+		return Page{}, fmt.Errorf("failed to count companies: %w", err)
+	}
+
+	selectQuery := `SELECT data, version FROM companies WHERE ` + where + ` ORDER BY ` + q.sqlOrder()
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		selectQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	args = append(args, q.Offset)
+	selectQuery += fmt.Sprintf(" OFFSET $%d;", len(args))
+
+	rows, err := s.db.Query(selectQuery, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to list companies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
+			return Page{}, fmt.Errorf("failed to scan company: %w", err)
+		}
+		var c Company
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return Page{}, fmt.Errorf("failed to unmarshal company: %w", err)
+		}
+		c.Version = version
+		page.Items = append(page.Items, c.ToSummary())
+	}
+	return page, rows.Err()
+}
+
 func (s *PostgresStore) Update(c Company) error {
 	if err := c.Validate(); err != nil {
 		return err
 	}
 
-	// Загружаем существующую компанию, чтобы сохранить CreatedAt и, при необходимости, связанные сущности.
-	existing, err := s.Get(c.ID)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Загружаем существующую компанию под блокировкой, чтобы сохранить CreatedAt и, при необходимости, связанные сущности.
+	existing, err := lockCompany(tx, c.ID)
 	if err != nil {
 		return err
 	}
+	if c.Version != 0 && c.Version != existing.Version {
+		return ErrVersionConflict
+	}
 
 	// Проверяем уникальность названия, если оно изменилось (исключая текущую компанию)
 	if !strings.EqualFold(existing.Name, c.Name) {
 		const checkQuery = `SELECT id FROM companies WHERE LOWER(name) = LOWER($1) AND id != $2;`
 		var existingID string
-		err := s.db.QueryRow(checkQuery, c.Name, c.ID).Scan(&existingID)
+		err := tx.QueryRow(checkQuery, c.Name, c.ID).Scan(&existingID)
 		if err == nil {
 			// Найдена другая компания с таким же названием
 			return ErrDuplicateName
@@ -187,112 +247,203 @@ func (s *PostgresStore) Update(c Company) error {
 	if c.Monsters == nil {
 		c.Monsters = existing.Monsters
 	}
+	if c.Instances == nil {
+		c.Instances = existing.Instances
+	}
+	c.Version = existing.Version
 	c.CreatedAt = existing.CreatedAt
-	c.UpdatedAt = time.Now()
+	if err := writeCompany(tx, c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	data, err := json.Marshal(c)
+// errUnchanged apply сообщает modify, что сохранять нечего.
+var errUnchanged = errors.New("company unchanged")
+
+// modify изменяет компанию функцией apply в транзакции с блокировкой
+// строки, так что параллельные изменения не теряются и не дают
+// ложного конфликта версий.
+func (s *PostgresStore) modify(id string, apply func(*Company) error) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to marshal company: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	const updateQuery = `UPDATE companies SET name = $2, updated_at = $3, data = $4::jsonb WHERE id = $1;`
-	res, err := s.db.Exec(updateQuery, c.ID, c.Name, c.UpdatedAt, data)
+	c, err := lockCompany(tx, id)
 	if err != nil {
-		return fmt.Errorf("failed to update company: %w", err)
+		return err
+	}
+	if err := apply(&c); err != nil {
+		if errors.Is(err, errUnchanged) {
+			return nil
+		}
+		return err
 	}
+	if err := writeCompany(tx, c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	affected, err := res.RowsAffected()
-	if err == nil && affected == 0 {
-		return ErrNotFound
+// lockCompany читает компанию с блокировкой строки до конца транзакции.
+func lockCompany(tx *sql.Tx, id string) (Company, error) {
+	const lockQuery = `SELECT data, version FROM companies WHERE id = $1 FOR UPDATE;`
+	var raw []byte
+	var version int
+	if err := tx.QueryRow(lockQuery, id).Scan(&raw, &version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Company{}, ErrNotFound
+		}
+		return Company{}, fmt.Errorf("failed to get company: %w", err)
 	}
+	var c Company
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Company{}, fmt.Errorf("failed to unmarshal company: %w", err)
+	}
+	c.Version = version
+	return c, nil
+}
 
+// writeCompany сохраняет компанию, заблокированную lockCompany, со
+// следующей версией.
+func writeCompany(tx *sql.Tx, c Company) error {
+	c.Version++
+	c.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal company: %w", err)
+	}
+
+	const updateQuery = `UPDATE companies SET name = $2, updated_at = $3, data = $4::jsonb, version = $5 WHERE id = $1;`
+	if _, err := tx.Exec(updateQuery, c.ID, c.Name, c.UpdatedAt, data, c.Version); err != nil {
+		return fmt.Errorf("failed to update company: %w", err)
+	}
 	return nil
 }
 
-func (s *PostgresStore) Delete(id string) error {
-	const deleteQuery = `DELETE FROM companies WHERE id = $1;`
+func (s *PostgresStore) Delete(id string, version int) error {
+	const deleteQuery = `DELETE FROM companies WHERE id = $1 AND ($2 = 0 OR version = $2);`
 
-	res, err := s.db.Exec(deleteQuery, id)
+	res, err := s.db.Exec(deleteQuery, id, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete company: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
 	if err == nil && affected == 0 {
-		return ErrNotFound
+		// Отличаем отсутствующую компанию от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
 	}
 
 	return nil
 }
 
 func (s *PostgresStore) AddCharacter(companyID string, char characters.CharacterSheet) error {
-	c, err := s.Get(companyID)
-	if err != nil {
-		return err
-	}
-
-	for _, existing := range c.Characters {
-		if existing.ID == char.ID {
-			return nil
+	return s.modify(companyID, func(c *Company) error {
+		for _, existing := range c.Characters {
+			if existing.ID == char.ID {
+				return errUnchanged
+			}
 		}
-	}
-
-	c.Characters = append(c.Characters, char)
-	c.UpdatedAt = time.Now()
-
-	return s.Update(c)
+		c.Characters = append(c.Characters, char)
+		return nil
+	})
 }
 
 func (s *PostgresStore) RemoveCharacter(companyID, characterID string) error {
-	c, err := s.Get(companyID)
-	if err != nil {
-		return err
-	}
-
-	for i, ch := range c.Characters {
-		if ch.ID == characterID {
-			c.Characters = append(c.Characters[:i], c.Characters[i+1:]...)
-			c.UpdatedAt = time.Now()
-			return s.Update(c)
+	return s.modify(companyID, func(c *Company) error {
+		for i, ch := range c.Characters {
+			if ch.ID == characterID {
+				c.Characters = append(c.Characters[:i], c.Characters[i+1:]...)
+				return nil
+			}
 		}
-	}
-
-	return nil
+		return errUnchanged
+	})
 }
 
 func (s *PostgresStore) AddMonster(companyID string, mon monsters.Monster) error {
-	c, err := s.Get(companyID)
-	if err != nil {
-		return err
-	}
-
-	for _, existing := range c.Monsters {
-		if existing.ID == mon.ID {
-			return nil
+	return s.modify(companyID, func(c *Company) error {
+		if hasMonster(c.Monsters, mon.ID) {
+			return errUnchanged
 		}
-	}
-
-	c.Monsters = append(c.Monsters, mon)
-	c.UpdatedAt = time.Now()
+		c.Monsters = append(c.Monsters, mon)
+		return nil
+	})
+}
 
-	return s.Update(c)
+func (s *PostgresStore) UpdateMonster(companyID string, mon monsters.Monster) error {
+	return s.modify(companyID, func(c *Company) error {
+		for i, m := range c.Monsters {
+			if m.ID == mon.ID {
+				c.Monsters[i] = mon
+				return nil
+			}
+		}
+		return ErrMonsterNotInCompany
+	})
 }
 
 func (s *PostgresStore) RemoveMonster(companyID, monsterID string) error {
-	c, err := s.Get(companyID)
-	if err != nil {
-		return err
-	}
-
-	for i, m := range c.Monsters {
-		if m.ID == monsterID {
-			c.Monsters = append(c.Monsters[:i], c.Monsters[i+1:]...)
-			c.UpdatedAt = time.Now()
-			return s.Update(c)
+	return s.modify(companyID, func(c *Company) error {
+		for i, m := range c.Monsters {
+			if m.ID == monsterID {
+				c.Monsters = append(c.Monsters[:i], c.Monsters[i+1:]...)
+				c.Instances = withoutMonsterInstances(c.Instances, monsterID)
+				return nil
+			}
 		}
-	}
+		return errUnchanged
+	})
+}
 
-	return nil
+func (s *PostgresStore) AddMonsterInstances(companyID string, mon monsters.Monster, instances []monsters.Instance) error {
+	return s.modify(companyID, func(c *Company) error {
+		if !hasMonster(c.Monsters, mon.ID) {
+			c.Monsters = append(c.Monsters, mon)
+		}
+		c.Instances = append(c.Instances, instances...)
+		return nil
+	})
 }
 
+func (s *PostgresStore) UpdateMonsterInstance(companyID, instanceID string, apply func(*monsters.Instance) error) (monsters.Instance, error) {
+	var updated monsters.Instance
+	err := s.modify(companyID, func(c *Company) error {
+		for i, existing := range c.Instances {
+			if existing.ID == instanceID {
+				inst := existing.Clone()
+				if err := apply(&inst); err != nil {
+					return err
+				}
+				inst.ID = instanceID
+				if err := inst.Validate(); err != nil {
+					return err
+				}
+				c.Instances[i] = inst
+				updated = inst
+				return nil
+			}
+		}
+		return ErrInstanceNotFound
+	})
+	return updated, err
+}
 
+func (s *PostgresStore) RemoveMonsterInstance(companyID, instanceID string) error {
+	return s.modify(companyID, func(c *Company) error {
+		for i, inst := range c.Instances {
+			if inst.ID == instanceID {
+				c.Instances = append(c.Instances[:i], c.Instances[i+1:]...)
+				return nil
+			}
+		}
+		return errUnchanged
+	})
+}
@@ -0,0 +1,233 @@
+package conditions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Состояния из SRD.
+const (
+	Blinded       = "blinded"
+	Charmed       = "charmed"
+	Deafened      = "deafened"
+	Exhaustion    = "exhaustion"
+	Frightened    = "frightened"
+	Grappled      = "grappled"
+	Incapacitated = "incapacitated"
+	Invisible     = "invisible"
+	Paralyzed     = "paralyzed"
+	Petrified     = "petrified"
+	Poisoned      = "poisoned"
+	Prone         = "prone"
+	Restrained    = "restrained"
+	Stunned       = "stunned"
+	Unconscious   = "unconscious"
+)
+
+// Единицы длительности состояния.
+const (
+	DurationIndefinite = ""           // пока не снимут вручную
+	DurationRounds     = "rounds"     // заданное число раундов
+	DurationMinutes    = "minutes"    // заданное число минут (1 минута = 10 раундов)
+	DurationUntilSave  = "until_save" // пока существо не преуспеет в спасброске
+)
+
+// MaxExhaustion максимальный уровень истощения (смерть).
+const MaxExhaustion = 6
+
+const roundsPerMinute = 10
+
+// srdNames известные состояния SRD с русскими синонимами.
+var srdNames = map[string]string{
+	Blinded:         Blinded,
+	"ослеплён":      Blinded,
+	Charmed:         Charmed,
+	"очарован":      Charmed,
+	Deafened:        Deafened,
+	"оглушён":       Deafened,
+	Exhaustion:      Exhaustion,
+	"истощение":     Exhaustion,
+	Frightened:      Frightened,
+	"испуган":       Frightened,
+	Grappled:        Grappled,
+	"схвачен":       Grappled,
+	Incapacitated:   Incapacitated,
+	"недееспособен": Incapacitated,
+	Invisible:       Invisible,
+	"невидим":       Invisible,
+	Paralyzed:       Paralyzed,
+	"парализован":   Paralyzed,
+	Petrified:       Petrified,
+	"окаменел":      Petrified,
+	Poisoned:        Poisoned,
+	"отравлен":      Poisoned,
+	Prone:           Prone,
+	"сбит с ног":    Prone,
+	Restrained:      Restrained,
+	"опутан":        Restrained,
+	Stunned:         Stunned,
+	"ошеломлён":     Stunned,
+	Unconscious:     Unconscious,
+	"без сознания":  Unconscious,
+}
+
+// Duration описывает, когда состояние заканчивается.
+type Duration struct {
+	Unit          string `json:"unit,omitempty"`          // rounds, minutes, until_save или пусто (бессрочно)
+	Amount        int    `json:"amount,omitempty"`        // количество раундов или минут
+	ElapsedRounds int    `json:"elapsedRounds,omitempty"` // сколько раундов уже прошло
+	SaveAbility   string `json:"saveAbility,omitempty"`   // характеристика спасброска для until_save, например "CON"
+	SaveDC        int    `json:"saveDC,omitempty"`
+}
+
+// Condition состояние, наложенное на персонажа или монстра.
+type Condition struct {
+	Name        string   `json:"name"`
+	Custom      bool     `json:"custom,omitempty"` // пользовательское состояние без механики SRD
+	Description string   `json:"description,omitempty"`
+	Level       int      `json:"level,omitempty"`  // уровень истощения (1-6)
+	Source      string   `json:"source,omitempty"` // например: "Ядовитый укус паука"
+	Duration    Duration `json:"duration"`
+}
+
+// IsSRD сообщает, является ли имя (английское или русское) состоянием SRD.
+func IsSRD(name string) bool {
+	_, ok := srdNames[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// Normalize приводит имя состояния SRD к каноническому английскому виду.
+// Пользовательские имена возвращаются без изменений (без лишних пробелов).
+func Normalize(name string) string {
+	trimmed := strings.TrimSpace(name)
+	if canonical, ok := srdNames[strings.ToLower(trimmed)]; ok {
+		return canonical
+	}
+	return trimmed
+}
+
+func (c Condition) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("condition name is required")
+	}
+	if !c.Custom && !IsSRD(c.Name) {
+		return fmt.Errorf("unknown condition %q (set custom to true for homebrew conditions)", c.Name)
+	}
+	if Normalize(c.Name) == Exhaustion && (c.Level < 1 || c.Level > MaxExhaustion) {
+		return fmt.Errorf("exhaustion level must be between 1 and %d", MaxExhaustion)
+	}
+	switch c.Duration.Unit {
+	case DurationIndefinite:
+	case DurationRounds, DurationMinutes:
+		if c.Duration.Amount < 1 {
+			return errors.New("duration amount must be at least 1")
+		}
+	case DurationUntilSave:
+		if c.Duration.SaveDC < 1 {
+			return errors.New("save DC is required for until_save duration")
+		}
+	default:
+		return fmt.Errorf("unknown duration unit %q", c.Duration.Unit)
+	}
+	return nil
+}
+
+// Apply добавляет состояние в список. Повторное наложение того же состояния
+// заменяет предыдущее, а уровни истощения складываются (не выше 6).
+func Apply(list []Condition, c Condition) ([]Condition, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if !c.Custom {
+		c.Name = Normalize(c.Name)
+	}
+	c.Duration.ElapsedRounds = 0
+
+	result := make([]Condition, 0, len(list)+1)
+	for _, existing := range list {
+		if strings.EqualFold(existing.Name, c.Name) {
+			if c.Name == Exhaustion {
+				c.Level += existing.Level
+				if c.Level > MaxExhaustion {
+					c.Level = MaxExhaustion
+				}
+			}
+			continue
+		}
+		result = append(result, existing)
+	}
+	return append(result, c), nil
+}
+
+// Remove снимает состояние по имени. Второе значение сообщает, было ли оно найдено.
+func Remove(list []Condition, name string) ([]Condition, bool) {
+	name = Normalize(name)
+	result := make([]Condition, 0, len(list))
+	found := false
+	for _, existing := range list {
+		if strings.EqualFold(existing.Name, name) {
+			found = true
+			continue
+		}
+		result = append(result, existing)
+	}
+	return result, found
+}
+
+// Tick отсчитывает прошедшие раунды и возвращает оставшиеся и истёкшие состояния.
+// Состояния "до спасброска" и бессрочные по времени не истекают.
+func Tick(list []Condition, rounds int) (remaining, expired []Condition) {
+	remaining = make([]Condition, 0, len(list))
+	for _, c := range list {
+		limit := 0
+		switch c.Duration.Unit {
+		case DurationRounds:
+			limit = c.Duration.Amount
+		case DurationMinutes:
+			limit = c.Duration.Amount * roundsPerMinute
+		}
+		if limit == 0 {
+			remaining = append(remaining, c)
+			continue
+		}
+		c.Duration.ElapsedRounds += rounds
+		if c.Duration.ElapsedRounds >= limit {
+			expired = append(expired, c)
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	return remaining, expired
+}
+
+// ResolveSave применяет результат спасброска против состояния "до спасброска":
+// при total >= DC состояние снимается. Второе значение сообщает, закончилось ли оно.
+func ResolveSave(list []Condition, name string, total int) ([]Condition, bool, error) {
+	name = Normalize(name)
+	for _, c := range list {
+		if !strings.EqualFold(c.Name, name) {
+			continue
+		}
+		if c.Duration.Unit != DurationUntilSave {
+			return nil, false, fmt.Errorf("condition %q does not end on a save", c.Name)
+		}
+		if total < c.Duration.SaveDC {
+			return list, false, nil
+		}
+		remaining, _ := Remove(list, name)
+		return remaining, true, nil
+	}
+	return nil, false, fmt.Errorf("condition %q is not applied", name)
+}
+
+// Has сообщает, наложено ли состояние.
+func Has(list []Condition, name string) bool {
+	name = Normalize(name)
+	for _, c := range list {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
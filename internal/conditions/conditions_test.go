@@ -0,0 +1,95 @@
+package conditions
+
+import "testing"
+
+func TestApplyStacksExhaustion(t *testing.T) {
+	t.Parallel()
+
+	list, err := Apply(nil, Condition{Name: "истощение", Level: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, err = Apply(list, Condition{Name: Exhaustion, Level: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("expected a single exhaustion entry, got %d", len(list))
+	}
+	if list[0].Level != MaxExhaustion {
+		t.Fatalf("expected exhaustion capped at %d, got %d", MaxExhaustion, list[0].Level)
+	}
+	if !Summarize(list).Dead {
+		t.Fatalf("expected exhaustion 6 to be fatal")
+	}
+}
+
+func TestApplyValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []Condition{
+		{Name: ""},
+		{Name: "cursed"},
+		{Name: Exhaustion},
+		{Name: Poisoned, Duration: Duration{Unit: DurationRounds}},
+		{Name: Poisoned, Duration: Duration{Unit: DurationUntilSave}},
+		{Name: Poisoned, Duration: Duration{Unit: "hours", Amount: 1}},
+	}
+
+	for _, c := range tests {
+		if _, err := Apply(nil, c); err == nil {
+			t.Fatalf("expected error for %+v", c)
+		}
+	}
+
+	if _, err := Apply(nil, Condition{Name: "cursed", Custom: true}); err != nil {
+		t.Fatalf("custom condition rejected: %v", err)
+	}
+}
+
+func TestTickExpiresByRoundsAndMinutes(t *testing.T) {
+	t.Parallel()
+
+	list := []Condition{
+		{Name: Poisoned, Duration: Duration{Unit: DurationRounds, Amount: 1}},
+		{Name: Charmed, Duration: Duration{Unit: DurationMinutes, Amount: 1}},
+		{Name: Frightened, Duration: Duration{Unit: DurationUntilSave, SaveDC: 13}},
+	}
+
+	remaining, expired := Tick(list, 1)
+	if len(expired) != 1 || expired[0].Name != Poisoned {
+		t.Fatalf("expected poisoned to expire, got %+v", expired)
+	}
+
+	remaining, expired = Tick(remaining, 9)
+	if len(expired) != 1 || expired[0].Name != Charmed {
+		t.Fatalf("expected charmed to expire after 10 rounds, got %+v", expired)
+	}
+	if len(remaining) != 1 || remaining[0].Name != Frightened {
+		t.Fatalf("expected only frightened to remain, got %+v", remaining)
+	}
+
+	remaining, ended, err := ResolveSave(remaining, Frightened, 12)
+	if err != nil || ended {
+		t.Fatalf("failed save must not end the condition (ended=%v, err=%v)", ended, err)
+	}
+	remaining, ended, err = ResolveSave(remaining, Frightened, 13)
+	if err != nil || !ended || len(remaining) != 0 {
+		t.Fatalf("successful save must end the condition (ended=%v, err=%v)", ended, err)
+	}
+}
+
+func TestSummarizeSpeed(t *testing.T) {
+	t.Parallel()
+
+	if got := Summarize([]Condition{{Name: Grappled}}).Speed(30); got != 0 {
+		t.Fatalf("grappled speed = %d, want 0", got)
+	}
+	if got := Summarize([]Condition{{Name: Exhaustion, Level: 2}}).Speed(30); got != 15 {
+		t.Fatalf("exhaustion 2 speed = %d, want 15", got)
+	}
+	if got := Summarize([]Condition{{Name: "dazed", Custom: true}}).Speed(30); got != 30 {
+		t.Fatalf("custom condition speed = %d, want 30", got)
+	}
+}
@@ -0,0 +1,116 @@
+package conditions
+
+// Effects сводные механические эффекты набора состояний.
+type Effects struct {
+	ExhaustionLevel          int  `json:"exhaustionLevel"`
+	Incapacitated            bool `json:"incapacitated"`            // не может совершать действия и реакции
+	SpeedZero                bool `json:"speedZero"`                // скорость 0 (схвачен, опутан, ...)
+	SpeedHalved              bool `json:"speedHalved"`              // истощение 2+
+	AttackAdvantage          bool `json:"attackAdvantage"`          // атаки существа с преимуществом
+	AttackDisadvantage       bool `json:"attackDisadvantage"`       // атаки существа с помехой
+	AttackedWithAdvantage    bool `json:"attackedWithAdvantage"`    // атаки по существу с преимуществом
+	AttackedWithDisadvantage bool `json:"attackedWithDisadvantage"` // атаки по существу с помехой
+	AbilityCheckDisadvantage bool `json:"abilityCheckDisadvantage"`
+	SaveDisadvantage         bool `json:"saveDisadvantage"`    // помеха на все спасброски
+	DexSaveDisadvantage      bool `json:"dexSaveDisadvantage"` // опутан
+	AutoFailStrDexSaves      bool `json:"autoFailStrDexSaves"`
+	MeleeHitsAreCritical     bool `json:"meleeHitsAreCritical"` // попадания в пределах 5 фт. - критические
+	ResistAllDamage          bool `json:"resistAllDamage"`      // окаменение
+	MaxHitPointsHalved       bool `json:"maxHitPointsHalved"`   // истощение 4+
+	Dead                     bool `json:"dead"`                 // истощение 6
+}
+
+// Summarize вычисляет механические эффекты состояний SRD.
+// Пользовательские состояния механики не имеют.
+func Summarize(list []Condition) Effects {
+	var e Effects
+	for _, c := range list {
+		if c.Custom {
+			continue
+		}
+		switch Normalize(c.Name) {
+		case Blinded:
+			e.AttackDisadvantage = true
+			e.AttackedWithAdvantage = true
+		case Exhaustion:
+			if c.Level > e.ExhaustionLevel {
+				e.ExhaustionLevel = c.Level
+			}
+		case Frightened, Poisoned:
+			e.AttackDisadvantage = true
+			e.AbilityCheckDisadvantage = true
+		case Grappled:
+			e.SpeedZero = true
+		case Incapacitated:
+			e.Incapacitated = true
+		case Invisible:
+			e.AttackAdvantage = true
+			e.AttackedWithDisadvantage = true
+		case Paralyzed, Unconscious:
+			e.Incapacitated = true
+			e.SpeedZero = true
+			e.AutoFailStrDexSaves = true
+			e.AttackedWithAdvantage = true
+			e.MeleeHitsAreCritical = true
+		case Petrified:
+			e.Incapacitated = true
+			e.SpeedZero = true
+			e.AutoFailStrDexSaves = true
+			e.AttackedWithAdvantage = true
+			e.ResistAllDamage = true
+		case Prone:
+			e.AttackDisadvantage = true
+		case Restrained:
+			e.SpeedZero = true
+			e.AttackDisadvantage = true
+			e.AttackedWithAdvantage = true
+			e.DexSaveDisadvantage = true
+		case Stunned:
+			e.Incapacitated = true
+			e.SpeedZero = true
+			e.AutoFailStrDexSaves = true
+			e.AttackedWithAdvantage = true
+		}
+	}
+
+	// Уровни истощения накапливаются
+	if e.ExhaustionLevel >= 1 {
+		e.AbilityCheckDisadvantage = true
+	}
+	if e.ExhaustionLevel >= 2 {
+		e.SpeedHalved = true
+	}
+	if e.ExhaustionLevel >= 3 {
+		e.AttackDisadvantage = true
+		e.SaveDisadvantage = true
+	}
+	if e.ExhaustionLevel >= 4 {
+		e.MaxHitPointsHalved = true
+	}
+	if e.ExhaustionLevel >= 5 {
+		e.SpeedZero = true
+	}
+	if e.ExhaustionLevel >= MaxExhaustion {
+		e.Dead = true
+	}
+	return e
+}
+
+// Speed применяет эффекты к базовой скорости.
+func (e Effects) Speed(base int) int {
+	if e.SpeedZero {
+		return 0
+	}
+	if e.SpeedHalved {
+		return base / 2
+	}
+	return base
+}
+
+// MaxHitPoints применяет эффекты к максимуму хитов.
+func (e Effects) MaxHitPoints(base int) int {
+	if e.MaxHitPointsHalved {
+		return base / 2
+	}
+	return base
+}
@@ -192,3 +192,112 @@ func rollDie(sides int) (int, error) {
 	}
 	return int(n.Int64()) + 1, nil
 }
+
+// Roll modes for a d20 roll.
+const (
+	ModeNormal       = "normal"
+	ModeAdvantage    = "advantage"
+	ModeDisadvantage = "disadvantage"
+)
+
+// D20Result contains the output of a d20 roll with advantage or disadvantage.
+type D20Result struct {
+	Mode    string `json:"mode"`
+	Rolls   []int  `json:"rolls"`
+	Natural int    `json:"natural"`
+}
+
+// CombineModes resolves several advantage sources: advantage and disadvantage cancel each other out.
+func CombineModes(modes ...string) string {
+	advantage, disadvantage := false, false
+	for _, mode := range modes {
+		switch mode {
+		case ModeAdvantage:
+			advantage = true
+		case ModeDisadvantage:
+			disadvantage = true
+		}
+	}
+	switch {
+	case advantage && !disadvantage:
+		return ModeAdvantage
+	case disadvantage && !advantage:
+		return ModeDisadvantage
+	}
+	return ModeNormal
+}
+
+// RollD20 rolls a d20, rolling twice and keeping the higher (advantage) or lower (disadvantage) die.
+func RollD20(mode string) (D20Result, error) {
+	switch mode {
+	case "", ModeNormal:
+		mode = ModeNormal
+	case ModeAdvantage, ModeDisadvantage:
+	default:
+		return D20Result{}, fmt.Errorf("unknown roll mode %q", mode)
+	}
+
+	count := 1
+	if mode != ModeNormal {
+		count = 2
+	}
+
+	result := D20Result{Mode: mode}
+	for i := 0; i < count; i++ {
+		value, err := rollDie(20)
+		if err != nil {
+			return D20Result{}, err
+		}
+		result.Rolls = append(result.Rolls, value)
+	}
+
+	result.Natural = result.Rolls[0]
+	for _, value := range result.Rolls[1:] {
+		if (mode == ModeAdvantage && value > result.Natural) || (mode == ModeDisadvantage && value < result.Natural) {
+			result.Natural = value
+		}
+	}
+	return result, nil
+}
+
+// DoubleDice returns a copy of the expression with every dice count doubled,
+// as used for critical hits. The constant modifier is left unchanged.
+func (e Expression) DoubleDice() Expression {
+	doubled := Expression{Modifier: e.Modifier}
+	for _, term := range e.Dice {
+		term.Count *= 2
+		doubled.Dice = append(doubled.Dice, term)
+	}
+	return doubled
+}
+
+// Average returns the average result of the expression rounded down,
+// as printed in stat blocks ("2d10+8" averages 19).
+func (e Expression) Average() int {
+	total := 2 * e.Modifier
+	for _, term := range e.Dice {
+		total += term.Sign * term.Count * (term.Sides + 1)
+	}
+	return total / 2
+}
+
+// String formats the expression in canonical form, e.g. "2d10+8" or "1d8-1d4".
+func (e Expression) String() string {
+	var b strings.Builder
+	for i, term := range e.Dice {
+		switch {
+		case term.Sign < 0:
+			b.WriteByte('-')
+		case i > 0:
+			b.WriteByte('+')
+		}
+		fmt.Fprintf(&b, "%dd%d", term.Count, term.Sides)
+	}
+	switch {
+	case e.Modifier > 0 && b.Len() > 0:
+		fmt.Fprintf(&b, "+%d", e.Modifier)
+	case e.Modifier != 0 || b.Len() == 0:
+		fmt.Fprintf(&b, "%d", e.Modifier)
+	}
+	return b.String()
+}
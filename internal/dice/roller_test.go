@@ -192,3 +192,93 @@ func TestRollSingleSideDie(t *testing.T) {
 		t.Fatalf("expected total %d, got %d", expectedTotal, result.Total)
 	}
 }
+
+func TestRollD20(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []string{"", ModeNormal, ModeAdvantage, ModeDisadvantage} {
+		result, err := RollD20(mode)
+		if err != nil {
+			t.Fatalf("unexpected error for mode %q: %v", mode, err)
+		}
+		for _, roll := range result.Rolls {
+			if roll < 1 || roll > 20 {
+				t.Fatalf("d20 roll out of range: %d", roll)
+			}
+		}
+		switch result.Mode {
+		case ModeNormal:
+			if len(result.Rolls) != 1 || result.Natural != result.Rolls[0] {
+				t.Fatalf("unexpected normal roll: %+v", result)
+			}
+		case ModeAdvantage:
+			if len(result.Rolls) != 2 || result.Natural != max(result.Rolls[0], result.Rolls[1]) {
+				t.Fatalf("unexpected advantage roll: %+v", result)
+			}
+		case ModeDisadvantage:
+			if len(result.Rolls) != 2 || result.Natural != min(result.Rolls[0], result.Rolls[1]) {
+				t.Fatalf("unexpected disadvantage roll: %+v", result)
+			}
+		}
+	}
+
+	if _, err := RollD20("lucky"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
+
+func TestCombineModes(t *testing.T) {
+	t.Parallel()
+
+	if got := CombineModes(ModeAdvantage, ModeNormal); got != ModeAdvantage {
+		t.Fatalf("got %q, want advantage", got)
+	}
+	if got := CombineModes(ModeAdvantage, ModeDisadvantage, ModeAdvantage); got != ModeNormal {
+		t.Fatalf("got %q, want normal", got)
+	}
+}
+
+func TestDoubleDice(t *testing.T) {
+	t.Parallel()
+
+	expr := Expression{Dice: []DiceTerm{{Count: 2, Sides: 6, Sign: 1}}, Modifier: 3}
+	doubled := expr.DoubleDice()
+
+	want := Expression{Dice: []DiceTerm{{Count: 4, Sides: 6, Sign: 1}}, Modifier: 3}
+	if !reflect.DeepEqual(doubled, want) {
+		t.Fatalf("unexpected result: %+v, want %+v", doubled, want)
+	}
+	if expr.Dice[0].Count != 2 {
+		t.Fatalf("original expression was modified")
+	}
+}
+
+func TestExpressionStringAndAverage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		input   string
+		want    string
+		average int
+	}{
+		{"2d10 + 8", "2d10+8", 19},
+		{"18d6", "18d6", 63},
+		{"d8-d4-1", "1d8-1d4-1", 1},
+		{"1d4-1", "1d4-1", 1},
+	}
+	for _, tc := range cases {
+		expr, err := ParseExpression(tc.input)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.input, err)
+		}
+		if got := expr.String(); got != tc.want {
+			t.Errorf("String(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+		if got := expr.Average(); got != tc.average {
+			t.Errorf("Average(%q) = %d, want %d", tc.input, got, tc.average)
+		}
+	}
+	if got := (Expression{Modifier: 5}).String(); got != "5" {
+		t.Errorf("constant expression = %q", got)
+	}
+}
@@ -0,0 +1,186 @@
+package encounters
+
+import (
+	"errors"
+	"fmt"
+
+	"dice-service/internal/monsters"
+)
+
+// Сложность столкновения.
+const (
+	DifficultyTrivial = "trivial"
+	DifficultyEasy    = "easy"
+	DifficultyMedium  = "medium"
+	DifficultyHard    = "hard"
+	DifficultyDeadly  = "deadly"
+)
+
+// Thresholds пороги опыта для сложностей столкновения.
+type Thresholds struct {
+	Easy   int `json:"easy"`
+	Medium int `json:"medium"`
+	Hard   int `json:"hard"`
+	Deadly int `json:"deadly"`
+}
+
+// levelThresholds пороги опыта на одного персонажа по уровню (DMG).
+var levelThresholds = [21]Thresholds{
+	1:  {25, 50, 75, 100},
+	2:  {50, 100, 150, 200},
+	3:  {75, 150, 225, 400},
+	4:  {125, 250, 375, 500},
+	5:  {250, 500, 750, 1100},
+	6:  {300, 600, 900, 1400},
+	7:  {350, 750, 1100, 1700},
+	8:  {450, 900, 1400, 2100},
+	9:  {550, 1100, 1600, 2400},
+	10: {600, 1200, 1900, 2800},
+	11: {800, 1600, 2400, 3600},
+	12: {1000, 2000, 3000, 4500},
+	13: {1100, 2200, 3400, 5100},
+	14: {1250, 2500, 3800, 5700},
+	15: {1400, 2800, 4300, 6400},
+	16: {1600, 3200, 4800, 7200},
+	17: {2000, 3900, 5900, 8800},
+	18: {2100, 4200, 6300, 9500},
+	19: {2400, 4900, 7300, 10900},
+	20: {2800, 5700, 8500, 12700},
+}
+
+// multipliers шкала множителей опыта за число монстров; размер отряда
+// сдвигает позицию на шкале.
+var multipliers = []float64{0.5, 1, 1.5, 2, 2.5, 3, 4, 5}
+
+// LevelThresholds пороги опыта персонажа уровня level.
+func LevelThresholds(level int) (Thresholds, error) {
+	if level < 1 || level >= len(levelThresholds) {
+		return Thresholds{}, fmt.Errorf("character level %d is out of range 1-20", level)
+	}
+	return levelThresholds[level], nil
+}
+
+// Multiplier множитель опыта для числа монстров и размера отряда: отряд
+// меньше трёх персонажей берёт следующий множитель, шесть и больше - предыдущий.
+func Multiplier(monsterCount, partySize int) float64 {
+	var index int
+	switch {
+	case monsterCount <= 1:
+		index = 1
+	case monsterCount == 2:
+		index = 2
+	case monsterCount <= 6:
+		index = 3
+	case monsterCount <= 10:
+		index = 4
+	case monsterCount <= 14:
+		index = 5
+	default:
+		index = 6
+	}
+	switch {
+	case partySize < 3:
+		index++
+	case partySize >= 6:
+		index--
+	}
+	return multipliers[index]
+}
+
+// Combatant группа монстров с известным показателем опасности.
+type Combatant struct {
+	MonsterID       string                   `json:"monsterId"`
+	Name            string                   `json:"name"`
+	ChallengeRating monsters.ChallengeRating `json:"challengeRating"`
+	Count           int                      `json:"count"`
+	XP              int                      `json:"xp"`      // за одного монстра
+	TotalXP         int                      `json:"totalXp"` // за всю группу
+}
+
+// Evaluation оценка сложности столкновения.
+type Evaluation struct {
+	PartySize      int         `json:"partySize"`
+	PartyLevels    []int       `json:"partyLevels"`
+	Thresholds     Thresholds  `json:"thresholds"` // суммарные пороги отряда
+	Monsters       []Combatant `json:"monsters"`
+	MonsterCount   int         `json:"monsterCount"`
+	TotalXP        int         `json:"totalXp"`    // опыт, который получит отряд
+	Multiplier     float64     `json:"multiplier"` // множитель за число монстров
+	AdjustedXP     int         `json:"adjustedXp"` // опыт для сравнения с порогами
+	Difficulty     string      `json:"difficulty"`
+	XPPerCharacter int         `json:"xpPerCharacter"`
+}
+
+// Resolve находит монстров групп через lookup и заполняет их опыт.
+func Resolve(groups []Group, lookup func(id string) (monsters.Monster, error)) ([]Combatant, error) {
+	if err := ValidateGroups(groups); err != nil {
+		return nil, err
+	}
+	result := make([]Combatant, 0, len(groups))
+	for _, g := range groups {
+		m, err := lookup(g.MonsterID)
+		if err != nil {
+			return nil, fmt.Errorf("monster %s: %w", g.MonsterID, err)
+		}
+		result = append(result, Combatant{
+			MonsterID:       g.MonsterID,
+			Name:            m.Name,
+			ChallengeRating: m.ChallengeRating,
+			Count:           g.Count,
+		})
+	}
+	return result, nil
+}
+
+// Evaluate оценивает столкновение отряда с уровнями levels против монстров:
+// суммарный опыт умножается на множитель за число монстров и сравнивается
+// с порогами сложности отряда.
+func Evaluate(levels []int, combatants []Combatant) (Evaluation, error) {
+	if len(levels) == 0 {
+		return Evaluation{}, errors.New("party has no characters")
+	}
+	eval := Evaluation{
+		PartySize:   len(levels),
+		PartyLevels: append([]int(nil), levels...),
+		Monsters:    make([]Combatant, 0, len(combatants)),
+	}
+	for _, level := range levels {
+		t, err := LevelThresholds(level)
+		if err != nil {
+			return Evaluation{}, err
+		}
+		eval.Thresholds.Easy += t.Easy
+		eval.Thresholds.Medium += t.Medium
+		eval.Thresholds.Hard += t.Hard
+		eval.Thresholds.Deadly += t.Deadly
+	}
+
+	for _, c := range combatants {
+		if c.Count < 1 {
+			return Evaluation{}, fmt.Errorf("monster %s: count must be at least 1", c.Name)
+		}
+		c.XP = c.ChallengeRating.XP()
+		c.TotalXP = c.XP * c.Count
+		eval.Monsters = append(eval.Monsters, c)
+		eval.MonsterCount += c.Count
+		eval.TotalXP += c.TotalXP
+	}
+
+	eval.Multiplier = Multiplier(eval.MonsterCount, eval.PartySize)
+	eval.AdjustedXP = int(float64(eval.TotalXP) * eval.Multiplier)
+	eval.XPPerCharacter = eval.TotalXP / eval.PartySize
+
+	switch {
+	case eval.AdjustedXP >= eval.Thresholds.Deadly:
+		eval.Difficulty = DifficultyDeadly
+	case eval.AdjustedXP >= eval.Thresholds.Hard:
+		eval.Difficulty = DifficultyHard
+	case eval.AdjustedXP >= eval.Thresholds.Medium:
+		eval.Difficulty = DifficultyMedium
+	case eval.AdjustedXP >= eval.Thresholds.Easy:
+		eval.Difficulty = DifficultyEasy
+	default:
+		eval.Difficulty = DifficultyTrivial
+	}
+	return eval, nil
+}
@@ -0,0 +1,117 @@
+package encounters
+
+import (
+	"errors"
+	"testing"
+
+	"dice-service/internal/monsters"
+)
+
+func TestMultiplier(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		monsters, party int
+		want            float64
+	}{
+		{1, 4, 1}, {2, 4, 1.5}, {3, 4, 2}, {6, 4, 2}, {7, 4, 2.5}, {11, 4, 3}, {15, 4, 4},
+		{1, 2, 1.5}, {15, 1, 5}, {1, 6, 0.5}, {4, 7, 1.5},
+	}
+	for _, tc := range cases {
+		if got := Multiplier(tc.monsters, tc.party); got != tc.want {
+			t.Errorf("Multiplier(%d, %d) = %v, want %v", tc.monsters, tc.party, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	// Пример из DMG: отряд из четырёх персонажей 3-го уровня
+	// против бугбира (CR 1) и трёх хобгоблинов (CR 1/2)
+	levels := []int{3, 3, 3, 3}
+	combatants := []Combatant{
+		{Name: "Bugbear", ChallengeRating: 1, Count: 1},
+		{Name: "Hobgoblin", ChallengeRating: 0.5, Count: 3},
+	}
+	eval, err := Evaluate(levels, combatants)
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	if eval.Thresholds != (Thresholds{Easy: 300, Medium: 600, Hard: 900, Deadly: 1600}) {
+		t.Fatalf("unexpected thresholds: %+v", eval.Thresholds)
+	}
+	if eval.TotalXP != 500 || eval.MonsterCount != 4 || eval.Multiplier != 2 || eval.AdjustedXP != 1000 {
+		t.Fatalf("unexpected XP: %+v", eval)
+	}
+	if eval.Difficulty != DifficultyHard || eval.XPPerCharacter != 125 {
+		t.Fatalf("unexpected difficulty %q, %d XP each", eval.Difficulty, eval.XPPerCharacter)
+	}
+	if eval.Monsters[1].XP != 100 || eval.Monsters[1].TotalXP != 300 {
+		t.Fatalf("unexpected combatant XP: %+v", eval.Monsters[1])
+	}
+
+	eval, err = Evaluate([]int{1}, []Combatant{{Name: "Rat", ChallengeRating: 0, Count: 1}})
+	if err != nil || eval.Difficulty != DifficultyTrivial {
+		t.Fatalf("expected trivial encounter, got %q, %v", eval.Difficulty, err)
+	}
+
+	if _, err := Evaluate(nil, combatants); err == nil {
+		t.Fatal("expected error for empty party")
+	}
+	if _, err := Evaluate([]int{21}, combatants); err == nil {
+		t.Fatal("expected error for level 21")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	lookup := func(id string) (monsters.Monster, error) {
+		if id == "ogre" {
+			return monsters.Monster{ID: "ogre", Name: "Ogre", ChallengeRating: 2}, nil
+		}
+		return monsters.Monster{}, monsters.ErrNotFound
+	}
+	combatants, err := Resolve([]Group{{MonsterID: "ogre", Count: 2}}, lookup)
+	if err != nil || len(combatants) != 1 || combatants[0].Name != "Ogre" || combatants[0].Count != 2 {
+		t.Fatalf("unexpected combatants: %+v, %v", combatants, err)
+	}
+	if _, err := Resolve([]Group{{MonsterID: "dragon", Count: 1}}, lookup); !errors.Is(err, monsters.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := Resolve([]Group{{MonsterID: "ogre", Count: 0}}, lookup); err == nil {
+		t.Fatal("expected error for zero count")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	created, err := store.Create(Encounter{CompanyID: "c1", Name: "Засада", Monsters: []Group{{MonsterID: "ogre", Count: 1}}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := store.Create(Encounter{CompanyID: "c2", Name: "Логово", Monsters: []Group{{MonsterID: "ogre", Count: 2}}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if list := store.List("c1"); len(list) != 1 || list[0].ID != created.ID {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	created.Name = "Засада у моста"
+	updated, err := store.Update(created)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("update: %+v, %v", updated, err)
+	}
+	if _, err := store.Update(created); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if err := store.Delete(created.ID, 2); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get(created.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+// Package encounters считает сложность столкновений по правилам DMG
+// и хранит именованные столкновения компании.
+package encounters
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("encounter not found")
+var ErrVersionConflict = errors.New("encounter was modified by another request")
+
+// Group группа одинаковых монстров в столкновении.
+type Group struct {
+	MonsterID string `json:"monsterId"`
+	Count     int    `json:"count"`
+}
+
+// Encounter именованное столкновение компании.
+type Encounter struct {
+	ID          string    `json:"id"`
+	Version     int       `json:"version"` // растёт при каждом сохранении; 0 в запросе - без проверки
+	CompanyID   string    `json:"companyId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Monsters    []Group   `json:"monsters"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (e Encounter) Validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return errors.New("encounter name is required")
+	}
+	if e.CompanyID == "" {
+		return errors.New("company id is required")
+	}
+	return ValidateGroups(e.Monsters)
+}
+
+// ValidateGroups проверяет список групп монстров.
+func ValidateGroups(groups []Group) error {
+	if len(groups) == 0 {
+		return errors.New("at least one monster is required")
+	}
+	for _, g := range groups {
+		if g.MonsterID == "" {
+			return errors.New("monsterId is required")
+		}
+		if g.Count < 1 {
+			return fmt.Errorf("monster %s: count must be at least 1", g.MonsterID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package encounters
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/monsters"
+)
+
+// ErrNoCandidates генератору не удалось собрать ни одного столкновения.
+var ErrNoCandidates = errors.New("no encounter fits the requested difficulty")
+
+const (
+	defaultCandidates  = 3
+	defaultMaxMonsters = 6
+	maxGeneratedKinds  = 3  // различных монстров в одном столкновении
+	attemptsPerResult  = 50 // попыток на один вариант
+)
+
+// GenerateOptions параметры генератора столкновений.
+type GenerateOptions struct {
+	Difficulty  string // целевая сложность
+	MaxMonsters int    // 0 - по умолчанию 6
+	Candidates  int    // число вариантов, 0 - по умолчанию 3
+	Seed        int64  // одинаковое зерно даёт одинаковые варианты
+}
+
+// Generate собирает случайные столкновения из монстров pool для отряда
+// с уровнями levels. Скорректированный опыт каждого варианта попадает
+// в диапазон целевой сложности: от её порога до порога следующей (для
+// смертельной - до полутора порогов). Варианты не повторяются; их может
+// оказаться меньше запрошенного, если пул мал.
+func Generate(levels []int, pool []monsters.Monster, opts GenerateOptions) ([]Evaluation, error) {
+	if opts.MaxMonsters == 0 {
+		opts.MaxMonsters = defaultMaxMonsters
+	}
+	if opts.Candidates == 0 {
+		opts.Candidates = defaultCandidates
+	}
+	if opts.MaxMonsters < 1 || opts.MaxMonsters > 20 {
+		return nil, errors.New("maxMonsters must be between 1 and 20")
+	}
+	if opts.Candidates < 1 || opts.Candidates > 10 {
+		return nil, errors.New("count must be between 1 and 10")
+	}
+
+	party, err := Evaluate(levels, nil)
+	if err != nil {
+		return nil, err
+	}
+	low, high, err := budget(party.Thresholds, opts.Difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	// Монстры, которые помещаются в бюджет хотя бы поодиночке;
+	// порядок фиксируется, чтобы зерно давало одинаковый результат.
+	var fitting []monsters.Monster
+	for _, m := range pool {
+		if m.ID != "" && adjustedXP(m.ChallengeRating.XP(), 1, len(levels)) < high {
+			fitting = append(fitting, m)
+		}
+	}
+	if len(fitting) == 0 {
+		return nil, ErrNoCandidates
+	}
+	slices.SortFunc(fitting, func(a, b monsters.Monster) int { return strings.Compare(a.ID, b.ID) })
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	seen := make(map[string]bool)
+	var result []Evaluation
+	for attempt := 0; attempt < opts.Candidates*attemptsPerResult && len(result) < opts.Candidates; attempt++ {
+		combatants, ok := compose(rng, fitting, low, high, opts.MaxMonsters, len(levels))
+		if !ok {
+			continue
+		}
+		key := compositionKey(combatants)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		eval, err := Evaluate(levels, combatants)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, eval)
+	}
+	if len(result) == 0 {
+		return nil, ErrNoCandidates
+	}
+	return result, nil
+}
+
+// budget диапазон скорректированного опыта [low, high) для сложности.
+func budget(t Thresholds, difficulty string) (int, int, error) {
+	switch difficulty {
+	case DifficultyTrivial:
+		return 1, t.Easy, nil
+	case DifficultyEasy:
+		return t.Easy, t.Medium, nil
+	case DifficultyMedium:
+		return t.Medium, t.Hard, nil
+	case DifficultyHard:
+		return t.Hard, t.Deadly, nil
+	case DifficultyDeadly:
+		return t.Deadly, t.Deadly * 3 / 2, nil
+	default:
+		return 0, 0, fmt.Errorf("unknown difficulty %q", difficulty)
+	}
+}
+
+// compose собирает одно столкновение: случайный ведущий монстр, к которому
+// добавляются сородичи или другие монстры, пока опыт не достигнет low,
+// не выходя за high и ограничение на число монстров.
+func compose(rng *rand.Rand, pool []monsters.Monster, low, high, maxMonsters, partySize int) ([]Combatant, bool) {
+	lead := pool[rng.Intn(len(pool))]
+	combatants := []Combatant{newCombatant(lead)}
+	count, xp := 1, lead.ChallengeRating.XP()
+
+	for adjustedXP(xp, count, partySize) < low {
+		if count >= maxMonsters {
+			return nil, false
+		}
+		// Варианты добавления: ещё один из уже выбранных или новый вид
+		var options []monsters.Monster
+		for _, c := range combatants {
+			if adjustedXP(xp+c.ChallengeRating.XP(), count+1, partySize) < high {
+				options = append(options, monsters.Monster{ID: c.MonsterID, ChallengeRating: c.ChallengeRating})
+			}
+		}
+		if len(combatants) < maxGeneratedKinds {
+			for _, m := range pool {
+				if !slices.ContainsFunc(combatants, func(c Combatant) bool { return c.MonsterID == m.ID }) &&
+					adjustedXP(xp+m.ChallengeRating.XP(), count+1, partySize) < high {
+					options = append(options, m)
+				}
+			}
+		}
+		if len(options) == 0 {
+			return nil, false
+		}
+
+		pick := options[rng.Intn(len(options))]
+		index := slices.IndexFunc(combatants, func(c Combatant) bool { return c.MonsterID == pick.ID })
+		if index >= 0 {
+			combatants[index].Count++
+		} else {
+			combatants = append(combatants, newCombatant(pick))
+		}
+		count++
+		xp += pick.ChallengeRating.XP()
+	}
+	return combatants, adjustedXP(xp, count, partySize) < high
+}
+
+func newCombatant(m monsters.Monster) Combatant {
+	return Combatant{MonsterID: m.ID, Name: m.Name, ChallengeRating: m.ChallengeRating, Count: 1}
+}
+
+func adjustedXP(totalXP, monsterCount, partySize int) int {
+	return int(float64(totalXP) * Multiplier(monsterCount, partySize))
+}
+
+// compositionKey одинаков для столкновений с одним составом.
+func compositionKey(combatants []Combatant) string {
+	parts := make([]string, 0, len(combatants))
+	for _, c := range combatants {
+		parts = append(parts, c.MonsterID+"x"+strconv.Itoa(c.Count))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,72 @@
+package encounters
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"dice-service/internal/monsters"
+)
+
+func generatorPool() []monsters.Monster {
+	return []monsters.Monster{
+		{ID: "goblin", Name: "Goblin", ChallengeRating: 0.25},
+		{ID: "wolf", Name: "Wolf", ChallengeRating: 0.25},
+		{ID: "hobgoblin", Name: "Hobgoblin", ChallengeRating: 0.5},
+		{ID: "bugbear", Name: "Bugbear", ChallengeRating: 1},
+		{ID: "ogre", Name: "Ogre", ChallengeRating: 2},
+		{ID: "troll", Name: "Troll", ChallengeRating: 5},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	levels := []int{3, 3, 3, 3}
+	for _, difficulty := range []string{DifficultyEasy, DifficultyMedium, DifficultyHard, DifficultyDeadly} {
+		opts := GenerateOptions{Difficulty: difficulty, MaxMonsters: 5, Candidates: 4, Seed: 42}
+		candidates, err := Generate(levels, generatorPool(), opts)
+		if err != nil {
+			t.Fatalf("%s: Generate error: %v", difficulty, err)
+		}
+		if len(candidates) == 0 || len(candidates) > 4 {
+			t.Fatalf("%s: unexpected number of candidates: %d", difficulty, len(candidates))
+		}
+		seen := make(map[string]bool)
+		for _, c := range candidates {
+			if c.Difficulty != difficulty {
+				t.Errorf("%s: candidate evaluated as %s: %+v", difficulty, c.Difficulty, c)
+			}
+			if c.MonsterCount > 5 || len(c.Monsters) > maxGeneratedKinds {
+				t.Errorf("%s: candidate exceeds limits: %+v", difficulty, c)
+			}
+			key := compositionKey(c.Monsters)
+			if seen[key] {
+				t.Errorf("%s: duplicate candidate %s", difficulty, key)
+			}
+			seen[key] = true
+		}
+
+		again, err := Generate(levels, generatorPool(), opts)
+		if err != nil || !reflect.DeepEqual(candidates, again) {
+			t.Fatalf("%s: same seed gave different results", difficulty)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	levels := []int{1, 1}
+	if _, err := Generate(levels, generatorPool(), GenerateOptions{Difficulty: "impossible"}); err == nil {
+		t.Fatal("expected error for unknown difficulty")
+	}
+	if _, err := Generate(nil, generatorPool(), GenerateOptions{Difficulty: DifficultyEasy}); err == nil {
+		t.Fatal("expected error for empty party")
+	}
+	// Даже один тролль слишком опасен для двух персонажей 1-го уровня
+	pool := []monsters.Monster{{ID: "troll", Name: "Troll", ChallengeRating: 5}}
+	if _, err := Generate(levels, pool, GenerateOptions{Difficulty: DifficultyEasy}); !errors.Is(err, ErrNoCandidates) {
+		t.Fatalf("expected ErrNoCandidates, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package encounters
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store хранилище столкновений.
+type Store interface {
+	Create(e Encounter) (Encounter, error)
+	Get(id string) (Encounter, error)
+	// List возвращает столкновения компании, новые в конце
+	List(companyID string) []Encounter
+	// Update сохраняет столкновение, только если его версия совпадает
+	// с e.Version (0 - без проверки), иначе возвращает ErrVersionConflict
+	Update(e Encounter) (Encounter, error)
+	// Delete удаляет столкновение; version 0 - без проверки версии
+	Delete(id string, version int) error
+}
+
+// MemoryStore хранилище столкновений в памяти
+type MemoryStore struct {
+	mu   sync.RWMutex
+	byID map[string]Encounter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		byID: make(map[string]Encounter),
+	}
+}
+
+func (s *MemoryStore) Create(e Encounter) (Encounter, error) {
+	if err := e.Validate(); err != nil {
+		return Encounter{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.ID == "" {
+		e.ID = generateID()
+	}
+	if _, exists := s.byID[e.ID]; exists {
+		return Encounter{}, fmt.Errorf("encounter with id %s already exists", e.ID)
+	}
+	e.Version = 1
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+
+	s.byID[e.ID] = e
+	return e, nil
+}
+
+func (s *MemoryStore) Get(id string) (Encounter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.byID[id]
+	if !ok {
+		return Encounter{}, ErrNotFound
+	}
+	return e, nil
+}
+
+func (s *MemoryStore) List(companyID string) []Encounter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []Encounter{}
+	for _, e := range s.byID {
+		if e.CompanyID == companyID {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (s *MemoryStore) Update(e Encounter) (Encounter, error) {
+	if err := e.Validate(); err != nil {
+		return Encounter{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byID[e.ID]
+	if !ok {
+		return Encounter{}, ErrNotFound
+	}
+	if e.Version != 0 && e.Version != existing.Version {
+		return Encounter{}, ErrVersionConflict
+	}
+
+	e.CompanyID = existing.CompanyID
+	e.Version = existing.Version + 1
+	e.CreatedAt = existing.CreatedAt
+	e.UpdatedAt = time.Now()
+	s.byID[e.ID] = e
+	return e, nil
+}
+
+func (s *MemoryStore) Delete(id string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && version != existing.Version {
+		return ErrVersionConflict
+	}
+	delete(s.byID, id)
+	return nil
+}
+
+func generateID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(fmt.Errorf("failed to generate id: %w", err))
+	}
+	return hex.EncodeToString(buf[:])
+}
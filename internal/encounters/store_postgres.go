@@ -0,0 +1,167 @@
+package encounters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresStore реализует Store для столкновений в PostgreSQL.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore создаёт хранилище столкновений и гарантирует,
+// что таблица существует.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	const createTable = `
+CREATE TABLE IF NOT EXISTS encounters (
+	id         TEXT PRIMARY KEY,
+	company_id TEXT NOT NULL,
+	version    INTEGER NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL,
+	data       JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_encounters_company_id ON encounters(company_id);`
+
+	if _, err := db.Exec(createTable); err != nil {
+		panic(fmt.Errorf("failed to create encounters table: %w", err))
+	}
+
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Create(e Encounter) (Encounter, error) {
+	if err := e.Validate(); err != nil {
+		return Encounter{}, err
+	}
+
+	if e.ID == "" {
+		e.ID = generateID()
+	}
+	e.Version = 1
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Encounter{}, fmt.Errorf("failed to marshal encounter: %w", err)
+	}
+
+	const insertQuery = `INSERT INTO encounters (id, company_id, version, created_at, data) VALUES ($1, $2, $3, $4, $5::jsonb);`
+	if _, err := s.db.Exec(insertQuery, e.ID, e.CompanyID, e.Version, e.CreatedAt, data); err != nil {
+		return Encounter{}, fmt.Errorf("failed to insert encounter: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *PostgresStore) Get(id string) (Encounter, error) {
+	const selectQuery = `SELECT data, version FROM encounters WHERE id = $1;`
+
+	var raw []byte
+	var version int
+	err := s.db.QueryRow(selectQuery, id).Scan(&raw, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Encounter{}, ErrNotFound
+		}
+		return Encounter{}, fmt.Errorf("failed to get encounter: %w", err)
+	}
+
+	var e Encounter
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return Encounter{}, fmt.Errorf("failed to unmarshal encounter: %w", err)
+	}
+	e.Version = version
+	return e, nil
+}
+
+func (s *PostgresStore) List(companyID string) []Encounter {
+	const listQuery = `SELECT data, version FROM encounters WHERE company_id = $1 ORDER BY created_at, id;`
+
+	result := []Encounter{}
+	rows, err := s.db.Query(listQuery, companyID)
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
+			continue
+		}
+		var e Encounter
+		if err := json.Unmarshal(raw, &e); err != nil {
+			continue
+		}
+		e.Version = version
+		result = append(result, e)
+	}
+	return result
+}
+
+func (s *PostgresStore) Update(e Encounter) (Encounter, error) {
+	if err := e.Validate(); err != nil {
+		return Encounter{}, err
+	}
+
+	existing, err := s.Get(e.ID)
+	if err != nil {
+		return Encounter{}, err
+	}
+	expected := e.Version
+	if expected == 0 {
+		expected = existing.Version
+	}
+	e.CompanyID = existing.CompanyID
+	e.Version = expected + 1
+	e.CreatedAt = existing.CreatedAt
+	e.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Encounter{}, fmt.Errorf("failed to marshal encounter: %w", err)
+	}
+
+	const updateQuery = `UPDATE encounters SET data = $2::jsonb, version = version + 1 WHERE id = $1 AND version = $3;`
+	res, err := s.db.Exec(updateQuery, e.ID, data, expected)
+	if err != nil {
+		return Encounter{}, fmt.Errorf("failed to update encounter: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		// Отличаем удалённое столкновение от устаревшей версии
+		if _, err := s.Get(e.ID); err != nil {
+			return Encounter{}, err
+		}
+		return Encounter{}, ErrVersionConflict
+	}
+
+	return e, nil
+}
+
+func (s *PostgresStore) Delete(id string, version int) error {
+	const deleteQuery = `DELETE FROM encounters WHERE id = $1 AND ($2 = 0 OR version = $2);`
+
+	res, err := s.db.Exec(deleteQuery, id, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete encounter: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		// Отличаем отсутствующее столкновение от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+
+	return nil
+}
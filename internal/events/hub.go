@@ -0,0 +1,139 @@
+// Package events рассылает события компании подписчикам (SSE, WebSocket)
+// и хранит последние события, чтобы переподключившийся клиент мог
+// догнать пропущенное.
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Типы событий.
+const (
+	TypeRoll         = "roll.made"
+	TypeHPChanged    = "hp.changed"
+	TypeTurnAdvanced = "turn.advanced"
+	TypeCondition    = "condition.applied"
+	TypeEntityAdded  = "entity.added"
+)
+
+const (
+	DefaultReplaySize = 256 // событий компании в буфере повторной отправки
+	subscriberBuffer  = 64  // событий в очереди подписчика
+)
+
+// Event событие компании. ID растут монотонно в пределах хаба.
+type Event struct {
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	CompanyID string          `json:"companyId"`
+	Time      time.Time       `json:"time"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// Subscription подписка на события компании. Канал C закрывается при
+// отписке или если подписчик не успевает читать события; клиент
+// переподключается с последним полученным ID.
+type Subscription struct {
+	C         <-chan Event
+	ch        chan Event
+	companyID string
+}
+
+// Hub рассылает события внутри процесса.
+type Hub struct {
+	mu         sync.Mutex
+	lastID     uint64
+	replaySize int
+	history    map[string][]Event // последние события по компаниям
+	subs       map[string]map[*Subscription]struct{}
+}
+
+// NewHub создаёт хаб, хранящий до replaySize последних событий каждой компании.
+func NewHub(replaySize int) *Hub {
+	if replaySize < 1 {
+		replaySize = DefaultReplaySize
+	}
+	return &Hub{
+		replaySize: replaySize,
+		history:    make(map[string][]Event),
+		subs:       make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish рассылает событие подписчикам компании и запоминает его для
+// повторной отправки. Данные сериализуются сразу, поэтому последующие
+// изменения data не влияют на событие.
+func (h *Hub) Publish(companyID, eventType string, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	e := Event{ID: h.lastID, Type: eventType, CompanyID: companyID, Time: time.Now(), Data: raw}
+
+	history := append(h.history[companyID], e)
+	if len(history) > h.replaySize {
+		history = append([]Event(nil), history[len(history)-h.replaySize:]...)
+	}
+	h.history[companyID] = history
+
+	for sub := range h.subs[companyID] {
+		select {
+		case sub.ch <- e:
+		default:
+			// Медленный подписчик отключается и догонит при переподключении
+			h.remove(sub)
+		}
+	}
+	return e, nil
+}
+
+// Subscribe подписывает на события компании. Если lastEventID не 0,
+// возвращает сохранённые события после него; если часть пропущенного
+// уже вытеснена из буфера, отдаётся всё, что есть.
+func (h *Hub) Subscribe(companyID string, lastEventID uint64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if lastEventID != 0 {
+		for _, e := range h.history[companyID] {
+			if e.ID > lastEventID {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, companyID: companyID}
+	if h.subs[companyID] == nil {
+		h.subs[companyID] = make(map[*Subscription]struct{})
+	}
+	h.subs[companyID][sub] = struct{}{}
+	return sub, replay
+}
+
+// Unsubscribe отменяет подписку; повторный вызов безопасен.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	subs := h.subs[sub.companyID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.companyID)
+	}
+	close(sub.ch)
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPublishDeliversToCompanySubscribers(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(0)
+	sub, replay := hub.Subscribe("c1", 0)
+	defer hub.Unsubscribe(sub)
+	other, _ := hub.Subscribe("c2", 0)
+	defer hub.Unsubscribe(other)
+	if len(replay) != 0 {
+		t.Fatalf("expected no replay for a new subscriber, got %d", len(replay))
+	}
+
+	published, err := hub.Publish("c1", TypeHPChanged, map[string]int{"currentHitPoints": 7})
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	e := <-sub.C
+	if e.ID != published.ID || e.Type != TypeHPChanged || e.CompanyID != "c1" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	var data map[string]int
+	if err := json.Unmarshal(e.Data, &data); err != nil || data["currentHitPoints"] != 7 {
+		t.Fatalf("unexpected data %s: %v", e.Data, err)
+	}
+	select {
+	case e := <-other.C:
+		t.Fatalf("event leaked to another company: %+v", e)
+	default:
+	}
+}
+
+func TestSubscribeReplaysAfterLastEventID(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(3)
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		e, err := hub.Publish("c1", TypeRoll, i)
+		if err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+		ids = append(ids, e.ID)
+	}
+
+	sub, replay := hub.Subscribe("c1", ids[2])
+	defer hub.Unsubscribe(sub)
+	if len(replay) != 2 || replay[0].ID != ids[3] || replay[1].ID != ids[4] {
+		t.Fatalf("unexpected replay: %+v", replay)
+	}
+
+	// Вытесненные события не возвращаются, отдаётся весь буфер
+	sub2, replay := hub.Subscribe("c1", ids[0])
+	defer hub.Unsubscribe(sub2)
+	if len(replay) != 3 || replay[0].ID != ids[2] {
+		t.Fatalf("expected the whole buffer, got %+v", replay)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(0)
+	sub, _ := hub.Subscribe("c1", 0)
+	for i := 0; i <= subscriberBuffer; i++ {
+		if _, err := hub.Publish("c1", TypeRoll, i); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("expected %d buffered events before close, got %d", subscriberBuffer, received)
+	}
+	hub.Unsubscribe(sub) // повторная отписка безопасна
+}
@@ -0,0 +1,242 @@
+package events
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID из RFC 6455 для ответа на рукопожатие.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Коды операций кадров WebSocket.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// maxControlPayload наибольшая длина кадра от клиента: сервер только
+// рассылает события, клиенту достаточно управляющих кадров.
+const maxControlPayload = 125
+
+// Таймауты соединения: запись кадра не дольше WriteTimeout, а клиент,
+// не присылающий ничего (хотя бы pong) дольше PongTimeout, отключается.
+// Сервер отправляет ping каждые PingInterval.
+const (
+	WriteTimeout = 10 * time.Second
+	PongTimeout  = 60 * time.Second
+	PingInterval = PongTimeout * 9 / 10
+)
+
+// ErrOriginNotAllowed рукопожатие пришло со страницы чужого сайта.
+var ErrOriginNotAllowed = errors.New("websocket origin not allowed")
+
+// IsWebSocket запрос просит переключиться на WebSocket.
+func IsWebSocket(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") &&
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// Conn серверная сторона соединения WebSocket, отправляющая текстовые
+// кадры. Сообщения клиента, кроме ping и close, игнорируются.
+type Conn struct {
+	conn   net.Conn
+	rw     *bufio.ReadWriter
+	mu     sync.Mutex // сериализует запись кадров
+	closed chan struct{}
+	once   sync.Once
+}
+
+// Upgrade выполняет рукопожатие WebSocket (RFC 6455) и забирает
+// соединение у HTTP-сервера. Заголовок Origin должен совпадать с Host
+// или входить в allowedOrigins, иначе возвращается ErrOriginNotAllowed:
+// так чужая страница не прочитает поток от имени пользователя.
+// Запросы без Origin (не из браузера) принимаются.
+func Upgrade(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*Conn, error) {
+	if r.Method != http.MethodGet || !IsWebSocket(r) {
+		return nil, errors.New("not a websocket handshake")
+	}
+	if !originAllowed(r, allowedOrigins) {
+		return nil, ErrOriginNotAllowed
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// Дедлайны HTTP-сервера заменяются собственными таймаутами соединения
+	_ = conn.SetDeadline(time.Time{})
+	_ = conn.SetReadDeadline(time.Now().Add(PongTimeout))
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &Conn{conn: conn, rw: rw, closed: make(chan struct{})}
+	go c.readLoop()
+	return c, nil
+}
+
+// Closed закрывается, когда клиент закрыл соединение.
+func (c *Conn) Closed() <-chan struct{} {
+	return c.closed
+}
+
+// WriteText отправляет текстовое сообщение одним кадром.
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Ping проверяет, что клиент на связи: без ответа в течение
+// PongTimeout соединение закрывается.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// Close отправляет кадр закрытия и закрывает соединение.
+func (c *Conn) Close() error {
+	_ = c.writeFrame(opClose, nil)
+	c.shutdown()
+	return c.conn.Close()
+}
+
+func (c *Conn) shutdown() {
+	c.once.Do(func() { close(c.closed) })
+}
+
+func (c *Conn) writeFrame(opcode byte, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Клиент, переставший читать, не держит запись дольше WriteTimeout
+	if err := c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		return err
+	}
+	header := []byte{0x80 | opcode}
+	switch n := len(data); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(data); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// readLoop читает кадры клиента: отвечает на ping и завершает работу
+// при close, ошибке чтения, слишком длинном кадре или молчании клиента
+// дольше PongTimeout. Любой кадр продлевает ожидание.
+func (c *Conn) readLoop() {
+	defer c.conn.Close()
+	defer c.shutdown()
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+		if err := c.conn.SetReadDeadline(time.Now().Add(PongTimeout)); err != nil {
+			return
+		}
+		switch opcode {
+		case opClose:
+			c.Close()
+			return
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := int(head[1] & 0x7F)
+	if !masked {
+		return 0, nil, errors.New("client frames must be masked")
+	}
+	if length > maxControlPayload {
+		return 0, nil, errors.New("client frame is too large")
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// originAllowed Origin отсутствует, совпадает с Host запроса или
+// входит в allowed (схема и хост, например https://dm.example.com).
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package items
+
+// compendium справочник снаряжения из SRD 5e.
+var compendium = []Item{
+	// Доспехи
+	{ID: "padded", Name: "Padded", LocalName: "Стёганый доспех", Category: CategoryArmor, Cost: 500, Weight: 8, Armor: &ArmorStats{Type: ArmorLight, BaseAC: 11, StealthDisadvantage: true}},
+	{ID: "leather", Name: "Leather", LocalName: "Кожаный доспех", Category: CategoryArmor, Cost: 1000, Weight: 10, Armor: &ArmorStats{Type: ArmorLight, BaseAC: 11}},
+	{ID: "studded-leather", Name: "Studded leather", LocalName: "Проклёпанный кожаный доспех", Category: CategoryArmor, Cost: 4500, Weight: 13, Armor: &ArmorStats{Type: ArmorLight, BaseAC: 12}},
+	{ID: "hide", Name: "Hide", LocalName: "Шкурный доспех", Category: CategoryArmor, Cost: 1000, Weight: 12, Armor: &ArmorStats{Type: ArmorMedium, BaseAC: 12}},
+	{ID: "chain-shirt", Name: "Chain shirt", LocalName: "Кольчужная рубаха", Category: CategoryArmor, Cost: 5000, Weight: 20, Armor: &ArmorStats{Type: ArmorMedium, BaseAC: 13}},
+	{ID: "scale-mail", Name: "Scale mail", LocalName: "Чешуйчатый доспех", Category: CategoryArmor, Cost: 5000, Weight: 45, Armor: &ArmorStats{Type: ArmorMedium, BaseAC: 14, StealthDisadvantage: true}},
+	{ID: "breastplate", Name: "Breastplate", LocalName: "Кираса", Category: CategoryArmor, Cost: 40000, Weight: 20, Armor: &ArmorStats{Type: ArmorMedium, BaseAC: 14}},
+	{ID: "half-plate", Name: "Half plate", LocalName: "Полулаты", Category: CategoryArmor, Cost: 75000, Weight: 40, Armor: &ArmorStats{Type: ArmorMedium, BaseAC: 15, StealthDisadvantage: true}},
+	{ID: "ring-mail", Name: "Ring mail", LocalName: "Колечный доспех", Category: CategoryArmor, Cost: 3000, Weight: 40, Armor: &ArmorStats{Type: ArmorHeavy, BaseAC: 14, StealthDisadvantage: true}},
+	{ID: "chain-mail", Name: "Chain mail", LocalName: "Кольчуга", Category: CategoryArmor, Cost: 7500, Weight: 55, Armor: &ArmorStats{Type: ArmorHeavy, BaseAC: 16, StrengthRequirement: 13, StealthDisadvantage: true}},
+	{ID: "splint", Name: "Splint", LocalName: "Наборный доспех", Category: CategoryArmor, Cost: 20000, Weight: 60, Armor: &ArmorStats{Type: ArmorHeavy, BaseAC: 17, StrengthRequirement: 15, StealthDisadvantage: true}},
+	{ID: "plate", Name: "Plate", LocalName: "Латы", Category: CategoryArmor, Cost: 150000, Weight: 65, Armor: &ArmorStats{Type: ArmorHeavy, BaseAC: 18, StrengthRequirement: 15, StealthDisadvantage: true}},
+
+	// Щит
+	{ID: "shield", Name: "Shield", LocalName: "Щит", Category: CategoryShield, Cost: 1000, Weight: 6, Armor: &ArmorStats{BaseAC: 2}},
+
+	// Оружие
+	{ID: "club", Name: "Club", LocalName: "Дубинка", Category: CategoryWeapon, Cost: 10, Weight: 2, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d4", DamageType: "bludgeoning", Properties: []string{PropertyLight}}},
+	{ID: "dagger", Name: "Dagger", LocalName: "Кинжал", Category: CategoryWeapon, Cost: 200, Weight: 1, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d4", DamageType: "piercing", Properties: []string{PropertyFinesse, PropertyLight, PropertyThrown}, NormalRange: 20, LongRange: 60}},
+	{ID: "greatclub", Name: "Greatclub", LocalName: "Палица", Category: CategoryWeapon, Cost: 20, Weight: 10, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d8", DamageType: "bludgeoning", Properties: []string{PropertyTwoHanded}}},
+	{ID: "handaxe", Name: "Handaxe", LocalName: "Ручной топор", Category: CategoryWeapon, Cost: 500, Weight: 2, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d6", DamageType: "slashing", Properties: []string{PropertyLight, PropertyThrown}, NormalRange: 20, LongRange: 60}},
+	{ID: "javelin", Name: "Javelin", LocalName: "Метательное копьё", Category: CategoryWeapon, Cost: 50, Weight: 2, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d6", DamageType: "piercing", Properties: []string{PropertyThrown}, NormalRange: 30, LongRange: 120}},
+	{ID: "light-hammer", Name: "Light hammer", LocalName: "Лёгкий молот", Category: CategoryWeapon, Cost: 200, Weight: 2, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d4", DamageType: "bludgeoning", Properties: []string{PropertyLight, PropertyThrown}, NormalRange: 20, LongRange: 60}},
+	{ID: "mace", Name: "Mace", LocalName: "Булава", Category: CategoryWeapon, Cost: 500, Weight: 4, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d6", DamageType: "bludgeoning"}},
+	{ID: "quarterstaff", Name: "Quarterstaff", LocalName: "Боевой посох", Category: CategoryWeapon, Cost: 20, Weight: 4, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d6", DamageType: "bludgeoning", VersatileDamage: "1d8", Properties: []string{PropertyVersatile}}},
+	{ID: "sickle", Name: "Sickle", LocalName: "Серп", Category: CategoryWeapon, Cost: 100, Weight: 2, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d4", DamageType: "slashing", Properties: []string{PropertyLight}}},
+	{ID: "spear", Name: "Spear", LocalName: "Копьё", Category: CategoryWeapon, Cost: 100, Weight: 3, Weapon: &WeaponStats{Group: WeaponSimple, Damage: "1d6", DamageType: "piercing", VersatileDamage: "1d8", Properties: []string{PropertyThrown, PropertyVersatile}, NormalRange: 20, LongRange: 60}},
+	{ID: "light-crossbow", Name: "Light crossbow", LocalName: "Лёгкий арбалет", Category: CategoryWeapon, Cost: 2500, Weight: 5, Weapon: &WeaponStats{Group: WeaponSimple, Ranged: true, Damage: "1d8", DamageType: "piercing", Properties: []string{PropertyAmmunition, PropertyLoading, PropertyTwoHanded}, NormalRange: 80, LongRange: 320}},
+	{ID: "dart", Name: "Dart", LocalName: "Дротик", Category: CategoryWeapon, Cost: 5, Weight: 0.25, Weapon: &WeaponStats{Group: WeaponSimple, Ranged: true, Damage: "1d4", DamageType: "piercing", Properties: []string{PropertyFinesse, PropertyThrown}, NormalRange: 20, LongRange: 60}},
+	{ID: "shortbow", Name: "Shortbow", LocalName: "Короткий лук", Category: CategoryWeapon, Cost: 2500, Weight: 2, Weapon: &WeaponStats{Group: WeaponSimple, Ranged: true, Damage: "1d6", DamageType: "piercing", Properties: []string{PropertyAmmunition, PropertyTwoHanded}, NormalRange: 80, LongRange: 320}},
+	{ID: "sling", Name: "Sling", LocalName: "Праща", Category: CategoryWeapon, Cost: 10, Weight: 0, Weapon: &WeaponStats{Group: WeaponSimple, Ranged: true, Damage: "1d4", DamageType: "bludgeoning", Properties: []string{PropertyAmmunition}, NormalRange: 30, LongRange: 120}},
+	{ID: "battleaxe", Name: "Battleaxe", LocalName: "Боевой топор", Category: CategoryWeapon, Cost: 1000, Weight: 4, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "slashing", VersatileDamage: "1d10", Properties: []string{PropertyVersatile}}},
+	{ID: "flail", Name: "Flail", LocalName: "Цеп", Category: CategoryWeapon, Cost: 1000, Weight: 2, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "bludgeoning"}},
+	{ID: "glaive", Name: "Glaive", LocalName: "Глефа", Category: CategoryWeapon, Cost: 2000, Weight: 6, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d10", DamageType: "slashing", Properties: []string{PropertyHeavy, PropertyReach, PropertyTwoHanded}}},
+	{ID: "greataxe", Name: "Greataxe", LocalName: "Секира", Category: CategoryWeapon, Cost: 3000, Weight: 7, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d12", DamageType: "slashing", Properties: []string{PropertyHeavy, PropertyTwoHanded}}},
+	{ID: "greatsword", Name: "Greatsword", LocalName: "Двуручный меч", Category: CategoryWeapon, Cost: 5000, Weight: 6, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "2d6", DamageType: "slashing", Properties: []string{PropertyHeavy, PropertyTwoHanded}}},
+	{ID: "halberd", Name: "Halberd", LocalName: "Алебарда", Category: CategoryWeapon, Cost: 2000, Weight: 6, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d10", DamageType: "slashing", Properties: []string{PropertyHeavy, PropertyReach, PropertyTwoHanded}}},
+	{ID: "lance", Name: "Lance", LocalName: "Длинное копьё", Category: CategoryWeapon, Cost: 1000, Weight: 6, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d12", DamageType: "piercing", Properties: []string{PropertyReach, PropertySpecial}}},
+	{ID: "longsword", Name: "Longsword", LocalName: "Длинный меч", Category: CategoryWeapon, Cost: 1500, Weight: 3, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "slashing", VersatileDamage: "1d10", Properties: []string{PropertyVersatile}}},
+	{ID: "maul", Name: "Maul", LocalName: "Молот", Category: CategoryWeapon, Cost: 1000, Weight: 10, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "2d6", DamageType: "bludgeoning", Properties: []string{PropertyHeavy, PropertyTwoHanded}}},
+	{ID: "morningstar", Name: "Morningstar", LocalName: "Моргенштерн", Category: CategoryWeapon, Cost: 1500, Weight: 4, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "piercing"}},
+	{ID: "pike", Name: "Pike", LocalName: "Пика", Category: CategoryWeapon, Cost: 500, Weight: 18, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d10", DamageType: "piercing", Properties: []string{PropertyHeavy, PropertyReach, PropertyTwoHanded}}},
+	{ID: "rapier", Name: "Rapier", LocalName: "Рапира", Category: CategoryWeapon, Cost: 2500, Weight: 2, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "piercing", Properties: []string{PropertyFinesse}}},
+	{ID: "scimitar", Name: "Scimitar", LocalName: "Скимитар", Category: CategoryWeapon, Cost: 2500, Weight: 3, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d6", DamageType: "slashing", Properties: []string{PropertyFinesse, PropertyLight}}},
+	{ID: "shortsword", Name: "Shortsword", LocalName: "Короткий меч", Category: CategoryWeapon, Cost: 1000, Weight: 2, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d6", DamageType: "piercing", Properties: []string{PropertyFinesse, PropertyLight}}},
+	{ID: "trident", Name: "Trident", LocalName: "Трезубец", Category: CategoryWeapon, Cost: 500, Weight: 4, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d6", DamageType: "piercing", VersatileDamage: "1d8", Properties: []string{PropertyThrown, PropertyVersatile}, NormalRange: 20, LongRange: 60}},
+	{ID: "war-pick", Name: "War pick", LocalName: "Боевая кирка", Category: CategoryWeapon, Cost: 500, Weight: 2, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "piercing"}},
+	{ID: "warhammer", Name: "Warhammer", LocalName: "Боевой молот", Category: CategoryWeapon, Cost: 1500, Weight: 2, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d8", DamageType: "bludgeoning", VersatileDamage: "1d10", Properties: []string{PropertyVersatile}}},
+	{ID: "whip", Name: "Whip", LocalName: "Кнут", Category: CategoryWeapon, Cost: 200, Weight: 3, Weapon: &WeaponStats{Group: WeaponMartial, Damage: "1d4", DamageType: "slashing", Properties: []string{PropertyFinesse, PropertyReach}}},
+	{ID: "blowgun", Name: "Blowgun", LocalName: "Духовая трубка", Category: CategoryWeapon, Cost: 1000, Weight: 1, Weapon: &WeaponStats{Group: WeaponMartial, Ranged: true, Damage: "1", DamageType: "piercing", Properties: []string{PropertyAmmunition, PropertyLoading}, NormalRange: 25, LongRange: 100}},
+	{ID: "hand-crossbow", Name: "Hand crossbow", LocalName: "Ручной арбалет", Category: CategoryWeapon, Cost: 7500, Weight: 3, Weapon: &WeaponStats{Group: WeaponMartial, Ranged: true, Damage: "1d6", DamageType: "piercing", Properties: []string{PropertyAmmunition, PropertyLight, PropertyLoading}, NormalRange: 30, LongRange: 120}},
+	{ID: "heavy-crossbow", Name: "Heavy crossbow", LocalName: "Тяжёлый арбалет", Category: CategoryWeapon, Cost: 5000, Weight: 18, Weapon: &WeaponStats{Group: WeaponMartial, Ranged: true, Damage: "1d10", DamageType: "piercing", Properties: []string{PropertyAmmunition, PropertyHeavy, PropertyLoading, PropertyTwoHanded}, NormalRange: 100, LongRange: 400}},
+	{ID: "longbow", Name: "Longbow", LocalName: "Длинный лук", Category: CategoryWeapon, Cost: 5000, Weight: 2, Weapon: &WeaponStats{Group: WeaponMartial, Ranged: true, Damage: "1d8", DamageType: "piercing", Properties: []string{PropertyAmmunition, PropertyHeavy, PropertyTwoHanded}, NormalRange: 150, LongRange: 600}},
+
+	// Снаряжение
+	{ID: "backpack", Name: "Backpack", LocalName: "Рюкзак", Category: CategoryGear, Cost: 200, Weight: 5},
+	{ID: "bedroll", Name: "Bedroll", LocalName: "Спальник", Category: CategoryGear, Cost: 100, Weight: 7},
+	{ID: "crowbar", Name: "Crowbar", LocalName: "Ломик", Category: CategoryGear, Cost: 200, Weight: 5},
+	{ID: "healers-kit", Name: "Healer's kit", LocalName: "Комплект целителя", Category: CategoryGear, Cost: 500, Weight: 3},
+	{ID: "potion-of-healing", Name: "Potion of healing", LocalName: "Зелье лечения", Category: CategoryGear, Cost: 5000, Weight: 0.5},
+	{ID: "rations", Name: "Rations (1 day)", LocalName: "Рацион (1 день)", Category: CategoryGear, Cost: 50, Weight: 2},
+	{ID: "rope-hempen", Name: "Rope, hempen (50 feet)", LocalName: "Пеньковая верёвка (50 футов)", Category: CategoryGear, Cost: 100, Weight: 10},
+	{ID: "thieves-tools", Name: "Thieves' tools", LocalName: "Воровские инструменты", Category: CategoryGear, Cost: 2500, Weight: 1},
+	{ID: "tinderbox", Name: "Tinderbox", LocalName: "Трутовица", Category: CategoryGear, Cost: 50, Weight: 1},
+	{ID: "torch", Name: "Torch", LocalName: "Факел", Category: CategoryGear, Cost: 1, Weight: 1},
+	{ID: "waterskin", Name: "Waterskin", LocalName: "Бурдюк", Category: CategoryGear, Cost: 20, Weight: 5},
+	{ID: "arrows", Name: "Arrows (20)", LocalName: "Стрелы (20)", Category: CategoryGear, Cost: 100, Weight: 1},
+	{ID: "crossbow-bolts", Name: "Crossbow bolts (20)", LocalName: "Арбалетные болты (20)", Category: CategoryGear, Cost: 100, Weight: 1.5},
+	{ID: "component-pouch", Name: "Component pouch", LocalName: "Мешочек с компонентами", Category: CategoryGear, Cost: 2500, Weight: 2},
+	{ID: "holy-symbol", Name: "Holy symbol", LocalName: "Священный символ", Category: CategoryGear, Cost: 500, Weight: 1},
+	{ID: "spellbook", Name: "Spellbook", LocalName: "Книга заклинаний", Category: CategoryGear, Cost: 5000, Weight: 3},
+}
@@ -0,0 +1,160 @@
+package items
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var ErrNotFound = errors.New("item not found")
+
+// Категории предметов.
+const (
+	CategoryWeapon = "weapon"
+	CategoryArmor  = "armor"
+	CategoryShield = "shield"
+	CategoryGear   = "gear"
+)
+
+// Типы доспехов.
+const (
+	ArmorLight  = "light"
+	ArmorMedium = "medium"
+	ArmorHeavy  = "heavy"
+)
+
+// Группы оружия.
+const (
+	WeaponSimple  = "simple"
+	WeaponMartial = "martial"
+)
+
+// Свойства оружия.
+const (
+	PropertyAmmunition = "ammunition"
+	PropertyFinesse    = "finesse"
+	PropertyHeavy      = "heavy"
+	PropertyLight      = "light"
+	PropertyLoading    = "loading"
+	PropertyReach      = "reach"
+	PropertySpecial    = "special"
+	PropertyThrown     = "thrown"
+	PropertyTwoHanded  = "two-handed"
+	PropertyVersatile  = "versatile"
+)
+
+// mediumArmorDexCap максимальный бонус Ловкости к КД в среднем доспехе.
+const mediumArmorDexCap = 2
+
+// ArmorStats параметры доспеха или щита.
+type ArmorStats struct {
+	Type                string `json:"type,omitempty"` // light, medium, heavy; пусто для щита
+	BaseAC              int    `json:"baseAC"`         // для щита - бонус к КД
+	StrengthRequirement int    `json:"strengthRequirement,omitempty"`
+	StealthDisadvantage bool   `json:"stealthDisadvantage,omitempty"`
+}
+
+// DexCap возвращает ограничение бонуса Ловкости к КД: -1 - без ограничения,
+// 0 у тяжёлого доспеха - Ловкость не учитывается вовсе.
+func (a ArmorStats) DexCap() int {
+	switch a.Type {
+	case ArmorMedium:
+		return mediumArmorDexCap
+	case ArmorHeavy:
+		return 0
+	}
+	return -1
+}
+
+// WeaponStats параметры оружия.
+type WeaponStats struct {
+	Group           string   `json:"group"` // simple, martial
+	Ranged          bool     `json:"ranged"`
+	Damage          string   `json:"damage"` // например: "1d8"
+	DamageType      string   `json:"damageType"`
+	VersatileDamage string   `json:"versatileDamage,omitempty"`
+	Properties      []string `json:"properties,omitempty"`
+	NormalRange     int      `json:"normalRange,omitempty"` // дистанция в футах для дальнобойного и метательного
+	LongRange       int      `json:"longRange,omitempty"`
+}
+
+// HasProperty сообщает, есть ли у оружия указанное свойство.
+func (w WeaponStats) HasProperty(property string) bool {
+	for _, p := range w.Properties {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
+
+// Item предмет из справочника снаряжения.
+type Item struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	LocalName string       `json:"localName,omitempty"` // русское название
+	Category  string       `json:"category"`
+	Cost      int          `json:"cost"`   // стоимость в медных монетах
+	Weight    float64      `json:"weight"` // вес в фунтах
+	Armor     *ArmorStats  `json:"armor,omitempty"`
+	Weapon    *WeaponStats `json:"weapon,omitempty"`
+}
+
+// clone копия предмета: изменения параметров доспеха или оружия
+// в копии не затрагивают справочник.
+func (i Item) clone() Item {
+	if i.Armor != nil {
+		armor := *i.Armor
+		i.Armor = &armor
+	}
+	if i.Weapon != nil {
+		weapon := *i.Weapon
+		weapon.Properties = append([]string(nil), weapon.Properties...)
+		i.Weapon = &weapon
+	}
+	return i
+}
+
+var byID = func() map[string]Item {
+	index := make(map[string]Item, len(compendium))
+	for _, item := range compendium {
+		index[item.ID] = item
+	}
+	return index
+}()
+
+// Get возвращает предмет справочника по идентификатору.
+func Get(id string) (Item, error) {
+	item, ok := byID[id]
+	if !ok {
+		return Item{}, ErrNotFound
+	}
+	return item.clone(), nil
+}
+
+// Find ищет предмет по идентификатору, английскому или русскому названию без учёта регистра.
+func Find(ref string) (Item, error) {
+	ref = strings.TrimSpace(ref)
+	if item, ok := byID[strings.ToLower(ref)]; ok {
+		return item.clone(), nil
+	}
+	for _, item := range compendium {
+		if strings.EqualFold(item.Name, ref) || (item.LocalName != "" && strings.EqualFold(item.LocalName, ref)) {
+			return item.clone(), nil
+		}
+	}
+	return Item{}, ErrNotFound
+}
+
+// List возвращает предметы справочника, отсортированные по названию.
+// Непустая category ограничивает выборку одной категорией.
+func List(category string) []Item {
+	result := make([]Item, 0, len(compendium))
+	for _, item := range compendium {
+		if category == "" || item.Category == category {
+			result = append(result, item.clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
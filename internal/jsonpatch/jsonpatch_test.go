@@ -0,0 +1,136 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("invalid result JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid expected JSON: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	t.Parallel()
+
+	// Примеры из приложения A RFC 7386
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+	}
+
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("merge %s with %s: %v", tt.doc, tt.patch, err)
+		}
+		assertJSONEqual(t, got, tt.want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "add and replace",
+			doc:   `{"currentHitPoints":12,"skills":["Атлетика"]}`,
+			patch: `[{"op":"replace","path":"/currentHitPoints","value":7},{"op":"add","path":"/skills/-","value":"Скрытность"}]`,
+			want:  `{"currentHitPoints":7,"skills":["Атлетика","Скрытность"]}`,
+		},
+		{
+			name:  "insert into array",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "move and copy",
+			doc:   `{"a":{"b":1},"c":{}}`,
+			patch: `[{"op":"move","from":"/a/b","path":"/c/b"},{"op":"copy","from":"/c","path":"/d"}]`,
+			want:  `{"a":{},"c":{"b":1},"d":{"b":1}}`,
+		},
+		{
+			name:  "escaped pointer",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"test","path":"/a~1b","value":1},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ops, err := DecodeOperations([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			got, err := Apply([]byte(tt.doc), ops)
+			if err != nil {
+				t.Fatalf("apply error: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	t.Parallel()
+
+	doc := []byte(`{"level":3,"items":[]}`)
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"replace missing member", `[{"op":"replace","path":"/missing","value":1}]`},
+		{"index out of bounds", `[{"op":"add","path":"/items/2","value":1}]`},
+		{"leading zero index", `[{"op":"add","path":"/items/01","value":1}]`},
+		{"unknown operation", `[{"op":"increment","path":"/level"}]`},
+		{"move into child", `[{"op":"move","from":"/items","path":"/items/0"}]`},
+	}
+
+	for _, tt := range tests {
+		ops, err := DecodeOperations([]byte(tt.patch))
+		if err != nil {
+			t.Fatalf("%s: decode error: %v", tt.name, err)
+		}
+		if _, err := Apply(doc, ops); err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+	}
+
+	ops, _ := DecodeOperations([]byte(`[{"op":"test","path":"/level","value":4}]`))
+	if _, err := Apply(doc, ops); !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("expected ErrTestFailed, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package monsters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/conditions"
+	"dice-service/internal/dice"
+)
+
+// Виды действий монстра.
+const (
+	ActionMelee  = "melee"  // рукопашная атака
+	ActionRanged = "ranged" // дальнобойная атака
+	ActionSave   = "save"   // спасбросок цели против СЛ
+)
+
+var ErrActionNotFound = errors.New("action not found")
+var ErrActionUnavailable = errors.New("action is not available")
+
+var saveAbilities = map[string]bool{"STR": true, "DEX": true, "CON": true, "INT": true, "WIS": true, "CHA": true}
+
+// Damage урон действия: выражение костей ("2d10+8") и тип урона.
+type Damage struct {
+	Dice string `json:"dice"`
+	Type string `json:"type,omitempty"`
+}
+
+// Action действие, особенность или легендарное действие монстра.
+type Action struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"` // melee, ranged, save; пусто - без броска
+	AttackBonus int      `json:"attackBonus,omitempty"`
+	Reach       int      `json:"reach,omitempty"`     // досягаемость в футах
+	Range       int      `json:"range,omitempty"`     // обычная дистанция в футах
+	LongRange   int      `json:"longRange,omitempty"` // максимальная дистанция в футах
+	Damage      []Damage `json:"damage,omitempty"`
+	SaveDC      int      `json:"saveDc,omitempty"`
+	SaveAbility string   `json:"saveAbility,omitempty"` // STR, DEX, CON, INT, WIS, CHA
+	Recharge    int      `json:"recharge,omitempty"`    // перезарядка на d6 от этого значения (5 - «Перезарядка 5–6»)
+	UsesPerDay  int      `json:"usesPerDay,omitempty"`
+	Description string   `json:"description,omitempty"`
+
+	// Состояние действия в бою; в карточке монстра не хранится,
+	// а переносится из экземпляра и обратно (Instance.Apply, Instance.Capture)
+	Spent bool `json:"-"` // перезаряжаемое действие использовано и ждёт перезарядки
+	Used  int  `json:"-"` // использований за день
+}
+
+// rechargePattern находит перезарядку в названии старого формата:
+// "Fire Breath (Recharge 5-6)", "Огненное дыхание (Перезарядка 5–6)".
+var rechargePattern = regexp.MustCompile(`(?i)\s*\((?:recharge|перезарядка)\s*([2-6])(?:\s*[-–]\s*6)?\)`)
+
+// UnmarshalJSON принимает и структуру, и строку старого формата
+// "Название. Описание".
+func (a *Action) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*a = actionFromText(text)
+		return nil
+	}
+	type plain Action
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*a = Action(decoded)
+	return nil
+}
+
+func actionFromText(text string) Action {
+	text = strings.TrimSpace(text)
+	name, _, found := strings.Cut(text, ". ")
+	if !found {
+		name = strings.TrimSuffix(text, ".")
+	}
+	action := Action{Name: name, Description: text}
+	if match := rechargePattern.FindStringSubmatch(name); match != nil {
+		action.Recharge, _ = strconv.Atoi(match[1])
+		action.Name = strings.TrimSpace(rechargePattern.ReplaceAllString(name, ""))
+	}
+	return action
+}
+
+func (a Action) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return errors.New("action name is required")
+	}
+	switch a.Type {
+	case "", ActionMelee, ActionRanged:
+	case ActionSave:
+		if a.SaveDC < 1 || !saveAbilities[a.SaveAbility] {
+			return fmt.Errorf("action %q: save requires saveDc and saveAbility", a.Name)
+		}
+	default:
+		return fmt.Errorf("action %q: unknown type %q", a.Name, a.Type)
+	}
+	if a.Reach < 0 || a.Range < 0 || a.LongRange < 0 || (a.LongRange > 0 && a.LongRange < a.Range) {
+		return fmt.Errorf("action %q: invalid reach or range", a.Name)
+	}
+	for _, d := range a.Damage {
+		if _, err := parseDamage(d.Dice); err != nil {
+			return fmt.Errorf("action %q: %w", a.Name, err)
+		}
+	}
+	if a.Recharge != 0 && (a.Recharge < 2 || a.Recharge > 6) {
+		return fmt.Errorf("action %q: recharge must be between 2 and 6", a.Name)
+	}
+	if a.UsesPerDay < 0 || a.Used < 0 {
+		return fmt.Errorf("action %q: uses must not be negative", a.Name)
+	}
+	return nil
+}
+
+// parseDamage разбирает выражение урона; число без костей - фиксированный урон.
+func parseDamage(text string) (dice.Expression, error) {
+	expr, err := dice.ParseExpression(text)
+	if err == nil {
+		return expr, nil
+	}
+	if flat, convErr := strconv.Atoi(strings.TrimSpace(text)); convErr == nil && flat >= 0 {
+		return dice.Expression{Modifier: flat}, nil
+	}
+	return dice.Expression{}, fmt.Errorf("invalid damage %q: %w", text, err)
+}
+
+// ActionRollOptions параметры броска действия.
+type ActionRollOptions struct {
+	Mode     string // normal, advantage, disadvantage
+	TargetAC int    // 0 - попадание определяется только натуральными 1 и 20
+}
+
+// DamageRoll бросок одного вида урона.
+type DamageRoll struct {
+	Expression string `json:"expression"`
+	Rolls      []int  `json:"rolls"`
+	Total      int    `json:"total"`
+	Type       string `json:"type,omitempty"`
+}
+
+// ActionRollResult результат броска действия.
+type ActionRollResult struct {
+	Action       string          `json:"action"`
+	Available    bool            `json:"available"`              // false - перезарядка не удалась
+	RechargeRoll int             `json:"rechargeRoll,omitempty"` // бросок d6 на перезарядку
+	D20          *dice.D20Result `json:"d20,omitempty"`
+	AttackTotal  int             `json:"attackTotal,omitempty"`
+	Hit          bool            `json:"hit"`
+	Critical     bool            `json:"critical"`
+	SaveDC       int             `json:"saveDc,omitempty"`
+	SaveAbility  string          `json:"saveAbility,omitempty"`
+	Damage       []DamageRoll    `json:"damage"`
+	TotalDamage  int             `json:"totalDamage"`
+	UsesLeft     *int            `json:"usesLeft,omitempty"`
+}
+
+// findAction ищет действие по названию без учёта регистра среди действий,
+// легендарных действий и особенностей.
+func findAction(m *Monster, name string) (*Action, error) {
+	for _, list := range [][]Action{m.Actions, m.LegendaryActions, m.Traits} {
+		for i := range list {
+			if strings.EqualFold(list[i].Name, name) {
+				return &list[i], nil
+			}
+		}
+	}
+	return nil, ErrActionNotFound
+}
+
+// RollAction бросает атаку и урон действия монстра. Использованное
+// перезаряжаемое действие сначала бросает d6 на перезарядку: при неудаче
+// действие не выполняется. Если состояние действия изменилось (перезарядка,
+// число использований), возвращает обновлённого монстра, из которого это
+// состояние можно забрать.
+func RollAction(m Monster, name string, opts ActionRollOptions) (ActionRollResult, *Monster, error) {
+	if opts.TargetAC < 0 {
+		return ActionRollResult{}, nil, errors.New("target AC must not be negative")
+	}
+	effects := conditions.Summarize(m.Conditions)
+	if effects.Incapacitated {
+		return ActionRollResult{}, nil, errors.New("monster is incapacitated and cannot act")
+	}
+
+	m.Actions = append([]Action(nil), m.Actions...)
+	m.LegendaryActions = append([]Action(nil), m.LegendaryActions...)
+	m.Traits = append([]Action(nil), m.Traits...)
+	action, err := findAction(&m, name)
+	if err != nil {
+		return ActionRollResult{}, nil, err
+	}
+
+	result := ActionRollResult{Action: action.Name, Damage: []DamageRoll{}}
+	if action.UsesPerDay > 0 && action.Used >= action.UsesPerDay {
+		return ActionRollResult{}, nil, fmt.Errorf("%w: no uses left today", ErrActionUnavailable)
+	}
+	if action.Recharge > 0 && action.Spent {
+		roll, err := dice.Roll(dice.Expression{Dice: []dice.DiceTerm{{Count: 1, Sides: 6, Sign: 1}}})
+		if err != nil {
+			return ActionRollResult{}, nil, err
+		}
+		result.RechargeRoll = roll.Total
+		if roll.Total < action.Recharge {
+			return result, nil, nil
+		}
+	}
+	result.Available = true
+
+	switch action.Type {
+	case ActionMelee, ActionRanged:
+		mode := opts.Mode
+		switch mode {
+		case "", dice.ModeNormal, dice.ModeAdvantage, dice.ModeDisadvantage:
+		default:
+			return ActionRollResult{}, nil, fmt.Errorf("unknown advantage state %q", opts.Mode)
+		}
+		modes := []string{mode}
+		if effects.AttackAdvantage {
+			modes = append(modes, dice.ModeAdvantage)
+		}
+		if effects.AttackDisadvantage {
+			modes = append(modes, dice.ModeDisadvantage)
+		}
+		d20, err := dice.RollD20(dice.CombineModes(modes...))
+		if err != nil {
+			return ActionRollResult{}, nil, err
+		}
+		result.D20 = &d20
+		result.AttackTotal = d20.Natural + action.AttackBonus
+		result.Critical = d20.Natural == 20
+		result.Hit = result.Critical || (d20.Natural != 1 && result.AttackTotal >= opts.TargetAC)
+	case ActionSave:
+		result.SaveDC = action.SaveDC
+		result.SaveAbility = action.SaveAbility
+		result.Hit = true
+	default:
+		result.Hit = true
+	}
+
+	if result.Hit {
+		for _, d := range action.Damage {
+			expr, err := parseDamage(d.Dice)
+			if err != nil {
+				return ActionRollResult{}, nil, err
+			}
+			if result.Critical {
+				expr = expr.DoubleDice()
+			}
+			roll := DamageRoll{Expression: d.Dice, Type: d.Type, Rolls: []int{}, Total: expr.Modifier}
+			if len(expr.Dice) > 0 {
+				rolled, err := dice.Roll(expr)
+				if err != nil {
+					return ActionRollResult{}, nil, err
+				}
+				roll.Rolls = rolled.Rolls
+				roll.Total = max(0, rolled.Total)
+			}
+			result.Damage = append(result.Damage, roll)
+			result.TotalDamage += roll.Total
+		}
+	}
+
+	if action.Recharge == 0 && action.UsesPerDay == 0 {
+		return result, nil, nil
+	}
+	if action.Recharge > 0 {
+		action.Spent = true
+	}
+	if action.UsesPerDay > 0 {
+		action.Used++
+		left := action.UsesPerDay - action.Used
+		result.UsesLeft = &left
+	}
+	return result, &m, nil
+}
@@ -0,0 +1,78 @@
+package monsters
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestActionLegacyString(t *testing.T) {
+	t.Parallel()
+
+	var m Monster
+	data := `{"actions":["Fire Breath (Recharge 5-6). The dragon exhales fire.", {"name":"Bite","type":"melee","attackBonus":14}]}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if len(m.Actions) != 2 {
+		t.Fatalf("expected 2 actions, got %d", len(m.Actions))
+	}
+	breath := m.Actions[0]
+	if breath.Name != "Fire Breath" || breath.Recharge != 5 || breath.Description == "" {
+		t.Fatalf("unexpected legacy action: %+v", breath)
+	}
+	if m.Actions[1].Type != ActionMelee || m.Actions[1].AttackBonus != 14 {
+		t.Fatalf("unexpected structured action: %+v", m.Actions[1])
+	}
+
+	// Перезарядку вне 2–6 разбор не распознаёт, и действие остаётся допустимым
+	odd := actionFromText("Odd Breath (Recharge 1). Never recharges.")
+	if odd.Recharge != 0 || odd.Name != "Odd Breath (Recharge 1)" {
+		t.Fatalf("unexpected action: %+v", odd)
+	}
+	if err := odd.Validate(); err != nil {
+		t.Fatalf("validate error: %v", err)
+	}
+}
+
+func TestRollActionRecharge(t *testing.T) {
+	t.Parallel()
+
+	m := Monster{
+		Name: "Dragon", Type: "Dragon", ArmorClass: 18, HitPoints: 100,
+		Actions: []Action{{Name: "Fire Breath", Type: ActionSave, SaveDC: 17, SaveAbility: "DEX", Recharge: 5,
+			Damage: []Damage{{Dice: "10d6", Type: "fire"}}}},
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("validate error: %v", err)
+	}
+
+	result, updated, err := RollAction(m, "fire breath", ActionRollOptions{})
+	if err != nil {
+		t.Fatalf("roll error: %v", err)
+	}
+	if !result.Available || result.SaveDC != 17 || len(result.Damage) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.TotalDamage < 10 || result.TotalDamage > 60 {
+		t.Fatalf("damage out of range: %d", result.TotalDamage)
+	}
+	if updated == nil || !updated.Actions[0].Spent {
+		t.Fatal("expected action to be spent after use")
+	}
+	if m.Actions[0].Spent {
+		t.Fatal("original monster must not be modified")
+	}
+
+	// Повторное использование бросает d6 на перезарядку
+	result, _, err = RollAction(*updated, "Fire Breath", ActionRollOptions{})
+	if err != nil {
+		t.Fatalf("roll error: %v", err)
+	}
+	if result.RechargeRoll < 1 || result.RechargeRoll > 6 || result.Available != (result.RechargeRoll >= 5) {
+		t.Fatalf("unexpected recharge result: %+v", result)
+	}
+
+	if _, _, err := RollAction(m, "Tail", ActionRollOptions{}); err != ErrActionNotFound {
+		t.Fatalf("expected ErrActionNotFound, got %v", err)
+	}
+}
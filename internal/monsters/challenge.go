@@ -0,0 +1,118 @@
+package monsters
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChallengeRating показатель опасности монстра: 0, 1/8, 1/4, 1/2 или целое от 1 до 30.
+// В JSON записывается строкой ("1/4", "17"); при чтении принимает также
+// число и старый формат "17 (18,000 XP)".
+type ChallengeRating float64
+
+// MaxChallengeRating наибольший показатель опасности в SRD.
+const MaxChallengeRating ChallengeRating = 30
+
+// challengeXP опыт за монстра по показателю опасности (SRD).
+var challengeXP = map[ChallengeRating]int{
+	0: 10, 0.125: 25, 0.25: 50, 0.5: 100,
+	1: 200, 2: 450, 3: 700, 4: 1100, 5: 1800,
+	6: 2300, 7: 2900, 8: 3900, 9: 5000, 10: 5900,
+	11: 7200, 12: 8400, 13: 10000, 14: 11500, 15: 13000,
+	16: 15000, 17: 18000, 18: 20000, 19: 22000, 20: 25000,
+	21: 33000, 22: 41000, 23: 50000, 24: 62000, 25: 75000,
+	26: 90000, 27: 105000, 28: 120000, 29: 135000, 30: 155000,
+}
+
+var fractionalRatings = map[string]ChallengeRating{
+	"1/8": 0.125,
+	"1/4": 0.25,
+	"1/2": 0.5,
+}
+
+// ParseChallengeRating разбирает показатель опасности из строки вида
+// "1/4", "0.25", "17" или "17 (18,000 XP)". Пустая строка - CR 0.
+func ParseChallengeRating(value string) (ChallengeRating, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	token := fields[0]
+	if cr, ok := fractionalRatings[token]; ok {
+		return cr, nil
+	}
+	number, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid challenge rating %q", value)
+	}
+	cr := ChallengeRating(number)
+	if !cr.Valid() {
+		return 0, fmt.Errorf("invalid challenge rating %q", value)
+	}
+	return cr, nil
+}
+
+// Valid сообщает, есть ли такой показатель в таблице SRD.
+func (cr ChallengeRating) Valid() bool {
+	_, ok := challengeXP[cr]
+	return ok
+}
+
+// XP опыт за победу над монстром.
+func (cr ChallengeRating) XP() int {
+	return challengeXP[cr]
+}
+
+// ProficiencyBonus бонус мастерства монстра: +2 до CR 4, далее +1 каждые 4 CR.
+func (cr ChallengeRating) ProficiencyBonus() int {
+	if cr < 1 {
+		return 2
+	}
+	return 2 + (int(math.Ceil(float64(cr)))-1)/4
+}
+
+// Step сдвигает показатель опасности на steps ступеней шкалы SRD
+// (0, 1/8, 1/4, 1/2, 1, 2, ...) в пределах от 0 до 30.
+func (cr ChallengeRating) Step(steps int) ChallengeRating {
+	ladder := make([]ChallengeRating, 0, len(challengeXP))
+	for rating := range challengeXP {
+		ladder = append(ladder, rating)
+	}
+	slices.Sort(ladder)
+	index, _ := slices.BinarySearch(ladder, cr)
+	return ladder[min(len(ladder)-1, max(0, index+steps))]
+}
+
+func (cr ChallengeRating) String() string {
+	for text, value := range fractionalRatings {
+		if value == cr {
+			return text
+		}
+	}
+	return strconv.FormatFloat(float64(cr), 'f', -1, 64)
+}
+
+func (cr ChallengeRating) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cr.String())
+}
+
+func (cr *ChallengeRating) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var number float64
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("challenge rating must be a string or a number")
+		}
+		text = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	parsed, err := ParseChallengeRating(text)
+	if err != nil {
+		return err
+	}
+	*cr = parsed
+	return nil
+}
@@ -0,0 +1,64 @@
+package monsters
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseChallengeRating(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  ChallengeRating
+		xp    int
+		bonus int
+	}{
+		{"17 (18,000 XP)", 17, 18000, 6},
+		{"1/4", 0.25, 50, 2},
+		{"1/8 (25 XP)", 0.125, 25, 2},
+		{"0.5", 0.5, 100, 2},
+		{"5", 5, 1800, 3},
+		{"30", 30, 155000, 9},
+		{"", 0, 10, 2},
+	}
+
+	for _, tt := range tests {
+		cr, err := ParseChallengeRating(tt.input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.input, err)
+		}
+		if cr != tt.want || cr.XP() != tt.xp || cr.ProficiencyBonus() != tt.bonus {
+			t.Fatalf("%q: got CR %v, XP %d, bonus %d", tt.input, cr, cr.XP(), cr.ProficiencyBonus())
+		}
+	}
+
+	for _, invalid := range []string{"1/3", "31", "-1", "2.5", "dragon"} {
+		if _, err := ParseChallengeRating(invalid); err == nil {
+			t.Fatalf("%q: expected error", invalid)
+		}
+	}
+}
+
+func TestChallengeRatingJSON(t *testing.T) {
+	t.Parallel()
+
+	var m Monster
+	if err := json.Unmarshal([]byte(`{"challengeRating":"13 (10,000 XP)"}`), &m); err != nil {
+		t.Fatalf("unmarshal legacy string: %v", err)
+	}
+	if m.ChallengeRating != 13 {
+		t.Fatalf("expected CR 13, got %v", m.ChallengeRating)
+	}
+	if err := json.Unmarshal([]byte(`{"challengeRating":0.25}`), &m); err != nil {
+		t.Fatalf("unmarshal number: %v", err)
+	}
+
+	data, err := json.Marshal(m.ChallengeRating)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	if string(data) != `"1/4"` {
+		t.Fatalf("expected \"1/4\", got %s", data)
+	}
+}
@@ -0,0 +1,27 @@
+package monsters
+
+import (
+	"regexp"
+	"strconv"
+
+	"dice-service/internal/conditions"
+)
+
+var speedValuePattern = regexp.MustCompile(`(\d+)(\s*(?:ft|фт))`)
+
+// EffectiveSpeed возвращает строку скорости монстра с учётом наложенных состояний:
+// при скорости 0 все значения обнуляются, при истощении 2+ - делятся пополам.
+func EffectiveSpeed(m Monster) string {
+	effects := conditions.Summarize(m.Conditions)
+	if !effects.SpeedZero && !effects.SpeedHalved {
+		return m.Speed
+	}
+	return speedValuePattern.ReplaceAllStringFunc(m.Speed, func(match string) string {
+		parts := speedValuePattern.FindStringSubmatch(match)
+		value, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return match
+		}
+		return strconv.Itoa(effects.Speed(value)) + parts[2]
+	})
+}
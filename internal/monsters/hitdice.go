@@ -0,0 +1,128 @@
+package monsters
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"dice-service/internal/dice"
+)
+
+// Способы определения хитов копии монстра.
+const (
+	HPFixed   = "fixed"   // хиты из карточки монстра (по умолчанию)
+	HPAverage = "average" // среднее значение костей хитов
+	HPRoll    = "roll"    // бросок костей хитов
+	HPMax     = "max"     // максимум костей хитов
+)
+
+// sizeHitDie кость хитов по размеру существа.
+var sizeHitDie = map[string]int{
+	"tiny":       4,
+	"small":      6,
+	"medium":     8,
+	"large":      10,
+	"huge":       12,
+	"gargantuan": 20,
+}
+
+// HitDice разобранные кости хитов вида "19d12+133".
+type HitDice struct {
+	Count    int
+	Sides    int
+	Modifier int
+}
+
+// ParseHitDice разбирает кости хитов: одна группа костей и необязательный модификатор.
+func ParseHitDice(text string) (HitDice, error) {
+	expr, err := dice.ParseExpression(text)
+	if err != nil {
+		return HitDice{}, fmt.Errorf("invalid hit dice %q: %w", text, err)
+	}
+	if len(expr.Dice) != 1 || expr.Dice[0].Sign != 1 {
+		return HitDice{}, fmt.Errorf("invalid hit dice %q: expected a single dice group like 8d10+40", text)
+	}
+	return HitDice{Count: expr.Dice[0].Count, Sides: expr.Dice[0].Sides, Modifier: expr.Modifier}, nil
+}
+
+// Average среднее значение хитов (с округлением вниз, как в SRD).
+func (h HitDice) Average() int {
+	return h.Count*(h.Sides+1)/2 + h.Modifier
+}
+
+// Max наибольшее значение хитов.
+func (h HitDice) Max() int {
+	return h.Count*h.Sides + h.Modifier
+}
+
+// String записывает кости хитов в виде "19d12+133".
+func (h HitDice) String() string {
+	return dice.Expression{Dice: []dice.DiceTerm{{Count: h.Count, Sides: h.Sides, Sign: 1}}, Modifier: h.Modifier}.String()
+}
+
+// ValidateHitDice проверяет, что кости хитов разбираются и соответствуют
+// размеру существа и модификатору Телосложения (модификатор = число костей × мод. CON).
+// Проверяется только при создании и импорте монстра: сохранённые ранее
+// и самодельные монстры с другими костями остаются изменяемыми.
+func ValidateHitDice(m Monster) error {
+	if strings.TrimSpace(m.HitDice) == "" {
+		return nil
+	}
+	hd, err := ParseHitDice(m.HitDice)
+	if err != nil {
+		return err
+	}
+	if sides, ok := sizeHitDie[strings.ToLower(m.Size)]; ok && hd.Sides != sides {
+		return fmt.Errorf("hit dice %q do not match size %s (expected d%d)", m.HitDice, m.Size, sides)
+	}
+	if con, ok := m.AbilityScores["CON"]; ok {
+		if want := hd.Count * AbilityModifier(con); hd.Modifier != want {
+			return fmt.Errorf("hit dice %q do not match CON %d (expected modifier %+d)", m.HitDice, con, want)
+		}
+	}
+	return nil
+}
+
+// AbilityModifier модификатор характеристики: (значение - 10) / 2 с округлением вниз.
+func AbilityModifier(score int) int {
+	if score >= 10 {
+		return (score - 10) / 2
+	}
+	return (score - 11) / 2
+}
+
+// RollHitPoints определяет хиты копии монстра выбранным способом.
+// Пустой способ и HPFixed возвращают хиты из карточки.
+func RollHitPoints(m Monster, mode string) (int, error) {
+	if mode == "" || mode == HPFixed {
+		return m.HitPoints, nil
+	}
+	if mode != HPAverage && mode != HPRoll && mode != HPMax {
+		return 0, fmt.Errorf("unknown hp mode %q", mode)
+	}
+	if strings.TrimSpace(m.HitDice) == "" {
+		return 0, errors.New("monster has no hit dice")
+	}
+	hd, err := ParseHitDice(m.HitDice)
+	if err != nil {
+		return 0, err
+	}
+
+	var hp int
+	switch mode {
+	case HPAverage:
+		hp = hd.Average()
+	case HPMax:
+		hp = hd.Max()
+	case HPRoll:
+		rolled, err := dice.Roll(dice.Expression{
+			Dice:     []dice.DiceTerm{{Count: hd.Count, Sides: hd.Sides, Sign: 1}},
+			Modifier: hd.Modifier,
+		})
+		if err != nil {
+			return 0, err
+		}
+		hp = rolled.Total
+	}
+	return max(1, hp), nil
+}
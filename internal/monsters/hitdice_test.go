@@ -0,0 +1,49 @@
+package monsters
+
+import "testing"
+
+func TestRollHitPoints(t *testing.T) {
+	t.Parallel()
+
+	troll := Monster{
+		Name: "Troll", Type: "Giant", Size: "Large", ArmorClass: 15, HitPoints: 84,
+		HitDice: "8d10+40", AbilityScores: map[string]int{"CON": 20},
+	}
+	if err := troll.Validate(); err != nil {
+		t.Fatalf("validate error: %v", err)
+	}
+
+	for mode, want := range map[string]int{"": 84, HPFixed: 84, HPAverage: 84, HPMax: 120} {
+		hp, err := RollHitPoints(troll, mode)
+		if err != nil || hp != want {
+			t.Fatalf("mode %q: got %d, %v; want %d", mode, hp, err, want)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		hp, err := RollHitPoints(troll, HPRoll)
+		if err != nil || hp < 48 || hp > 120 {
+			t.Fatalf("rolled hp out of range: %d, %v", hp, err)
+		}
+	}
+	if _, err := RollHitPoints(troll, "luck"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
+
+func TestValidateHitDice(t *testing.T) {
+	t.Parallel()
+
+	base := Monster{Name: "Troll", Type: "Giant", Size: "Large", ArmorClass: 15, HitPoints: 84,
+		AbilityScores: map[string]int{"CON": 20}}
+	for _, hitDice := range []string{"8d12+40", "8d10+30", "8d10+1d4", "many"} {
+		m := base
+		m.HitDice = hitDice
+		if err := ValidateHitDice(m); err == nil {
+			t.Fatalf("%q: expected validation error", hitDice)
+		}
+		// Уже сохранённый монстр с такими костями остаётся изменяемым
+		if err := m.Validate(); err != nil {
+			t.Fatalf("%q: unexpected Validate error: %v", hitDice, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package monsters
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/conditions"
+)
+
+// DefaultLegendaryActions число легендарных действий за раунд у монстра
+// с легендарными действиями.
+const DefaultLegendaryActions = 3
+
+// MaxInstances наибольшее число экземпляров, создаваемых за раз.
+const MaxInstances = 50
+
+// Instance экземпляр монстра в компании или бою: ссылается на карточку
+// монстра и хранит собственное состояние - хиты, состояния, реакцию,
+// легендарные действия и перезарядку.
+type Instance struct {
+	ID                 string                 `json:"id"`
+	MonsterID          string                 `json:"monsterId"`
+	Name               string                 `json:"name"` // «Гоблин 3»
+	MaxHitPoints       int                    `json:"maxHitPoints"`
+	CurrentHitPoints   int                    `json:"currentHitPoints"`
+	TemporaryHitPoints int                    `json:"temporaryHitPoints,omitempty"`
+	Conditions         []conditions.Condition `json:"conditions,omitempty"`
+	ReactionUsed       bool                   `json:"reactionUsed,omitempty"`
+	LegendaryActions   int                    `json:"legendaryActions,omitempty"`         // осталось до начала своего хода
+	LegendaryPerRound  int                    `json:"legendaryActionsPerRound,omitempty"` // восстанавливается в начале хода
+	Spent              []string               `json:"spent,omitempty"`                    // перезаряжаемые действия, ждущие перезарядки
+	Used               map[string]int         `json:"used,omitempty"`                     // использований за день по действиям
+}
+
+func (i Instance) Validate() error {
+	if i.MonsterID == "" {
+		return errors.New("monsterId is required")
+	}
+	if strings.TrimSpace(i.Name) == "" {
+		return errors.New("instance name is required")
+	}
+	if i.MaxHitPoints < 1 {
+		return errors.New("max hit points must be at least 1")
+	}
+	if i.CurrentHitPoints < 0 || i.CurrentHitPoints > i.MaxHitPoints {
+		return fmt.Errorf("current hit points must be between 0 and %d", i.MaxHitPoints)
+	}
+	if i.TemporaryHitPoints < 0 {
+		return errors.New("temporary hit points must not be negative")
+	}
+	if i.LegendaryActions < 0 || i.LegendaryActions > i.LegendaryPerRound {
+		return fmt.Errorf("legendary actions must be between 0 and %d", i.LegendaryPerRound)
+	}
+	for _, c := range i.Conditions {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewInstances создаёт count экземпляров монстра. Хиты каждого
+// определяются способом hpMode (см. RollHitPoints), при броске - отдельно
+// для каждого. Имена нумеруются после уже существующих экземпляров
+// этого монстра в existing.
+func NewInstances(m Monster, count int, hpMode string, existing []Instance) ([]Instance, error) {
+	if count < 1 || count > MaxInstances {
+		return nil, fmt.Errorf("count must be between 1 and %d", MaxInstances)
+	}
+	number := 0
+	for _, inst := range existing {
+		if inst.MonsterID != m.ID {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(inst.Name, m.Name+" ")); err == nil {
+			number = max(number, n)
+		}
+	}
+
+	result := make([]Instance, 0, count)
+	for n := 0; n < count; n++ {
+		hp, err := RollHitPoints(m, hpMode)
+		if err != nil {
+			return nil, err
+		}
+		number++
+		inst := Instance{
+			ID:               generateID(),
+			MonsterID:        m.ID,
+			Name:             fmt.Sprintf("%s %d", m.Name, number),
+			MaxHitPoints:     hp,
+			CurrentHitPoints: hp,
+		}
+		if len(m.LegendaryActions) > 0 {
+			inst.LegendaryPerRound = DefaultLegendaryActions
+			inst.LegendaryActions = DefaultLegendaryActions
+		}
+		result = append(result, inst)
+	}
+	return result, nil
+}
+
+// Damage наносит урон: сначала расходуются временные хиты, хиты
+// не опускаются ниже нуля.
+func (i *Instance) Damage(amount int) error {
+	if amount < 0 {
+		return errors.New("damage amount must not be negative")
+	}
+	absorbed := min(i.TemporaryHitPoints, amount)
+	i.TemporaryHitPoints -= absorbed
+	i.CurrentHitPoints = max(0, i.CurrentHitPoints-(amount-absorbed))
+	return nil
+}
+
+// AdjustDamage урон типа damageType по монстру с учётом иммунитетов,
+// сопротивлений и уязвимостей карточки: при иммунитете урона нет, при
+// сопротивлении он уменьшается вдвое с округлением вниз, при уязвимости
+// удваивается (сопротивление применяется первым). Второе значение -
+// "immune", "resistant", "vulnerable", "resistant and vulnerable" или
+// пусто. Без типа урон не меняется.
+func AdjustDamage(m Monster, amount int, damageType string) (int, string) {
+	if damageType == "" {
+		return amount, ""
+	}
+	if matchesDamageType(m.DamageImmunities, damageType) {
+		return 0, "immune"
+	}
+	var adjustments []string
+	if matchesDamageType(m.DamageResistances, damageType) {
+		amount /= 2
+		adjustments = append(adjustments, "resistant")
+	}
+	if matchesDamageType(m.DamageVulnerabilities, damageType) {
+		amount *= 2
+		adjustments = append(adjustments, "vulnerable")
+	}
+	return amount, strings.Join(adjustments, " and ")
+}
+
+// matchesDamageType запись списка относится к типу урона: совпадает
+// с ним или начинается с него («piercing from nonmagical attacks»).
+func matchesDamageType(list []string, damageType string) bool {
+	for _, entry := range list {
+		fields := strings.Fields(entry)
+		if len(fields) > 0 && strings.EqualFold(strings.Trim(fields[0], ",;"), damageType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Heal восстанавливает хиты, не выше максимума.
+func (i *Instance) Heal(amount int) error {
+	if amount < 0 {
+		return errors.New("heal amount must not be negative")
+	}
+	i.CurrentHitPoints = min(i.MaxHitPoints, i.CurrentHitPoints+amount)
+	return nil
+}
+
+// Defeated экземпляр лишился всех хитов.
+func (i Instance) Defeated() bool {
+	return i.CurrentHitPoints == 0
+}
+
+// StartTurn в начале хода монстр восстанавливает реакцию
+// и легендарные действия.
+func (i *Instance) StartTurn() {
+	i.ReactionUsed = false
+	i.LegendaryActions = i.LegendaryPerRound
+}
+
+// UseReaction тратит реакцию до начала следующего хода.
+func (i *Instance) UseReaction() error {
+	if i.ReactionUsed {
+		return errors.New("reaction is already used this round")
+	}
+	i.ReactionUsed = true
+	return nil
+}
+
+// UseLegendaryAction тратит cost легендарных действий (обычно 1-3).
+func (i *Instance) UseLegendaryAction(cost int) error {
+	if cost < 1 {
+		return errors.New("legendary action cost must be at least 1")
+	}
+	if cost > i.LegendaryActions {
+		return fmt.Errorf("only %d legendary actions left", i.LegendaryActions)
+	}
+	i.LegendaryActions -= cost
+	return nil
+}
+
+// Apply возвращает карточку монстра с состоянием экземпляра: именем,
+// хитами, состояниями, перезарядкой и использованиями действий.
+// Результат можно передать в RollAction.
+func (i Instance) Apply(m Monster) Monster {
+	m.Name = i.Name
+	m.HitPoints = max(1, i.CurrentHitPoints)
+	m.Conditions = append([]conditions.Condition(nil), i.Conditions...)
+	for _, list := range []*[]Action{&m.Actions, &m.LegendaryActions, &m.Traits} {
+		actions := append([]Action(nil), (*list)...)
+		for j := range actions {
+			actions[j].Spent = slices.Contains(i.Spent, actions[j].Name)
+			actions[j].Used = i.Used[actions[j].Name]
+		}
+		*list = actions
+	}
+	return m
+}
+
+// Capture запоминает перезарядку и использования действий из карточки,
+// полученной от Apply и изменённой RollAction.
+func (i *Instance) Capture(m Monster) {
+	i.Spent, i.Used = nil, nil
+	for _, list := range [][]Action{m.Actions, m.LegendaryActions, m.Traits} {
+		for _, a := range list {
+			if a.Spent {
+				i.Spent = append(i.Spent, a.Name)
+			}
+			if a.Used > 0 {
+				if i.Used == nil {
+					i.Used = make(map[string]int)
+				}
+				i.Used[a.Name] = a.Used
+			}
+		}
+	}
+}
+
+// Clone копия экземпляра, не разделяющая списки с исходным.
+func (i Instance) Clone() Instance {
+	i.Conditions = append([]conditions.Condition(nil), i.Conditions...)
+	i.Spent = append([]string(nil), i.Spent...)
+	if i.Used != nil {
+		used := make(map[string]int, len(i.Used))
+		for name, n := range i.Used {
+			used[name] = n
+		}
+		i.Used = used
+	}
+	return i
+}
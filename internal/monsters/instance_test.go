@@ -0,0 +1,138 @@
+package monsters
+
+import "testing"
+
+func TestNewInstances(t *testing.T) {
+	t.Parallel()
+
+	goblin := Monster{ID: "goblin", Name: "Гоблин", HitPoints: 7, HitDice: "2d6"}
+	first, err := NewInstances(goblin, 2, HPMax, nil)
+	if err != nil {
+		t.Fatalf("NewInstances error: %v", err)
+	}
+	if first[0].Name != "Гоблин 1" || first[1].Name != "Гоблин 2" || first[0].ID == first[1].ID {
+		t.Fatalf("unexpected instances: %+v", first)
+	}
+	if first[0].MaxHitPoints != 12 || first[0].CurrentHitPoints != 12 {
+		t.Fatalf("expected max hit points 12, got %+v", first[0])
+	}
+
+	// Нумерация продолжается после уже существующих, даже если часть удалена
+	more, err := NewInstances(goblin, 1, "", first[1:])
+	if err != nil || more[0].Name != "Гоблин 3" || more[0].MaxHitPoints != 7 {
+		t.Fatalf("unexpected instance: %+v, %v", more, err)
+	}
+
+	if _, err := NewInstances(goblin, 0, "", nil); err == nil {
+		t.Fatal("expected error for zero count")
+	}
+	if _, err := NewInstances(goblin, 1, "bogus", nil); err == nil {
+		t.Fatal("expected error for unknown hp mode")
+	}
+}
+
+func TestInstanceState(t *testing.T) {
+	t.Parallel()
+
+	dragon := GetSampleMonsters()[0]
+	dragon.ID = "dragon"
+	dragon.LegendaryActions = []Action{{Name: "Удар хвостом", Description: "Дракон бьёт хвостом."}}
+	instances, err := NewInstances(dragon, 1, HPFixed, nil)
+	if err != nil {
+		t.Fatalf("NewInstances error: %v", err)
+	}
+	inst := instances[0]
+	if inst.LegendaryPerRound != DefaultLegendaryActions || inst.LegendaryActions != DefaultLegendaryActions {
+		t.Fatalf("expected legendary actions, got %+v", inst)
+	}
+
+	inst.TemporaryHitPoints = 10
+	if err := inst.Damage(30); err != nil {
+		t.Fatalf("Damage error: %v", err)
+	}
+	if inst.TemporaryHitPoints != 0 || inst.CurrentHitPoints != dragon.HitPoints-20 {
+		t.Fatalf("temporary hit points should absorb damage first: %+v", inst)
+	}
+	if err := inst.Heal(1000); err != nil || inst.CurrentHitPoints != inst.MaxHitPoints {
+		t.Fatalf("heal should stop at max: %+v", inst)
+	}
+	if err := inst.Damage(-1); err == nil {
+		t.Fatal("expected error for negative damage")
+	}
+
+	if err := inst.UseLegendaryAction(2); err != nil {
+		t.Fatalf("UseLegendaryAction error: %v", err)
+	}
+	if err := inst.UseLegendaryAction(2); err == nil {
+		t.Fatal("expected error when not enough legendary actions are left")
+	}
+	if err := inst.UseReaction(); err != nil {
+		t.Fatalf("UseReaction error: %v", err)
+	}
+	if err := inst.UseReaction(); err == nil {
+		t.Fatal("expected error for a second reaction")
+	}
+	inst.StartTurn()
+	if inst.ReactionUsed || inst.LegendaryActions != DefaultLegendaryActions {
+		t.Fatalf("StartTurn should restore reaction and legendary actions: %+v", inst)
+	}
+}
+
+func TestInstanceRechargeIsPerInstance(t *testing.T) {
+	t.Parallel()
+
+	dragon := GetSampleMonsters()[0]
+	dragon.ID = "dragon"
+	instances, err := NewInstances(dragon, 2, HPFixed, nil)
+	if err != nil {
+		t.Fatalf("NewInstances error: %v", err)
+	}
+
+	_, updated, err := RollAction(instances[0].Apply(dragon), "Огненное дыхание", ActionRollOptions{})
+	if err != nil || updated == nil {
+		t.Fatalf("RollAction = %v, %v", updated, err)
+	}
+	instances[0].Capture(*updated)
+	if len(instances[0].Spent) != 1 || instances[0].Spent[0] != "Огненное дыхание" {
+		t.Fatalf("expected spent breath, got %+v", instances[0].Spent)
+	}
+	if len(instances[1].Spent) != 0 {
+		t.Fatal("recharge state must not leak to other instances")
+	}
+	for _, a := range dragon.Actions {
+		if a.Spent {
+			t.Fatal("recharge state must not leak to the monster card")
+		}
+	}
+	if view := instances[0].Apply(dragon); view.Name != "Красный Дракон 1" || !view.Actions[2].Spent {
+		t.Fatalf("unexpected applied monster: %s %+v", view.Name, view.Actions[2])
+	}
+}
+
+func TestAdjustDamage(t *testing.T) {
+	t.Parallel()
+
+	m := Monster{
+		DamageResistances:     []string{"cold", "bludgeoning from nonmagical attacks"},
+		DamageImmunities:      []string{"poison"},
+		DamageVulnerabilities: []string{"fire", "bludgeoning"},
+	}
+	cases := []struct {
+		damageType string
+		want       int
+		adjustment string
+	}{
+		{"", 9, ""},
+		{"acid", 9, ""},
+		{"fire", 18, "vulnerable"},
+		{"Cold", 4, "resistant"},
+		{"bludgeoning", 8, "resistant and vulnerable"},
+		{"poison", 0, "immune"},
+	}
+	for _, tc := range cases {
+		got, adjustment := AdjustDamage(m, 9, tc.damageType)
+		if got != tc.want || adjustment != tc.adjustment {
+			t.Errorf("AdjustDamage(9, %q) = %d, %q; want %d, %q", tc.damageType, got, adjustment, tc.want, tc.adjustment)
+		}
+	}
+}
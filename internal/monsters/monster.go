@@ -1,32 +1,46 @@
 package monsters
 
-import "errors"
+import (
+	"errors"
+
+	"dice-service/internal/conditions"
+)
 
 var ErrNotFound = errors.New("monster not found")
+var ErrVersionConflict = errors.New("monster was modified by another request")
 
 type Monster struct {
-	ID              string            `json:"id"`
-	Name            string            `json:"name"`
-	Type            string            `json:"type"`            // например: "Beast", "Undead", "Dragon"
-	Size            string            `json:"size"`            // Tiny, Small, Medium, Large, Huge, Gargantuan
-	Alignment       string            `json:"alignment"`       // например: "Chaotic Evil"
-	ArmorClass      int               `json:"armorClass"`
-	HitPoints       int               `json:"hitPoints"`
-	HitDice          string            `json:"hitDice"`         // например: "10d8+30"
-	Speed            string            `json:"speed"`           // например: "30 ft., fly 60 ft."
-	AbilityScores    map[string]int    `json:"abilityScores"`  // STR, DEX, CON, INT, WIS, CHA
-	Skills           map[string]int    `json:"skills"`          // например: {"Perception": 5, "Stealth": 4}
-	SavingThrows     map[string]int    `json:"savingThrows"`   // например: {"DEX": 6, "CON": 8}
-	DamageResistances []string         `json:"damageResistances"`
-	DamageImmunities  []string         `json:"damageImmunities"`
-	ConditionImmunities []string       `json:"conditionImmunities"`
-	Senses            string            `json:"senses"`         // например: "darkvision 60 ft."
-	Languages         []string         `json:"languages"`
-	ChallengeRating   string            `json:"challengeRating"` // например: "5 (1,800 XP)"
-	Traits            []string         `json:"traits"`          // особенности
-	Actions           []string         `json:"actions"`         // действия
-	LegendaryActions  []string         `json:"legendaryActions"` // легендарные действия
-	Description       string           `json:"description"`
+	ID                    string                 `json:"id"`
+	Version               int                    `json:"version"` // растёт при каждом сохранении; 0 в запросе - без проверки
+	Name                  string                 `json:"name"`
+	Type                  string                 `json:"type"`      // например: "Beast", "Undead", "Dragon"
+	Size                  string                 `json:"size"`      // Tiny, Small, Medium, Large, Huge, Gargantuan
+	Alignment             string                 `json:"alignment"` // например: "Chaotic Evil"
+	ArmorClass            int                    `json:"armorClass"`
+	HitPoints             int                    `json:"hitPoints"`
+	HitDice               string                 `json:"hitDice"`       // например: "10d8+30"
+	Speed                 string                 `json:"speed"`         // например: "30 ft., fly 60 ft."
+	AbilityScores         map[string]int         `json:"abilityScores"` // STR, DEX, CON, INT, WIS, CHA
+	Skills                map[string]int         `json:"skills"`        // например: {"Perception": 5, "Stealth": 4}
+	SavingThrows          map[string]int         `json:"savingThrows"`  // например: {"DEX": 6, "CON": 8}
+	DamageVulnerabilities []string               `json:"damageVulnerabilities,omitempty"`
+	DamageResistances     []string               `json:"damageResistances"`
+	DamageImmunities      []string               `json:"damageImmunities"`
+	ConditionImmunities   []string               `json:"conditionImmunities"`
+	Senses                string                 `json:"senses"` // например: "darkvision 60 ft."
+	Languages             []string               `json:"languages"`
+	ChallengeRating       ChallengeRating        `json:"challengeRating"`  // например: "5", "1/4"
+	XP                    int                    `json:"xp"`               // вычисляется по показателю опасности
+	ProficiencyBonus      int                    `json:"proficiencyBonus"` // вычисляется по показателю опасности
+	Traits                []Action               `json:"traits"`           // особенности
+	Actions               []Action               `json:"actions"`          // действия
+	LegendaryActions      []Action               `json:"legendaryActions"` // легендарные действия
+	Description           string                 `json:"description"`
+	Environments          []string               `json:"environments,omitempty"` // местности обитания: "forest", "mountain", "underdark"...
+	ScaledFrom            string                 `json:"scaledFrom,omitempty"`   // ID монстра, из которого получен масштабированием
+	BaseID                string                 `json:"baseId,omitempty"`       // ID базового монстра для производного по шаблону
+	TemplateID            string                 `json:"templateId,omitempty"`   // ID применённого шаблона
+	Conditions            []conditions.Condition `json:"conditions,omitempty"`   // состояния копии монстра в компании
 }
 
 func (m Monster) Validate() error {
@@ -42,12 +56,26 @@ func (m Monster) Validate() error {
 	if m.HitPoints < 1 {
 		return errors.New("hit points must be at least 1")
 	}
+	if !m.ChallengeRating.Valid() {
+		return errors.New("invalid challenge rating")
+	}
+	for _, list := range [][]Action{m.Traits, m.Actions, m.LegendaryActions} {
+		for _, a := range list {
+			if err := a.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range m.Conditions {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-
-
-
-
-
-
+// Normalize заполняет опыт и бонус мастерства по показателю опасности.
+func (m *Monster) Normalize() {
+	m.XP = m.ChallengeRating.XP()
+	m.ProficiencyBonus = m.ChallengeRating.ProficiencyBonus()
+}
@@ -0,0 +1,190 @@
+package monsters
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Поля сортировки списка монстров.
+const (
+	SortByName = "name"
+	SortByType = "type"
+	SortByCR   = "cr"
+)
+
+var sortColumns = map[string]string{
+	SortByName: "LOWER(name)",
+	SortByType: "LOWER(type)",
+	SortByCR:   "challenge_rating",
+}
+
+// Query фильтры, сортировка и страница для списка монстров.
+type Query struct {
+	Name        string // подстрока названия без учёта регистра
+	Type        string
+	Size        string
+	Environment string // местность обитания без учёта регистра
+	CRMin       *ChallengeRating
+	CRMax       *ChallengeRating
+	BaseID      string // производные от монстра с этим ID
+	TemplateID  string // полученные этим шаблоном
+	Sort        string
+	Desc        bool
+	Limit       int // 0 - все записи
+	Offset      int
+}
+
+// Page страница списка монстров.
+type Page struct {
+	Items []Monster
+	Total int // число записей, подходящих под фильтры
+}
+
+func (q Query) Validate() error {
+	if q.Sort != "" {
+		if _, ok := sortColumns[q.Sort]; !ok {
+			return fmt.Errorf("unknown sort field %q", q.Sort)
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return errors.New("limit and offset must not be negative")
+	}
+	if q.CRMin != nil && q.CRMax != nil && *q.CRMin > *q.CRMax {
+		return errors.New("invalid challenge rating range")
+	}
+	return nil
+}
+
+func (q Query) matches(m Monster) bool {
+	if q.Name != "" && !strings.Contains(strings.ToLower(m.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.Type != "" && !strings.EqualFold(m.Type, q.Type) {
+		return false
+	}
+	if q.Size != "" && !strings.EqualFold(m.Size, q.Size) {
+		return false
+	}
+	if q.Environment != "" && !slices.ContainsFunc(m.Environments, func(e string) bool { return strings.EqualFold(e, q.Environment) }) {
+		return false
+	}
+	if q.CRMin != nil && m.ChallengeRating < *q.CRMin {
+		return false
+	}
+	if q.CRMax != nil && m.ChallengeRating > *q.CRMax {
+		return false
+	}
+	if q.BaseID != "" && m.BaseID != q.BaseID {
+		return false
+	}
+	if q.TemplateID != "" && m.TemplateID != q.TemplateID {
+		return false
+	}
+	return true
+}
+
+// apply фильтрует, сортирует и обрезает список в памяти. Без поля сортировки
+// монстры упорядочиваются по идентификатору, как в PostgresStore.
+func (q Query) apply(list []Monster) Page {
+	filtered := make([]Monster, 0, len(list))
+	for _, m := range list {
+		if q.matches(m) {
+			filtered = append(filtered, m)
+		}
+	}
+
+	// Порядок как в PostgresStore: по полю сортировки, затем по id
+	compare := func(a, b Monster) int {
+		switch q.Sort {
+		case SortByName:
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case SortByType:
+			return strings.Compare(strings.ToLower(a.Type), strings.ToLower(b.Type))
+		case SortByCR:
+			switch {
+			case a.ChallengeRating < b.ChallengeRating:
+				return -1
+			case a.ChallengeRating > b.ChallengeRating:
+				return 1
+			}
+		}
+		return 0
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		c := compare(filtered[i], filtered[j])
+		if q.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return filtered[i].ID < filtered[j].ID
+	})
+
+	page := Page{Total: len(filtered)}
+	start := min(q.Offset, len(filtered))
+	end := len(filtered)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, end)
+	}
+	page.Items = filtered[start:end]
+	return page
+}
+
+// likeEscaper экранирует подстановочные символы LIKE в пользовательском вводе.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlWhere строит условие WHERE для фильтров запроса (параметры начиная с $1).
+func (q Query) sqlWhere() (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.Name != "" {
+		add("name ILIKE '%%' || $%d || '%%' ESCAPE '\\'", likeEscaper.Replace(q.Name))
+	}
+	if q.Type != "" {
+		add("LOWER(type) = LOWER($%d)", q.Type)
+	}
+	if q.Size != "" {
+		add("LOWER(data->>'size') = LOWER($%d)", q.Size)
+	}
+	if q.Environment != "" {
+		add("EXISTS (SELECT 1 FROM jsonb_array_elements_text(COALESCE(data->'environments', '[]')) env WHERE LOWER(env) = LOWER($%d))", q.Environment)
+	}
+	if q.CRMin != nil {
+		add("challenge_rating >= $%d", float64(*q.CRMin))
+	}
+	if q.CRMax != nil {
+		add("challenge_rating <= $%d", float64(*q.CRMax))
+	}
+	if q.BaseID != "" {
+		add("data->>'baseId' = $%d", q.BaseID)
+	}
+	if q.TemplateID != "" {
+		add("data->>'templateId' = $%d", q.TemplateID)
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// sqlOrder строит ORDER BY; id в конце делает порядок страниц устойчивым.
+func (q Query) sqlOrder() string {
+	column, ok := sortColumns[q.Sort]
+	if !ok {
+		return "id"
+	}
+	if q.Desc {
+		return column + " DESC, id"
+	}
+	return column + ", id"
+}
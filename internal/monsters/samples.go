@@ -13,8 +13,15 @@ func GetSampleMonsters() []Monster {
 			HitDice:          "19d12+133",
 			Speed:            "40 ft., climb 40 ft., fly 80 ft.",
 			AbilityScores:   map[string]int{"STR": 27, "DEX": 10, "CON": 25, "INT": 16, "WIS": 13, "CHA": 21},
-			ChallengeRating: "17 (18,000 XP)",
+			ChallengeRating: 17,
+			Environments:    []string{"mountain", "hill"},
 			Description:      "Древний красный дракон - одно из самых могущественных существ в мире. Его огненное дыхание может испепелить целые армии.",
+			Actions: []Action{
+				{Name: "Укус", Type: ActionMelee, AttackBonus: 14, Reach: 10, Damage: []Damage{{Dice: "2d10+8", Type: "piercing"}, {Dice: "2d6", Type: "fire"}}},
+				{Name: "Коготь", Type: ActionMelee, AttackBonus: 14, Reach: 5, Damage: []Damage{{Dice: "2d6+8", Type: "slashing"}}},
+				{Name: "Огненное дыхание", Type: ActionSave, SaveDC: 21, SaveAbility: "DEX", Recharge: 5, Damage: []Damage{{Dice: "18d6", Type: "fire"}},
+					Description: "Дракон выдыхает огонь 60-футовым конусом. Половина урона при успешном спасброске."},
+			},
 		},
 		{
 			Name:            "Лич",
@@ -26,7 +33,8 @@ func GetSampleMonsters() []Monster {
 			HitDice:          "18d8+54",
 			Speed:            "30 ft.",
 			AbilityScores:   map[string]int{"STR": 11, "DEX": 16, "CON": 16, "INT": 20, "WIS": 14, "CHA": 16},
-			ChallengeRating: "21 (33,000 XP)",
+			ChallengeRating: 21,
+			Environments:    []string{"underdark", "urban"},
 			Description:      "Бессмертный некромант, обменявший свою душу на вечную жизнь. Обладает огромной магической силой и может воскрешать мертвых.",
 		},
 		{
@@ -39,7 +47,8 @@ func GetSampleMonsters() []Monster {
 			HitDice:          "19d10+76",
 			Speed:            "0 ft., fly 20 ft. (hover)",
 			AbilityScores:   map[string]int{"STR": 10, "DEX": 14, "CON": 18, "INT": 17, "WIS": 15, "CHA": 17},
-			ChallengeRating: "13 (10,000 XP)",
+			ChallengeRating: 13,
+			Environments:    []string{"underdark"},
 			Description:      "Плавающий глаз с множеством щупалец. Каждое щупальце может использовать магический луч. Крайне параноидальное существо.",
 		},
 		{
@@ -52,7 +61,8 @@ func GetSampleMonsters() []Monster {
 			HitDice:          "17d8+68",
 			Speed:            "30 ft.",
 			AbilityScores:   map[string]int{"STR": 18, "DEX": 18, "CON": 18, "INT": 17, "WIS": 15, "CHA": 18},
-			ChallengeRating: "13 (10,000 XP)",
+			ChallengeRating: 13,
+			Environments:    []string{"urban"},
 			Description:      "Бессмертный вампир, питающийся кровью живых. Обладает способностью превращаться в туман, контролировать разум и регенерировать.",
 		},
 		{
@@ -65,8 +75,16 @@ func GetSampleMonsters() []Monster {
 			HitDice:          "8d10+40",
 			Speed:            "30 ft.",
 			AbilityScores:   map[string]int{"STR": 18, "DEX": 13, "CON": 20, "INT": 7, "WIS": 9, "CHA": 7},
-			ChallengeRating: "5 (1,800 XP)",
+			ChallengeRating: 5,
+			Environments:    []string{"forest", "hill", "mountain", "arctic", "swamp", "underdark"},
 			Description:      "Большое, злобное существо с мощной регенерацией. Может восстанавливать потерянные конечности. Слабость к огню и кислоте.",
+			Traits: []Action{
+				{Name: "Регенерация", Description: "Тролль восстанавливает 10 хитов в начале своего хода, если не получил урон кислотой или огнём."},
+			},
+			Actions: []Action{
+				{Name: "Укус", Type: ActionMelee, AttackBonus: 7, Reach: 5, Damage: []Damage{{Dice: "1d6+4", Type: "piercing"}}},
+				{Name: "Коготь", Type: ActionMelee, AttackBonus: 7, Reach: 5, Damage: []Damage{{Dice: "2d6+4", Type: "slashing"}}},
+			},
 		},
 	}
 }
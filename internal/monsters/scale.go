@@ -0,0 +1,267 @@
+package monsters
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/dice"
+)
+
+// crStats строка таблицы «Характеристики монстра по показателю опасности» (DMG).
+type crStats struct {
+	ArmorClass   int
+	HPMin, HPMax int
+	AttackBonus  int
+	DamageMin    int // урон за раунд
+	DamageMax    int
+	SaveDC       int
+}
+
+func (s crStats) hitPoints() float64 { return float64(s.HPMin+s.HPMax) / 2 }
+func (s crStats) damage() float64    { return float64(s.DamageMin+s.DamageMax) / 2 }
+
+var challengeStats = map[ChallengeRating]crStats{
+	0:     {13, 1, 6, 3, 0, 1, 13},
+	0.125: {13, 7, 35, 3, 2, 3, 13},
+	0.25:  {13, 36, 49, 3, 4, 5, 13},
+	0.5:   {13, 50, 70, 3, 6, 8, 13},
+	1:     {13, 71, 85, 3, 9, 14, 13},
+	2:     {13, 86, 100, 3, 15, 20, 13},
+	3:     {13, 101, 115, 4, 21, 26, 13},
+	4:     {14, 116, 130, 5, 27, 32, 14},
+	5:     {15, 131, 145, 6, 33, 38, 15},
+	6:     {15, 146, 160, 6, 39, 44, 15},
+	7:     {15, 161, 175, 6, 45, 50, 15},
+	8:     {16, 176, 190, 7, 51, 56, 16},
+	9:     {16, 191, 205, 7, 57, 62, 16},
+	10:    {17, 206, 220, 7, 63, 68, 16},
+	11:    {17, 221, 235, 8, 69, 74, 17},
+	12:    {17, 236, 250, 8, 75, 80, 17},
+	13:    {18, 251, 265, 8, 81, 86, 18},
+	14:    {18, 266, 280, 8, 87, 92, 18},
+	15:    {18, 281, 295, 8, 93, 98, 18},
+	16:    {18, 296, 310, 9, 99, 104, 18},
+	17:    {19, 311, 325, 10, 105, 110, 19},
+	18:    {19, 326, 340, 10, 111, 116, 19},
+	19:    {19, 341, 355, 10, 117, 122, 19},
+	20:    {19, 356, 400, 10, 123, 140, 19},
+	21:    {19, 401, 445, 11, 141, 158, 20},
+	22:    {19, 446, 490, 11, 159, 176, 20},
+	23:    {19, 491, 535, 11, 177, 194, 20},
+	24:    {19, 536, 580, 12, 195, 212, 21},
+	25:    {19, 581, 625, 12, 213, 230, 21},
+	26:    {19, 626, 670, 12, 231, 248, 21},
+	27:    {19, 671, 715, 13, 249, 266, 22},
+	28:    {19, 716, 760, 13, 267, 284, 22},
+	29:    {19, 761, 805, 13, 285, 302, 22},
+	30:    {19, 806, 850, 14, 303, 320, 23},
+}
+
+var (
+	scaledNamePattern = regexp.MustCompile(`\s*\(CR [\d/]+\)$`)
+	textAttackPattern = regexp.MustCompile(`(?i)((?:attack(?: roll)?|атака(?: оружием| заклинанием)?):\s*)([+\-−–]\s*\d+)`)
+	textSaveDCPattern = regexp.MustCompile(`(DC|СЛ)\s*(\d+)`)
+	textDamagePattern = regexp.MustCompile(`\d+\s*\(\s*\d+\s*[dDкК]\s*\d+(?:\s*[+\-−–]\s*\d+)?\s*\)`)
+)
+
+// Scale возвращает копию монстра, пересчитанную под показатель опасности
+// target по таблице DMG: КД, бонус атаки и СЛ сдвигаются на разницу строк
+// таблицы, хиты и урон масштабируются пропорционально средним значениям.
+// Кости хитов и выражения урона переписываются с сохранением вида костей.
+// Копия не сохранена (пустой ID) и ссылается на исходного монстра.
+func Scale(m Monster, target ChallengeRating) (Monster, error) {
+	if !target.Valid() {
+		return Monster{}, fmt.Errorf("invalid challenge rating %s", target)
+	}
+	from, ok := challengeStats[m.ChallengeRating]
+	if !ok {
+		return Monster{}, fmt.Errorf("invalid challenge rating %s", m.ChallengeRating)
+	}
+	to := challengeStats[target]
+
+	scaled := m
+	scaled.ID, scaled.Version = "", 0
+	scaled.ScaledFrom = m.ID
+	scaled.Name = fmt.Sprintf("%s (CR %s)", scaledNamePattern.ReplaceAllString(m.Name, ""), target)
+	scaled.ChallengeRating = target
+	scaled.Conditions = nil
+	scaled.Normalize()
+
+	scaled.ArmorClass = min(30, max(0, m.ArmorClass+to.ArmorClass-from.ArmorClass))
+	if err := scaleHitPoints(&scaled, float64(m.HitPoints)*to.hitPoints()/from.hitPoints()); err != nil {
+		return Monster{}, err
+	}
+
+	proficiency := scaled.ProficiencyBonus - m.ChallengeRating.ProficiencyBonus()
+	scaled.SavingThrows = shiftBonuses(m.SavingThrows, proficiency)
+	scaled.Skills = shiftBonuses(m.Skills, proficiency)
+
+	adjust := actionAdjustment{
+		attack: to.AttackBonus - from.AttackBonus,
+		saveDC: to.SaveDC - from.SaveDC,
+		damage: 1,
+	}
+	if from.damage() > 0 {
+		adjust.damage = to.damage() / from.damage()
+	}
+	var err error
+	if scaled.Traits, err = adjust.apply(m.Traits); err != nil {
+		return Monster{}, err
+	}
+	if scaled.Actions, err = adjust.apply(m.Actions); err != nil {
+		return Monster{}, err
+	}
+	if scaled.LegendaryActions, err = adjust.apply(m.LegendaryActions); err != nil {
+		return Monster{}, err
+	}
+	return scaled, nil
+}
+
+// scaleHitPoints подбирает число костей хитов под нужное среднее значение.
+// Вид кости берётся из текущих костей хитов или по размеру, модификатор -
+// из Телосложения.
+func scaleHitPoints(m *Monster, target float64) error {
+	hd := HitDice{Sides: 8}
+	if sides, ok := sizeHitDie[strings.ToLower(m.Size)]; ok {
+		hd.Sides = sides
+	}
+	perDie := 0
+	if strings.TrimSpace(m.HitDice) != "" {
+		current, err := ParseHitDice(m.HitDice)
+		if err != nil {
+			return err
+		}
+		hd.Sides = current.Sides
+		perDie = current.Modifier / current.Count
+	}
+	if con, ok := m.AbilityScores["CON"]; ok {
+		perDie = AbilityModifier(con)
+	}
+
+	average := float64(hd.Sides+1)/2 + float64(perDie)
+	if average <= 0 {
+		average = float64(hd.Sides+1) / 2
+	}
+	hd.Count = max(1, int(math.Round(target/average)))
+	hd.Modifier = hd.Count * perDie
+
+	m.HitDice = hd.String()
+	m.HitPoints = max(1, hd.Average())
+	return nil
+}
+
+func shiftBonuses(bonuses map[string]int, delta int) map[string]int {
+	if bonuses == nil {
+		return nil
+	}
+	shifted := make(map[string]int, len(bonuses))
+	for name, bonus := range bonuses {
+		shifted[name] = bonus + delta
+	}
+	return shifted
+}
+
+// actionAdjustment изменения действий при масштабировании.
+type actionAdjustment struct {
+	attack int     // сдвиг бонуса атаки
+	saveDC int     // сдвиг СЛ спасброска
+	damage float64 // множитель среднего урона
+}
+
+func (adj actionAdjustment) apply(actions []Action) ([]Action, error) {
+	if actions == nil {
+		return nil, nil
+	}
+	result := make([]Action, len(actions))
+	for i, a := range actions {
+		a.Spent, a.Used = false, 0
+		if a.Type == ActionMelee || a.Type == ActionRanged {
+			a.AttackBonus += adj.attack
+		}
+		if a.Type == ActionSave {
+			a.SaveDC = max(1, a.SaveDC+adj.saveDC)
+		}
+		a.Damage = append([]Damage(nil), a.Damage...)
+		for j, d := range a.Damage {
+			scaled, err := scaleDamage(d.Dice, adj.damage)
+			if err != nil {
+				return nil, fmt.Errorf("action %q: %w", a.Name, err)
+			}
+			a.Damage[j].Dice = scaled
+		}
+		a.Description = adj.rewriteDescription(a.Description, a.Damage)
+		result[i] = a
+	}
+	return result, nil
+}
+
+// rewriteDescription обновляет числа в тексте действия старого формата:
+// бонус атаки, СЛ и урон вида "5 (1d6 + 2)".
+func (adj actionAdjustment) rewriteDescription(text string, damage []Damage) string {
+	if text == "" {
+		return text
+	}
+	text = textAttackPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textAttackPattern.FindStringSubmatch(match)
+		bonus, err := strconv.Atoi(normalizeDice(parts[2]))
+		if err != nil {
+			return match
+		}
+		return parts[1] + fmt.Sprintf("%+d", bonus+adj.attack)
+	})
+	text = textSaveDCPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textSaveDCPattern.FindStringSubmatch(match)
+		dc, _ := strconv.Atoi(parts[2])
+		return fmt.Sprintf("%s %d", parts[1], max(1, dc+adj.saveDC))
+	})
+	// Урон в тексте заменяется, только если он однозначно совпадает со структурой
+	if len(textDamagePattern.FindAllString(text, -1)) == len(damage) {
+		i := 0
+		text = textDamagePattern.ReplaceAllStringFunc(text, func(match string) string {
+			d := damage[i]
+			i++
+			expr, err := parseDamage(d.Dice)
+			if err != nil {
+				return match
+			}
+			return fmt.Sprintf("%d (%s)", expr.Average(), d.Dice)
+		})
+	}
+	return text
+}
+
+// scaleDamage меняет число костей выражения урона так, чтобы средний урон
+// изменился в factor раз. Модификатор сохраняется, фиксированный урон
+// масштабируется напрямую.
+func scaleDamage(text string, factor float64) (string, error) {
+	expr, err := parseDamage(text)
+	if err != nil {
+		return "", err
+	}
+	if len(expr.Dice) == 0 {
+		return strconv.Itoa(max(0, int(math.Round(float64(expr.Modifier)*factor)))), nil
+	}
+
+	diceAverage := 0.0
+	for _, term := range expr.Dice {
+		diceAverage += float64(term.Sign*term.Count*(term.Sides+1)) / 2
+	}
+	wanted := (diceAverage+float64(expr.Modifier))*factor - float64(expr.Modifier)
+	ratio := 0.0
+	if diceAverage > 0 {
+		ratio = wanted / diceAverage
+	}
+
+	scaled := dice.Expression{Modifier: expr.Modifier}
+	for _, term := range expr.Dice {
+		count := term.Count
+		if term.Sign > 0 {
+			count = max(1, int(math.Round(float64(term.Count)*ratio)))
+		}
+		scaled.Dice = append(scaled.Dice, dice.DiceTerm{Count: count, Sides: term.Sides, Sign: term.Sign})
+	}
+	return scaled.String(), nil
+}
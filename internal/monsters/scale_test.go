@@ -0,0 +1,76 @@
+package monsters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScale(t *testing.T) {
+	t.Parallel()
+
+	ogre := Monster{
+		ID:              "ogre",
+		Name:            "Ogre",
+		Type:            "Giant",
+		Size:            "Large",
+		ArmorClass:      11,
+		HitPoints:       59,
+		HitDice:         "7d10+21",
+		AbilityScores:   map[string]int{"STR": 19, "DEX": 8, "CON": 16, "INT": 5, "WIS": 7, "CHA": 7},
+		Skills:          map[string]int{"Perception": 0},
+		ChallengeRating: 2,
+		Actions: []Action{
+			{Name: "Greatclub", Type: ActionMelee, AttackBonus: 6, Reach: 5, Damage: []Damage{{Dice: "2d8+4", Type: "bludgeoning"}},
+				Description: "Greatclub. Melee Weapon Attack: +6 to hit, reach 5 ft., one target. Hit: 13 (2d8 + 4) bludgeoning damage."},
+			{Name: "Roar", Type: ActionSave, SaveDC: 13, SaveAbility: "WIS", Spent: true, Recharge: 5},
+		},
+	}
+
+	scaled, err := Scale(ogre, 5)
+	if err != nil {
+		t.Fatalf("Scale error: %v", err)
+	}
+	if err := scaled.Validate(); err != nil {
+		t.Fatalf("scaled monster is invalid: %v", err)
+	}
+	if scaled.ID != "" || scaled.ScaledFrom != "ogre" || scaled.Name != "Ogre (CR 5)" {
+		t.Fatalf("unexpected identity: id=%q from=%q name=%q", scaled.ID, scaled.ScaledFrom, scaled.Name)
+	}
+	if scaled.XP != 1800 || scaled.ProficiencyBonus != 3 || scaled.Skills["Perception"] != 1 {
+		t.Fatalf("unexpected derived values: %+v", scaled)
+	}
+	if scaled.ArmorClass != 13 {
+		t.Fatalf("expected AC 13, got %d", scaled.ArmorClass)
+	}
+	// 59 × 138 / 93 ≈ 87.5 хитов: 10d10+30 дают в среднем 85
+	if scaled.HitDice != "10d10+30" || scaled.HitPoints != 85 {
+		t.Fatalf("unexpected hit points: %d (%s)", scaled.HitPoints, scaled.HitDice)
+	}
+
+	// Урон за раунд 15–20 → 33–38: средний урон удваивается
+	club := scaled.Actions[0]
+	if club.AttackBonus != 9 || club.Damage[0].Dice != "5d8+4" {
+		t.Fatalf("unexpected attack: %+v", club)
+	}
+	if !strings.Contains(club.Description, "+9 to hit") || !strings.Contains(club.Description, "26 (5d8+4) bludgeoning") {
+		t.Fatalf("description was not rewritten: %q", club.Description)
+	}
+	if roar := scaled.Actions[1]; roar.SaveDC != 15 || roar.Spent {
+		t.Fatalf("unexpected save action: %+v", roar)
+	}
+	if ogre.Actions[0].AttackBonus != 6 || ogre.Actions[1].SaveDC != 13 {
+		t.Fatal("original monster was modified")
+	}
+
+	again, err := Scale(scaled, 0.5)
+	if err != nil {
+		t.Fatalf("Scale error: %v", err)
+	}
+	if again.Name != "Ogre (CR 1/2)" || again.HitPoints < 1 {
+		t.Fatalf("unexpected rescale: %q hp=%d", again.Name, again.HitPoints)
+	}
+
+	if _, err := Scale(ogre, 3.5); err == nil {
+		t.Fatal("expected error for invalid challenge rating")
+	}
+}
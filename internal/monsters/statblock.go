@@ -0,0 +1,497 @@
+package monsters
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// statField поле блока характеристик, которое распознаётся по заголовку строки.
+type statField int
+
+const (
+	fieldArmorClass statField = iota
+	fieldHitPoints
+	fieldSpeed
+	fieldSavingThrows
+	fieldSkills
+	fieldVulnerabilities
+	fieldResistances
+	fieldImmunities
+	fieldConditionImmunities
+	fieldSenses
+	fieldLanguages
+	fieldChallenge
+	fieldProficiencyBonus
+)
+
+// statHeadings заголовки строк блока характеристик на английском и русском.
+var statHeadings = []struct {
+	prefix string
+	field  statField
+}{
+	{"armor class", fieldArmorClass},
+	{"класс доспеха", fieldArmorClass},
+	{"кд", fieldArmorClass},
+	{"hit points", fieldHitPoints},
+	{"хиты", fieldHitPoints},
+	{"speed", fieldSpeed},
+	{"скорость", fieldSpeed},
+	{"saving throws", fieldSavingThrows},
+	{"спасброски", fieldSavingThrows},
+	{"skills", fieldSkills},
+	{"навыки", fieldSkills},
+	{"damage vulnerabilities", fieldVulnerabilities},
+	{"уязвимость к урону", fieldVulnerabilities},
+	{"уязвимости к урону", fieldVulnerabilities},
+	{"damage resistances", fieldResistances},
+	{"сопротивление урону", fieldResistances},
+	{"сопротивления урону", fieldResistances},
+	{"сопротивление к урону", fieldResistances},
+	{"damage immunities", fieldImmunities},
+	{"иммунитет к урону", fieldImmunities},
+	{"condition immunities", fieldConditionImmunities},
+	{"иммунитет к состояниям", fieldConditionImmunities},
+	{"иммунитет к состоянию", fieldConditionImmunities},
+	{"senses", fieldSenses},
+	{"чувства", fieldSenses},
+	{"languages", fieldLanguages},
+	{"языки", fieldLanguages},
+	{"challenge", fieldChallenge},
+	{"cr", fieldChallenge},
+	{"опасность", fieldChallenge},
+	{"proficiency bonus", fieldProficiencyBonus},
+	{"бонус мастерства", fieldProficiencyBonus},
+}
+
+// Разделы с действиями после блока характеристик.
+const (
+	sectionTraits = iota
+	sectionActions
+	sectionLegendary
+)
+
+var sectionHeadings = map[string]int{
+	"actions":           sectionActions,
+	"действия":          sectionActions,
+	"bonus actions":     sectionActions,
+	"бонусные действия": sectionActions,
+	"reactions":         sectionActions,
+	"реакции":           sectionActions,
+	"legendary actions": sectionLegendary,
+	"легендарные действия": sectionLegendary,
+}
+
+// abilityAliases сокращения и названия характеристик.
+var abilityAliases = map[string]string{
+	"str": "STR", "dex": "DEX", "con": "CON", "int": "INT", "wis": "WIS", "cha": "CHA",
+	"strength": "STR", "dexterity": "DEX", "constitution": "CON",
+	"intelligence": "INT", "wisdom": "WIS", "charisma": "CHA",
+	"сил": "STR", "лов": "DEX", "тел": "CON", "инт": "INT", "мдр": "WIS", "хар": "CHA",
+}
+
+var abilityOrder = []string{"STR", "DEX", "CON", "INT", "WIS", "CHA"}
+
+// ruAbilityStems основы русских названий характеристик в спасбросках.
+var ruAbilityStems = []struct{ stem, ability string }{
+	{"сил", "STR"}, {"лов", "DEX"}, {"тел", "CON"}, {"инт", "INT"}, {"мудр", "WIS"}, {"хар", "CHA"},
+}
+
+// ruDamageTypes основы русских названий типов урона.
+var ruDamageTypes = []struct{ stem, damageType string }{
+	{"колющ", "piercing"}, {"рубящ", "slashing"}, {"дробящ", "bludgeoning"},
+	{"огн", "fire"}, {"холод", "cold"}, {"электр", "lightning"}, {"звук", "thunder"},
+	{"кислот", "acid"}, {"яд", "poison"}, {"некрот", "necrotic"}, {"излуч", "radiant"},
+	{"психич", "psychic"}, {"силов", "force"},
+}
+
+// ruSizes основы русских названий размеров.
+var ruSizes = []struct{ stem, size string }{
+	{"крошечн", "Tiny"}, {"маленьк", "Small"}, {"средн", "Medium"},
+	{"больш", "Large"}, {"огромн", "Huge"}, {"громадн", "Gargantuan"},
+}
+
+var (
+	firstNumberPattern  = regexp.MustCompile(`\d+`)
+	hitDicePattern      = regexp.MustCompile(`\(\s*(\d+\s*[dDкК]\s*\d+(?:\s*[+\-−–]\s*\d+)?)\s*\)`)
+	abilityScorePattern = regexp.MustCompile(`(\d+)\s*\(\s*[+\-−–]?\s*\d+\s*\)`)
+	abilityLinePattern  = regexp.MustCompile(`(?i)^(?:\s*(?:STR|DEX|CON|INT|WIS|CHA|СИЛ|ЛОВ|ТЕЛ|ИНТ|МДР|ХАР)\s*|\s*\d+\s*\(\s*[+\-−–]?\s*\d+\s*\)\s*)+$`)
+	bonusPattern        = regexp.MustCompile(`^(.+?)\s*([+\-−–]\s*\d+)$`)
+	entryPattern        = regexp.MustCompile(`^([\p{Lu}\d][^.]{0,60}?)\.\s+(.+)$`)
+	listItemPattern     = regexp.MustCompile(`^[^.:]{1,40}:`)
+	usesPattern         = regexp.MustCompile(`(?i)\s*\((\d+)\s*/\s*(?:day|день)\)`)
+
+	attackPattern = regexp.MustCompile(`(?i)(melee or ranged|melee|ranged|рукопашная или дальнобойная|рукопашная|дальнобойная)\s+(?:weapon |spell |оружием |заклинанием )?(?:attack|атака)(?: roll)?(?: оружием| заклинанием)?:\s*([+\-−–]\s*\d+)`)
+	reachPattern  = regexp.MustCompile(`(?i)(?:reach|досягаемость)\s+(\d+)\s*(?:ft|фт)`)
+	rangePattern  = regexp.MustCompile(`(?i)(?:range|дистанция)\s+(\d+)(?:\s*/\s*(\d+))?\s*(?:ft|фт)`)
+	enSavePattern = regexp.MustCompile(`(?i)DC\s*(\d+)\s+(strength|dexterity|constitution|intelligence|wisdom|charisma)\s+saving throw`)
+	enSave2024    = regexp.MustCompile(`(?i)(strength|dexterity|constitution|intelligence|wisdom|charisma)\s+saving throw:\s*DC\s*(\d+)`)
+	ruSavePattern = regexp.MustCompile(`(?i)спасброс\p{L}*\s+(\p{L}+)\s+сл\s*(\d+)`)
+	enDamage      = regexp.MustCompile(`(?i)\d+\s*\(\s*(\d+\s*d\s*\d+(?:\s*[+\-−–]\s*\d+)?)\s*\)\s+(\p{L}+)\s+damage`)
+	ruDamage      = regexp.MustCompile(`(?i)\d+\s*\(\s*(\d+\s*к\s*\d+(?:\s*[+\-−–]\s*\d+)?)\s*\)\s+(?:(\p{L}+)\s+)?урона(?:\s+(\p{L}+))?`)
+)
+
+// ParseStatBlock разбирает текстовый блок характеристик монстра в формате
+// 5e (английский или русский). Строки, которые не удалось сопоставить
+// полям монстра, возвращаются предупреждениями.
+func ParseStatBlock(text string) (Monster, []string) {
+	var monster Monster
+	warnings := []string{}
+	warn := func(line, reason string) {
+		warnings = append(warnings, fmt.Sprintf("%s: %q", reason, line))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return monster, append(warnings, "stat block is empty")
+	}
+
+	monster.Name = lines[0]
+	rest := lines[1:]
+	if len(rest) > 0 && parseSizeLine(&monster, rest[0]) {
+		rest = rest[1:]
+	}
+
+	var scores []int
+	section := sectionTraits
+	inBody := false
+	var current *Action
+	var currentSection int
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		action := structureAction(*current)
+		switch currentSection {
+		case sectionActions:
+			monster.Actions = append(monster.Actions, action)
+		case sectionLegendary:
+			monster.LegendaryActions = append(monster.LegendaryActions, action)
+		default:
+			monster.Traits = append(monster.Traits, action)
+		}
+		current = nil
+	}
+
+	for _, line := range rest {
+		lower := strings.ToLower(line)
+
+		if s, ok := sectionHeadings[strings.TrimRight(lower, ":")]; ok {
+			flush()
+			section = s
+			inBody = true
+			continue
+		}
+
+		if abilityLinePattern.MatchString(line) {
+			for _, match := range abilityScorePattern.FindAllStringSubmatch(line, -1) {
+				score, _ := strconv.Atoi(match[1])
+				scores = append(scores, score)
+			}
+			continue
+		}
+
+		if field, value, ok := matchHeading(line); ok && (!inBody || field == fieldProficiencyBonus) {
+			if err := applyStatField(&monster, field, value); err != nil {
+				warn(line, err.Error())
+			}
+			// После показателя опасности идут особенности и действия
+			if field == fieldChallenge {
+				inBody = true
+			}
+			continue
+		}
+
+		if match := entryPattern.FindStringSubmatch(line); match != nil && isEntryName(match[1]) {
+			flush()
+			current = &Action{Name: match[1], Description: line}
+			currentSection = section
+			continue
+		}
+
+		switch {
+		case current != nil && isContinuation(current.Description, line):
+			current.Description += " " + line
+		case section == sectionLegendary && current == nil && len(monster.LegendaryActions) == 0:
+			// Вступление к легендарным действиям не переносится в монстра
+		case inBody:
+			warn(line, "unrecognized text")
+		default:
+			warn(line, "unrecognized line")
+		}
+	}
+	flush()
+
+	switch {
+	case len(scores) == len(abilityOrder):
+		monster.AbilityScores = make(map[string]int, len(abilityOrder))
+		for i, ability := range abilityOrder {
+			monster.AbilityScores[ability] = scores[i]
+		}
+	case len(scores) > 0:
+		warnings = append(warnings, fmt.Sprintf("expected 6 ability scores, found %d", len(scores)))
+	default:
+		warnings = append(warnings, "ability scores not found")
+	}
+
+	if err := monster.Validate(); err != nil {
+		warnings = append(warnings, "monster is incomplete: "+err.Error())
+	} else if err := ValidateHitDice(monster); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	return monster, warnings
+}
+
+// parseSizeLine разбирает строку «Huge dragon, chaotic evil» или
+// «Огромный дракон, хаотично-злой».
+func parseSizeLine(m *Monster, line string) bool {
+	descriptor, alignment, _ := strings.Cut(line, ",")
+	words := strings.Fields(descriptor)
+	if len(words) == 0 {
+		return false
+	}
+
+	first := strings.ToLower(words[0])
+	size := ""
+	if _, ok := sizeHitDie[first]; ok {
+		size = strings.ToUpper(first[:1]) + first[1:]
+	}
+	for _, s := range ruSizes {
+		if strings.HasPrefix(first, s.stem) {
+			size = s.size
+		}
+	}
+	if size == "" {
+		return false
+	}
+
+	m.Size = size
+	if len(words) > 1 {
+		m.Type = capitalize(strings.Join(words[1:], " "))
+	}
+	m.Alignment = strings.TrimSpace(alignment)
+	return true
+}
+
+func capitalize(text string) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return text
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// matchHeading определяет поле по заголовку строки и возвращает остаток строки.
+func matchHeading(line string) (statField, string, bool) {
+	lower := strings.ToLower(line)
+	for _, h := range statHeadings {
+		if !strings.HasPrefix(lower, h.prefix) {
+			continue
+		}
+		value := line[len(h.prefix):]
+		// Заголовок должен быть отдельным словом
+		if value != "" {
+			next := []rune(value)[0]
+			if unicode.IsLetter(next) {
+				continue
+			}
+		}
+		return h.field, strings.TrimSpace(strings.TrimLeft(value, ":")), true
+	}
+	return 0, "", false
+}
+
+func applyStatField(m *Monster, field statField, value string) error {
+	switch field {
+	case fieldArmorClass:
+		number := firstNumberPattern.FindString(value)
+		if number == "" {
+			return fmt.Errorf("armor class not found")
+		}
+		m.ArmorClass, _ = strconv.Atoi(number)
+	case fieldHitPoints:
+		number := firstNumberPattern.FindString(value)
+		if number == "" {
+			return fmt.Errorf("hit points not found")
+		}
+		m.HitPoints, _ = strconv.Atoi(number)
+		if match := hitDicePattern.FindStringSubmatch(value); match != nil {
+			m.HitDice = normalizeDice(match[1])
+		}
+	case fieldSpeed:
+		m.Speed = value
+	case fieldSavingThrows:
+		bonuses, err := parseBonuses(value)
+		if err != nil {
+			return err
+		}
+		m.SavingThrows = make(map[string]int, len(bonuses))
+		for name, bonus := range bonuses {
+			ability, ok := abilityAliases[strings.ToLower(name)]
+			if !ok {
+				return fmt.Errorf("unknown ability %q", name)
+			}
+			m.SavingThrows[ability] = bonus
+		}
+	case fieldSkills:
+		bonuses, err := parseBonuses(value)
+		if err != nil {
+			return err
+		}
+		m.Skills = bonuses
+	case fieldVulnerabilities:
+		m.DamageVulnerabilities = splitList(value)
+	case fieldResistances:
+		m.DamageResistances = splitList(value)
+	case fieldImmunities:
+		m.DamageImmunities = splitList(value)
+	case fieldConditionImmunities:
+		m.ConditionImmunities = splitList(value)
+	case fieldSenses:
+		m.Senses = value
+	case fieldLanguages:
+		m.Languages = splitList(value)
+	case fieldChallenge:
+		cr, err := ParseChallengeRating(value)
+		if err != nil {
+			return err
+		}
+		m.ChallengeRating = cr
+	case fieldProficiencyBonus:
+		// Бонус мастерства вычисляется по показателю опасности
+	}
+	return nil
+}
+
+// parseBonuses разбирает список вида «Dex +6, Con +13».
+func parseBonuses(value string) (map[string]int, error) {
+	bonuses := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		match := bonusPattern.FindStringSubmatch(item)
+		if match == nil {
+			return nil, fmt.Errorf("invalid bonus %q", item)
+		}
+		bonus, err := strconv.Atoi(normalizeDice(match[2]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bonus %q", item)
+		}
+		bonuses[strings.TrimSpace(match[1])] = bonus
+	}
+	return bonuses, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// normalizeDice приводит выражение к виду "19d12+133": русская «к» вместо d,
+// типографские минусы, пробелы.
+func normalizeDice(text string) string {
+	return strings.NewReplacer("к", "d", "К", "d", "D", "d", "−", "-", "–", "-", " ", "").Replace(text)
+}
+
+// isEntryName отличает название особенности или действия от начала
+// обычного предложения.
+func isEntryName(name string) bool {
+	return len(strings.Fields(usesPattern.ReplaceAllString(rechargePattern.ReplaceAllString(name, ""), ""))) <= 5
+}
+
+// isContinuation сообщает, продолжает ли строка описание действия: перенос
+// посреди предложения или пункт списка («At will: ...», «1/day each: ...»).
+func isContinuation(description, line string) bool {
+	if !strings.ContainsAny(description[len(description)-1:], ".!?") {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(line)
+	return unicode.IsLower(first) || listItemPattern.MatchString(line)
+}
+
+// structureAction извлекает из текста действия атаку, досягаемость,
+// дистанцию, урон, спасбросок, перезарядку и число использований.
+func structureAction(a Action) Action {
+	parsed := actionFromText(a.Description)
+	a.Name, a.Recharge = parsed.Name, parsed.Recharge
+	if match := usesPattern.FindStringSubmatch(a.Name); match != nil {
+		a.UsesPerDay, _ = strconv.Atoi(match[1])
+		a.Name = strings.TrimSpace(usesPattern.ReplaceAllString(a.Name, ""))
+	}
+	text := a.Description
+
+	if match := attackPattern.FindStringSubmatch(text); match != nil {
+		a.Type = ActionMelee
+		if kind := strings.ToLower(match[1]); kind == "ranged" || kind == "дальнобойная" {
+			a.Type = ActionRanged
+		}
+		a.AttackBonus, _ = strconv.Atoi(normalizeDice(match[2]))
+	}
+	if match := reachPattern.FindStringSubmatch(text); match != nil {
+		a.Reach, _ = strconv.Atoi(match[1])
+	}
+	if match := rangePattern.FindStringSubmatch(text); match != nil {
+		a.Range, _ = strconv.Atoi(match[1])
+		if match[2] != "" {
+			a.LongRange, _ = strconv.Atoi(match[2])
+		}
+	}
+
+	if a.Type == "" {
+		switch match := enSavePattern.FindStringSubmatch(text); {
+		case match != nil:
+			a.Type, a.SaveAbility = ActionSave, abilityAliases[strings.ToLower(match[2])]
+			a.SaveDC, _ = strconv.Atoi(match[1])
+		default:
+			if match := enSave2024.FindStringSubmatch(text); match != nil {
+				a.Type, a.SaveAbility = ActionSave, abilityAliases[strings.ToLower(match[1])]
+				a.SaveDC, _ = strconv.Atoi(match[2])
+			} else if match := ruSavePattern.FindStringSubmatch(text); match != nil {
+				lower := strings.ToLower(match[1])
+				for _, s := range ruAbilityStems {
+					if strings.HasPrefix(lower, s.stem) {
+						a.Type, a.SaveAbility = ActionSave, s.ability
+						a.SaveDC, _ = strconv.Atoi(match[2])
+					}
+				}
+			}
+		}
+	}
+
+	for _, match := range enDamage.FindAllStringSubmatch(text, -1) {
+		a.Damage = append(a.Damage, Damage{Dice: normalizeDice(match[1]), Type: strings.ToLower(match[2])})
+	}
+	for _, match := range ruDamage.FindAllStringSubmatch(text, -1) {
+		a.Damage = append(a.Damage, Damage{Dice: normalizeDice(match[1]), Type: ruDamageType(match[2], match[3])})
+	}
+	return a
+}
+
+func ruDamageType(words ...string) string {
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		for _, t := range ruDamageTypes {
+			if strings.HasPrefix(lower, t.stem) {
+				return t.damageType
+			}
+		}
+	}
+	return ""
+}
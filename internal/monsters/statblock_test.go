@@ -0,0 +1,128 @@
+package monsters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStatBlockEnglish(t *testing.T) {
+	t.Parallel()
+
+	text := `Adult Red Dragon
+Huge dragon, chaotic evil
+Armor Class 19 (natural armor)
+Hit Points 256 (19d12 + 133)
+Speed 40 ft., climb 40 ft., fly 80 ft.
+STR DEX CON INT WIS CHA
+27 (+8) 10 (+0) 25 (+7) 16 (+3) 13 (+1) 21 (+5)
+Saving Throws Dex +6, Con +13, Wis +7, Cha +11
+Skills Perception +13, Stealth +6
+Damage Vulnerabilities cold
+Damage Immunities fire
+Senses blindsight 60 ft., darkvision 120 ft., passive Perception 23
+Languages Common, Draconic
+Challenge 17 (18,000 XP)
+Legendary Resistance (3/Day). If the dragon fails a saving throw, it can choose to succeed instead.
+Actions
+Multiattack. The dragon can use its Frightful Presence. It then makes three attacks.
+Bite. Melee Weapon Attack: +14 to hit, reach 10 ft., one target. Hit: 19 (2d10 + 8) piercing damage
+plus 7 (2d6) fire damage.
+Fire Breath (Recharge 5–6). The dragon exhales fire in a 60-foot cone. Each creature in that area must make a DC 21 Dexterity saving throw, taking 63 (18d6) fire damage on a failed save.
+Legendary Actions
+The dragon can take 3 legendary actions, choosing from the options below.
+Detect. The dragon makes a Wisdom (Perception) check.
+Mysterious footnote without structure`
+
+	m, warnings := ParseStatBlock(text)
+	if m.Name != "Adult Red Dragon" || m.Size != "Huge" || m.Type != "Dragon" || m.Alignment != "chaotic evil" {
+		t.Fatalf("unexpected header: %q %q %q %q", m.Name, m.Size, m.Type, m.Alignment)
+	}
+	if m.ArmorClass != 19 || m.HitPoints != 256 || m.HitDice != "19d12+133" {
+		t.Fatalf("unexpected AC/HP: %d %d %q", m.ArmorClass, m.HitPoints, m.HitDice)
+	}
+	if m.AbilityScores["STR"] != 27 || m.AbilityScores["CHA"] != 21 {
+		t.Fatalf("unexpected ability scores: %v", m.AbilityScores)
+	}
+	if m.SavingThrows["CON"] != 13 || m.Skills["Perception"] != 13 {
+		t.Fatalf("unexpected saves/skills: %v %v", m.SavingThrows, m.Skills)
+	}
+	if m.ChallengeRating != 17 || len(m.Languages) != 2 || len(m.DamageImmunities) != 1 {
+		t.Fatalf("unexpected CR/languages/immunities: %v %v %v", m.ChallengeRating, m.Languages, m.DamageImmunities)
+	}
+	if len(m.DamageVulnerabilities) != 1 || m.DamageVulnerabilities[0] != "cold" {
+		t.Fatalf("unexpected vulnerabilities: %v", m.DamageVulnerabilities)
+	}
+
+	if len(m.Traits) != 1 || m.Traits[0].Name != "Legendary Resistance" || m.Traits[0].UsesPerDay != 3 {
+		t.Fatalf("unexpected traits: %+v", m.Traits)
+	}
+	if len(m.Actions) != 3 {
+		t.Fatalf("expected 3 actions, got %+v", m.Actions)
+	}
+	bite := m.Actions[1]
+	if bite.Type != ActionMelee || bite.AttackBonus != 14 || bite.Reach != 10 || len(bite.Damage) != 2 {
+		t.Fatalf("unexpected bite: %+v", bite)
+	}
+	if bite.Damage[0] != (Damage{Dice: "2d10+8", Type: "piercing"}) || bite.Damage[1].Type != "fire" {
+		t.Fatalf("unexpected bite damage: %+v", bite.Damage)
+	}
+	breath := m.Actions[2]
+	if breath.Name != "Fire Breath" || breath.Recharge != 5 || breath.Type != ActionSave || breath.SaveDC != 21 || breath.SaveAbility != "DEX" {
+		t.Fatalf("unexpected breath: %+v", breath)
+	}
+	if len(m.LegendaryActions) != 1 || m.LegendaryActions[0].Name != "Detect" {
+		t.Fatalf("unexpected legendary actions: %+v", m.LegendaryActions)
+	}
+	if strings.Contains(m.LegendaryActions[0].Description, "footnote") {
+		t.Fatalf("footnote must not be merged into an action: %q", m.LegendaryActions[0].Description)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Mysterious footnote") {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+}
+
+func TestParseStatBlockRussian(t *testing.T) {
+	t.Parallel()
+
+	text := `Тролль
+Большой великан, хаотично-злой
+Класс Доспеха 15 (природный доспех)
+Хиты 84 (8к10 + 40)
+Скорость 30 фт.
+СИЛ ЛОВ ТЕЛ ИНТ МДР ХАР
+18 (+4) 13 (+1) 20 (+5) 7 (−2) 9 (−1) 7 (−2)
+Навыки Восприятие +2
+Чувства тёмное зрение 60 фт., пассивная Внимательность 12
+Языки Великаний
+Опасность 5 (1 800 опыта)
+Регенерация. Тролль восстанавливает 10 хитов в начале своего хода.
+Действия
+Укус. Рукопашная атака оружием: +7 к попаданию, досягаемость 5 фт., одна цель. Попадание: 7 (1к6 + 4) колющего урона.
+Вопль. Каждое существо в пределах 30 фт. должно преуспеть в спасброске Мудрости Сл 13, иначе получит 10 (3к6) урона психической энергией.
+Уязвимость к урону огонь`
+
+	m, warnings := ParseStatBlock(text)
+	if m.Size != "Large" || m.HitDice != "8d10+40" || m.ChallengeRating != 5 || m.AbilityScores["INT"] != 7 {
+		t.Fatalf("unexpected monster: %+v", m)
+	}
+	if m.Skills["Восприятие"] != 2 {
+		t.Fatalf("unexpected skills: %v", m.Skills)
+	}
+	if len(m.Traits) != 1 || len(m.Actions) != 2 {
+		t.Fatalf("unexpected traits/actions: %+v %+v", m.Traits, m.Actions)
+	}
+	bite := m.Actions[0]
+	if bite.Type != ActionMelee || bite.AttackBonus != 7 || bite.Reach != 5 || len(bite.Damage) != 1 || bite.Damage[0] != (Damage{Dice: "1d6+4", Type: "piercing"}) {
+		t.Fatalf("unexpected bite: %+v", bite)
+	}
+	scream := m.Actions[1]
+	if scream.Type != ActionSave || scream.SaveAbility != "WIS" || scream.SaveDC != 13 || len(scream.Damage) != 1 || scream.Damage[0].Type != "psychic" {
+		t.Fatalf("unexpected scream: %+v", scream)
+	}
+
+	// Строка после действий без названия не распознана
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Уязвимость") {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+}
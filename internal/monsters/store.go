@@ -11,8 +11,13 @@ type Store interface {
 	Create(monster Monster) (Monster, error)
 	Get(id string) (Monster, error)
 	List() []Monster
+	// Find возвращает страницу списка с учётом фильтров и сортировки
+	Find(q Query) (Page, error)
+	// Update сохраняет монстра, только если его версия совпадает
+	// с monster.Version (0 - без проверки), иначе возвращает ErrVersionConflict
 	Update(id string, monster Monster) (Monster, error)
-	Delete(id string) error
+	// Delete удаляет монстра; version 0 - без проверки версии
+	Delete(id string, version int) error
 }
 
 type MemoryStore struct {
@@ -41,6 +46,8 @@ func (s *MemoryStore) Create(monster Monster) (Monster, error) {
 	if _, exists := s.byID[monster.ID]; exists {
 		return Monster{}, fmt.Errorf("monster with id %s already exists", monster.ID)
 	}
+	monster.Version = 1
+	monster.Normalize()
 
 	s.byID[monster.ID] = monster
 	s.order = append(s.order, monster.ID)
@@ -69,6 +76,13 @@ func (s *MemoryStore) List() []Monster {
 	return result
 }
 
+func (s *MemoryStore) Find(q Query) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	return q.apply(s.List()), nil
+}
+
 func (s *MemoryStore) Update(id string, monster Monster) (Monster, error) {
 	if err := monster.Validate(); err != nil {
 		return Monster{}, err
@@ -77,22 +91,32 @@ func (s *MemoryStore) Update(id string, monster Monster) (Monster, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.byID[id]; !ok {
+	existing, ok := s.byID[id]
+	if !ok {
 		return Monster{}, ErrNotFound
 	}
+	if monster.Version != 0 && monster.Version != existing.Version {
+		return Monster{}, ErrVersionConflict
+	}
 
 	monster.ID = id
+	monster.Version = existing.Version + 1
+	monster.Normalize()
 	s.byID[id] = monster
 	return monster, nil
 }
 
-func (s *MemoryStore) Delete(id string) error {
+func (s *MemoryStore) Delete(id string, version int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.byID[id]; !ok {
+	existing, ok := s.byID[id]
+	if !ok {
 		return ErrNotFound
 	}
+	if version != 0 && version != existing.Version {
+		return ErrVersionConflict
+	}
 
 	delete(s.byID, id)
 	// Удаляем из порядка
@@ -21,7 +21,7 @@ CREATE TABLE IF NOT EXISTS monsters (
 	id   TEXT PRIMARY KEY,
 	name TEXT NOT NULL,
 	type TEXT NOT NULL,
-	challenge_rating TEXT NOT NULL,
+	challenge_rating NUMERIC NOT NULL,
 	data JSONB NOT NULL
 );`
 
@@ -61,6 +61,31 @@ END $$;`
 		panic(fmt.Errorf("failed to migrate monsters table: %w", err))
 	}
 
+	// Миграция: номер версии для оптимистичной блокировки
+	const migrateVersion = `ALTER TABLE monsters ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`
+	if _, err := db.Exec(migrateVersion); err != nil {
+		panic(fmt.Errorf("failed to add monsters version column: %w", err))
+	}
+
+	// Миграция: текстовый challenge_rating ("17 (18,000 XP)", "1/4") -> число.
+	// Нераспознанные значения становятся CR 0, JSON приводится к новому формату.
+	const migrateChallengeRating = `
+DO $$
+BEGIN
+	IF (SELECT data_type FROM information_schema.columns WHERE table_name = 'monsters' AND column_name = 'challenge_rating') = 'text' THEN
+		ALTER TABLE monsters ALTER COLUMN challenge_rating TYPE NUMERIC USING (COALESCE(CASE
+			WHEN split_part(challenge_rating, ' ', 1) IN ('1/8', '1/4', '1/2') THEN
+				1 / split_part(split_part(challenge_rating, ' ', 1), '/', 2)::numeric
+			WHEN split_part(challenge_rating, ' ', 1) ~ '^[0-9]+(\.[0-9]+)?$' THEN
+				split_part(challenge_rating, ' ', 1)::numeric
+		END, 0));
+		UPDATE monsters SET data = jsonb_set(data, '{challengeRating}', to_jsonb(challenge_rating::float8::text));
+	END IF;
+END $$;`
+	if _, err := db.Exec(migrateChallengeRating); err != nil {
+		panic(fmt.Errorf("failed to migrate monsters challenge_rating column: %w", err))
+	}
+
 	return &PostgresStore{db: db}
 }
 
@@ -72,14 +97,16 @@ func (s *PostgresStore) Create(monster Monster) (Monster, error) {
 	if monster.ID == "" {
 		monster.ID = generateID()
 	}
+	monster.Version = 1
+	monster.Normalize()
 
 	data, err := json.Marshal(monster)
 	if err != nil {
 		return Monster{}, fmt.Errorf("failed to marshal monster: %w", err)
 	}
 
-	const insertQuery = `INSERT INTO monsters (id, name, type, challenge_rating, data) VALUES ($1, $2, $3, $4, $5::jsonb);`
-	if _, err := s.db.Exec(insertQuery, monster.ID, monster.Name, monster.Type, monster.ChallengeRating, data); err != nil {
+	const insertQuery = `INSERT INTO monsters (id, name, type, challenge_rating, version, data) VALUES ($1, $2, $3, $4, $5, $6::jsonb);`
+	if _, err := s.db.Exec(insertQuery, monster.ID, monster.Name, monster.Type, float64(monster.ChallengeRating), monster.Version, data); err != nil {
 		return Monster{}, fmt.Errorf("failed to insert monster: %w", err)
 	}
 
@@ -87,10 +114,11 @@ func (s *PostgresStore) Create(monster Monster) (Monster, error) {
 }
 
 func (s *PostgresStore) Get(id string) (Monster, error) {
-	const selectQuery = `SELECT data FROM monsters WHERE id = $1;`
+	const selectQuery = `SELECT data, version FROM monsters WHERE id = $1;`
 
 	var raw []byte
-	err := s.db.QueryRow(selectQuery, id).Scan(&raw)
+	var version int
+	err := s.db.QueryRow(selectQuery, id).Scan(&raw, &version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Monster{}, ErrNotFound
@@ -102,12 +130,14 @@ func (s *PostgresStore) Get(id string) (Monster, error) {
 	if err := json.Unmarshal(raw, &monster); err != nil {
 		return Monster{}, fmt.Errorf("failed to unmarshal monster: %w", err)
 	}
+	monster.Version = version
+	monster.Normalize()
 
 	return monster, nil
 }
 
 func (s *PostgresStore) List() []Monster {
-	const listQuery = `SELECT data FROM monsters ORDER BY id;`
+	const listQuery = `SELECT data, version FROM monsters ORDER BY id;`
 
 	rows, err := s.db.Query(listQuery)
 	if err != nil {
@@ -118,61 +148,112 @@ func (s *PostgresStore) List() []Monster {
 	var result []Monster
 	for rows.Next() {
 		var raw []byte
-		if err := rows.Scan(&raw); err != nil {
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
 			continue
 		}
 		var monster Monster
 		if err := json.Unmarshal(raw, &monster); err != nil {
 			continue
 		}
+		monster.Version = version
+		monster.Normalize()
 		result = append(result, monster)
 	}
 	return result
 }
 
+// Find выполняет фильтрацию, сортировку и постраничную выборку в SQL.
+func (s *PostgresStore) Find(q Query) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	where, args := q.sqlWhere()
+
+	page := Page{Items: []Monster{}}
+	countQuery := `SELECT COUNT(*) FROM monsters WHERE ` + where + `;`
+	if err := s.db.QueryRow(countQuery, args...).Scan(&page.Total); err != nil {
+		return Page{}, fmt.Errorf("failed to count monsters: %w", err)
+	}
+
+	selectQuery := `SELECT data, version FROM monsters WHERE ` + where + ` ORDER BY ` + q.sqlOrder()
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		selectQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	args = append(args, q.Offset)
+	selectQuery += fmt.Sprintf(" OFFSET $%d;", len(args))
+
+	rows, err := s.db.Query(selectQuery, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to list monsters: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
+			return Page{}, fmt.Errorf("failed to scan monster: %w", err)
+		}
+		var monster Monster
+		if err := json.Unmarshal(raw, &monster); err != nil {
+			return Page{}, fmt.Errorf("failed to unmarshal monster: %w", err)
+		}
+		monster.Version = version
+		monster.Normalize()
+		page.Items = append(page.Items, monster)
+	}
+	return page, rows.Err()
+}
+
 func (s *PostgresStore) Update(id string, monster Monster) (Monster, error) {
 	if err := monster.Validate(); err != nil {
 		return Monster{}, err
 	}
 
 	monster.ID = id
+	monster.Normalize()
 
 	data, err := json.Marshal(monster)
 	if err != nil {
 		return Monster{}, fmt.Errorf("failed to marshal monster: %w", err)
 	}
 
-	const updateQuery = `UPDATE monsters SET name = $2, type = $3, challenge_rating = $4, data = $5::jsonb WHERE id = $1;`
-	res, err := s.db.Exec(updateQuery, id, monster.Name, monster.Type, monster.ChallengeRating, data)
+	// Версия 0 - запись без проверки: условие на версию не добавляется,
+	// иначе параллельная запись приводила бы к ложному конфликту
+	const updateQuery = `UPDATE monsters SET name = $2, type = $3, challenge_rating = $4, data = $5::jsonb, version = version + 1 WHERE id = $1 AND ($6 = 0 OR version = $6) RETURNING version;`
+	err = s.db.QueryRow(updateQuery, id, monster.Name, monster.Type, float64(monster.ChallengeRating), data, monster.Version).Scan(&monster.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Отличаем отсутствующего монстра от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return Monster{}, err
+		}
+		return Monster{}, ErrVersionConflict
+	}
 	if err != nil {
 		return Monster{}, fmt.Errorf("failed to update monster: %w", err)
 	}
 
-	affected, err := res.RowsAffected()
-	if err == nil && affected == 0 {
-		return Monster{}, ErrNotFound
-	}
-
 	return monster, nil
 }
 
-func (s *PostgresStore) Delete(id string) error {
-	const deleteQuery = `DELETE FROM monsters WHERE id = $1;`
+func (s *PostgresStore) Delete(id string, version int) error {
+	const deleteQuery = `DELETE FROM monsters WHERE id = $1 AND ($2 = 0 OR version = $2);`
 
-	res, err := s.db.Exec(deleteQuery, id)
+	res, err := s.db.Exec(deleteQuery, id, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete monster: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
 	if err == nil && affected == 0 {
-		return ErrNotFound
+		// Отличаем отсутствующего монстра от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
 	}
 
 	return nil
 }
-
-
-
-
-
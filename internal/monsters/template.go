@@ -0,0 +1,303 @@
+package monsters
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+var ErrTemplateReadOnly = errors.New("built-in template cannot be modified")
+var ErrTemplateInUse = errors.New("template is used by derived monsters")
+
+// Template шаблон монстра («зомби», «полудракон»...): набор изменений,
+// который применяется к базовому монстру и даёт производного.
+type Template struct {
+	ID          string `json:"id"`
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Builtin     bool   `json:"builtin,omitempty"` // встроенный шаблон, не изменяется
+
+	Type                  string         `json:"type,omitempty"`               // новый тип существа
+	Alignment             string         `json:"alignment,omitempty"`          // новое мировоззрение
+	AbilityScores         map[string]int `json:"abilityScores,omitempty"`      // фиксированные значения характеристик
+	AbilityAdjustments    map[string]int `json:"abilityAdjustments,omitempty"` // прибавки к характеристикам
+	ArmorClass            int            `json:"armorClass,omitempty"`         // прибавка к КД
+	Senses                string         `json:"senses,omitempty"`             // добавляются к чувствам
+	Languages             []string       `json:"languages,omitempty"`
+	DamageVulnerabilities []string       `json:"damageVulnerabilities,omitempty"`
+	DamageResistances     []string       `json:"damageResistances,omitempty"`
+	DamageImmunities      []string       `json:"damageImmunities,omitempty"`
+	ConditionImmunities   []string       `json:"conditionImmunities,omitempty"`
+	Traits                []Action       `json:"traits,omitempty"`
+	Actions               []Action       `json:"actions,omitempty"`        // СЛ 0 у спасброска - 8 + бонус мастерства + мод. CON
+	ChallengeSteps        int            `json:"challengeSteps,omitempty"` // сдвиг по шкале показателя опасности
+}
+
+// skillAbilities характеристика, от которой зависит навык.
+var skillAbilities = map[string]string{
+	"athletics": "STR", "acrobatics": "DEX", "sleight of hand": "DEX", "stealth": "DEX",
+	"arcana": "INT", "history": "INT", "investigation": "INT", "nature": "INT", "religion": "INT",
+	"animal handling": "WIS", "insight": "WIS", "medicine": "WIS", "perception": "WIS", "survival": "WIS",
+	"deception": "CHA", "intimidation": "CHA", "performance": "CHA", "persuasion": "CHA",
+}
+
+func (t Template) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("template name is required")
+	}
+	for _, scores := range []map[string]int{t.AbilityScores, t.AbilityAdjustments} {
+		for ability := range scores {
+			if !saveAbilities[ability] {
+				return fmt.Errorf("unknown ability %q", ability)
+			}
+		}
+	}
+	for ability, score := range t.AbilityScores {
+		if score < 1 || score > 30 {
+			return fmt.Errorf("ability %s must be between 1 and 30", ability)
+		}
+	}
+	for _, list := range [][]Action{t.Traits, t.Actions} {
+		for _, a := range list {
+			// СЛ спасброска может вычисляться при применении
+			if a.Type == ActionSave && a.SaveDC == 0 {
+				a.SaveDC = 1
+			}
+			if err := a.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyTemplate применяет шаблон к базовому монстру. Производный монстр
+// не сохранён (пустой ID) и помнит базу и шаблон, чтобы его можно было
+// пересоздать при изменении базы. Спасброски, навыки и кости хитов
+// пересчитываются по изменённым характеристикам.
+func ApplyTemplate(base Monster, t Template) (Monster, error) {
+	if err := t.Validate(); err != nil {
+		return Monster{}, err
+	}
+
+	derived := base
+	derived.ID, derived.Version = "", 0
+	derived.BaseID, derived.TemplateID, derived.ScaledFrom = base.ID, t.ID, ""
+	derived.Name = fmt.Sprintf("%s (%s)", base.Name, t.Name)
+	derived.Conditions = nil
+	if t.Type != "" {
+		derived.Type = t.Type
+	}
+	if t.Alignment != "" {
+		derived.Alignment = t.Alignment
+	}
+	derived.ArmorClass = min(30, max(0, base.ArmorClass+t.ArmorClass))
+
+	derived.AbilityScores = make(map[string]int, len(abilityOrder))
+	for ability, score := range base.AbilityScores {
+		derived.AbilityScores[ability] = score
+	}
+	for ability, score := range t.AbilityScores {
+		derived.AbilityScores[ability] = score
+	}
+	for ability, delta := range t.AbilityAdjustments {
+		score, ok := derived.AbilityScores[ability]
+		if !ok {
+			score = 10
+		}
+		derived.AbilityScores[ability] = min(30, max(1, score+delta))
+	}
+	modifierDelta := func(ability string) int {
+		before, ok := base.AbilityScores[ability]
+		if !ok {
+			before = 10
+		}
+		after, ok := derived.AbilityScores[ability]
+		if !ok {
+			after = 10
+		}
+		return AbilityModifier(after) - AbilityModifier(before)
+	}
+
+	derived.SavingThrows = make(map[string]int, len(base.SavingThrows))
+	for ability, bonus := range base.SavingThrows {
+		derived.SavingThrows[ability] = bonus + modifierDelta(ability)
+	}
+	derived.Skills = make(map[string]int, len(base.Skills))
+	for skill, bonus := range base.Skills {
+		derived.Skills[skill] = bonus + modifierDelta(skillAbilities[strings.ToLower(skill)])
+	}
+	if strings.TrimSpace(base.HitDice) != "" {
+		hd, err := ParseHitDice(base.HitDice)
+		if err != nil {
+			return Monster{}, err
+		}
+		extra := hd.Count * modifierDelta("CON")
+		hd.Modifier += extra
+		derived.HitDice = hd.String()
+		derived.HitPoints = max(1, base.HitPoints+extra)
+	}
+
+	derived.DamageVulnerabilities = mergeNames(base.DamageVulnerabilities, t.DamageVulnerabilities)
+	derived.DamageResistances = mergeNames(base.DamageResistances, t.DamageResistances)
+	derived.DamageImmunities = mergeNames(base.DamageImmunities, t.DamageImmunities)
+	derived.ConditionImmunities = mergeNames(base.ConditionImmunities, t.ConditionImmunities)
+	derived.Languages = mergeNames(base.Languages, t.Languages)
+	if t.Senses != "" && !strings.Contains(strings.ToLower(base.Senses), strings.ToLower(t.Senses)) {
+		derived.Senses = strings.TrimPrefix(base.Senses+", "+t.Senses, ", ")
+	}
+
+	derived.ChallengeRating = base.ChallengeRating.Step(t.ChallengeSteps)
+	derived.Normalize()
+
+	con, ok := derived.AbilityScores["CON"]
+	if !ok {
+		con = 10
+	}
+	saveDC := 8 + derived.ProficiencyBonus + AbilityModifier(con)
+	derived.Traits = mergeActions(base.Traits, t.Traits, saveDC)
+	derived.Actions = mergeActions(base.Actions, t.Actions, saveDC)
+	derived.LegendaryActions = append([]Action(nil), base.LegendaryActions...)
+
+	if err := derived.Validate(); err != nil {
+		return Monster{}, fmt.Errorf("template %q: %w", t.Name, err)
+	}
+	return derived, nil
+}
+
+// mergeNames объединяет списки без повторов (без учёта регистра).
+func mergeNames(base, added []string) []string {
+	result := append([]string(nil), base...)
+	for _, name := range added {
+		if !slices.ContainsFunc(result, func(existing string) bool { return strings.EqualFold(existing, name) }) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// mergeActions добавляет действия шаблона; одноимённые действия базы заменяются.
+func mergeActions(base, added []Action, saveDC int) []Action {
+	result := append([]Action(nil), base...)
+	for _, a := range added {
+		if a.Type == ActionSave && a.SaveDC == 0 {
+			a.SaveDC = saveDC
+		}
+		a.Damage = append([]Damage(nil), a.Damage...)
+		index := slices.IndexFunc(result, func(existing Action) bool { return strings.EqualFold(existing.Name, a.Name) })
+		if index >= 0 {
+			result[index] = a
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+// RegenerateDerived пересоздаёт производных монстров после изменения базы:
+// шаблон применяется заново к новой версии, ID производного сохраняется.
+// Производные от производных обновляются по цепочке. Ручные правки
+// производного монстра при этом теряются. Ошибка по одному монстру не
+// останавливает остальных: все ошибки возвращаются вместе.
+func RegenerateDerived(store Store, templates TemplateStore, base Monster) ([]Monster, error) {
+	var regenerated []Monster
+	var errs []error
+	visited := map[string]bool{base.ID: true}
+	queue := []Monster{base}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		page, err := store.Find(Query{BaseID: current.ID})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("monsters derived from %s: %w", current.ID, err))
+			continue
+		}
+		for _, derived := range page.Items {
+			if visited[derived.ID] {
+				continue
+			}
+			visited[derived.ID] = true
+
+			saved, err := regenerate(store, templates, derived, current)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			regenerated = append(regenerated, saved)
+			queue = append(queue, saved)
+		}
+	}
+	return regenerated, errors.Join(errs...)
+}
+
+// RegenerateTemplate пересоздаёт всех монстров, полученных шаблоном,
+// после его изменения. Как и RegenerateDerived, продолжает работу после
+// ошибок и возвращает их вместе.
+func RegenerateTemplate(store Store, templates TemplateStore, t Template) ([]Monster, error) {
+	page, err := store.Find(Query{TemplateID: t.ID})
+	if err != nil {
+		return nil, err
+	}
+	var regenerated []Monster
+	var errs []error
+	for _, derived := range page.Items {
+		base, err := store.Get(derived.BaseID)
+		if err != nil {
+			// База удалена - производный монстр остаётся как есть
+			if !errors.Is(err, ErrNotFound) {
+				errs = append(errs, fmt.Errorf("monster %s: %w", derived.ID, err))
+			}
+			continue
+		}
+		saved, err := regenerate(store, templates, derived, base)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		regenerated = append(regenerated, saved)
+		more, err := RegenerateDerived(store, templates, saved)
+		regenerated = append(regenerated, more...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return regenerated, errors.Join(errs...)
+}
+
+// DeleteTemplate удаляет шаблон, если по нему не получен ни один монстр:
+// иначе производных монстров нельзя было бы пересоздать при изменении базы.
+func DeleteTemplate(store Store, templates TemplateStore, id string, version int) error {
+	if _, ok := builtinTemplate(id); ok {
+		return ErrTemplateReadOnly
+	}
+	page, err := store.Find(Query{TemplateID: id, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if page.Total > 0 {
+		return fmt.Errorf("%w: %d monsters", ErrTemplateInUse, page.Total)
+	}
+	return templates.Delete(id, version)
+}
+
+// regenerate применяет шаблон производного монстра к базе и сохраняет
+// результат под ID производного.
+func regenerate(store Store, templates TemplateStore, derived, base Monster) (Monster, error) {
+	t, err := templates.Get(derived.TemplateID)
+	if err != nil {
+		return Monster{}, fmt.Errorf("monster %s: %w", derived.ID, err)
+	}
+	fresh, err := ApplyTemplate(base, t)
+	if err != nil {
+		return Monster{}, fmt.Errorf("monster %s: %w", derived.ID, err)
+	}
+	saved, err := store.Update(derived.ID, fresh)
+	if err != nil {
+		return Monster{}, fmt.Errorf("monster %s: %w", derived.ID, err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,65 @@
+package monsters
+
+// BuiltinTemplates возвращает встроенные шаблоны в духе SRD.
+func BuiltinTemplates() []Template {
+	return []Template{
+		{
+			ID:                  "zombie",
+			Name:                "Зомби",
+			Description:         "Поднятое некромантией тело: крепче и сильнее при жизни, но почти лишено разума.",
+			Type:                "Undead",
+			Alignment:           "Neutral Evil",
+			AbilityScores:       map[string]int{"INT": 3, "WIS": 6, "CHA": 5},
+			AbilityAdjustments:  map[string]int{"STR": 1, "CON": 2, "DEX": -2},
+			Senses:              "darkvision 60 ft.",
+			DamageImmunities:    []string{"poison"},
+			ConditionImmunities: []string{"poisoned"},
+			Traits: []Action{
+				{Name: "Стойкость нежити", Description: "Если урон опускает хиты зомби до 0, он совершает спасбросок Телосложения со СЛ 5 + полученный урон, если только это не урон излучением или критическое попадание. При успехе хиты зомби опускаются только до 1."},
+			},
+			ChallengeSteps: -1,
+			Builtin:        true,
+		},
+		{
+			ID:                    "skeleton",
+			Name:                  "Скелет",
+			Description:           "Оживлённые кости: проворнее зомби, но хрупки против дробящего урона.",
+			Type:                  "Undead",
+			Alignment:             "Lawful Evil",
+			AbilityScores:         map[string]int{"INT": 6, "CHA": 5},
+			AbilityAdjustments:    map[string]int{"DEX": 2},
+			Senses:                "darkvision 60 ft.",
+			DamageVulnerabilities: []string{"bludgeoning"},
+			DamageImmunities:      []string{"poison"},
+			ConditionImmunities:   []string{"exhaustion", "poisoned"},
+			Traits: []Action{
+				{Name: "Хрупкие кости", Description: "Скелет уязвим к дробящему урону."},
+			},
+			ChallengeSteps: -1,
+			Builtin:        true,
+		},
+		{
+			ID:                "half-dragon",
+			Name:              "Полудракон",
+			Description:       "Потомок красного дракона: огненное дыхание, сопротивление огню и острые чувства.",
+			Senses:            "blindsight 10 ft., darkvision 60 ft.",
+			Languages:         []string{"Draconic"},
+			DamageResistances: []string{"fire"},
+			Actions: []Action{
+				{Name: "Огненное дыхание", Type: ActionSave, SaveAbility: "DEX", Recharge: 5, Damage: []Damage{{Dice: "7d6", Type: "fire"}},
+					Description: "Существо выдыхает огонь 15-футовым конусом. Половина урона при успешном спасброске."},
+			},
+			ChallengeSteps: 2,
+			Builtin:        true,
+		},
+		{
+			ID:                 "elite",
+			Name:               "Элитный",
+			Description:        "Закалённый в боях вариант: лучше снаряжён и сильнее обычных сородичей.",
+			AbilityAdjustments: map[string]int{"STR": 2, "DEX": 2, "CON": 2, "INT": 2, "WIS": 2, "CHA": 2},
+			ArmorClass:         2,
+			ChallengeSteps:     1,
+			Builtin:            true,
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package monsters
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TemplateStore хранилище шаблонов. Встроенные шаблоны доступны всегда
+// и не изменяются: Update и Delete возвращают ErrTemplateReadOnly.
+type TemplateStore interface {
+	Create(t Template) (Template, error)
+	Get(id string) (Template, error)
+	List() []Template
+	// Update сохраняет шаблон, только если его версия совпадает
+	// с t.Version (0 - без проверки), иначе возвращает ErrVersionConflict
+	Update(id string, t Template) (Template, error)
+	Delete(id string, version int) error
+}
+
+// builtinTemplate ищет встроенный шаблон по ID.
+func builtinTemplate(id string) (Template, bool) {
+	for _, t := range BuiltinTemplates() {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+type MemoryTemplateStore struct {
+	mu    sync.RWMutex
+	byID  map[string]Template
+	order []string
+}
+
+func NewMemoryTemplateStore() *MemoryTemplateStore {
+	return &MemoryTemplateStore{
+		byID: make(map[string]Template),
+	}
+}
+
+func (s *MemoryTemplateStore) Create(t Template) (Template, error) {
+	if err := t.Validate(); err != nil {
+		return Template{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.ID == "" {
+		t.ID = generateID()
+	}
+	if _, exists := s.byID[t.ID]; exists {
+		return Template{}, fmt.Errorf("template with id %s already exists", t.ID)
+	}
+	if _, exists := builtinTemplate(t.ID); exists {
+		return Template{}, fmt.Errorf("template with id %s already exists", t.ID)
+	}
+	t.Version = 1
+	t.Builtin = false
+
+	s.byID[t.ID] = t
+	s.order = append(s.order, t.ID)
+	return t, nil
+}
+
+func (s *MemoryTemplateStore) Get(id string) (Template, error) {
+	if t, ok := builtinTemplate(id); ok {
+		return t, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.byID[id]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+// List возвращает встроенные шаблоны, затем пользовательские в порядке создания.
+func (s *MemoryTemplateStore) List() []Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := BuiltinTemplates()
+	for _, id := range s.order {
+		result = append(result, s.byID[id])
+	}
+	return result
+}
+
+func (s *MemoryTemplateStore) Update(id string, t Template) (Template, error) {
+	if _, ok := builtinTemplate(id); ok {
+		return Template{}, ErrTemplateReadOnly
+	}
+	if err := t.Validate(); err != nil {
+		return Template{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byID[id]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	if t.Version != 0 && t.Version != existing.Version {
+		return Template{}, ErrVersionConflict
+	}
+
+	t.ID = id
+	t.Version = existing.Version + 1
+	t.Builtin = false
+	s.byID[id] = t
+	return t, nil
+}
+
+func (s *MemoryTemplateStore) Delete(id string, version int) error {
+	if _, ok := builtinTemplate(id); ok {
+		return ErrTemplateReadOnly
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byID[id]
+	if !ok {
+		return ErrTemplateNotFound
+	}
+	if version != 0 && version != existing.Version {
+		return ErrVersionConflict
+	}
+
+	delete(s.byID, id)
+	for i, v := range s.order {
+		if v == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package monsters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PostgresTemplateStore реализует TemplateStore в PostgreSQL.
+// Встроенные шаблоны в таблице не хранятся.
+type PostgresTemplateStore struct {
+	db *sql.DB
+}
+
+// NewPostgresTemplateStore создаёт хранилище шаблонов и гарантирует,
+// что таблица существует.
+func NewPostgresTemplateStore(db *sql.DB) *PostgresTemplateStore {
+	const createTable = `
+CREATE TABLE IF NOT EXISTS monster_templates (
+	id      TEXT PRIMARY KEY,
+	version INTEGER NOT NULL DEFAULT 1,
+	data    JSONB NOT NULL
+);`
+
+	if _, err := db.Exec(createTable); err != nil {
+		panic(fmt.Errorf("failed to create monster_templates table: %w", err))
+	}
+
+	return &PostgresTemplateStore{db: db}
+}
+
+func (s *PostgresTemplateStore) Create(t Template) (Template, error) {
+	if err := t.Validate(); err != nil {
+		return Template{}, err
+	}
+
+	if t.ID == "" {
+		t.ID = generateID()
+	}
+	if _, exists := builtinTemplate(t.ID); exists {
+		return Template{}, fmt.Errorf("template with id %s already exists", t.ID)
+	}
+	t.Version = 1
+	t.Builtin = false
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	const insertQuery = `INSERT INTO monster_templates (id, version, data) VALUES ($1, $2, $3::jsonb);`
+	if _, err := s.db.Exec(insertQuery, t.ID, t.Version, data); err != nil {
+		return Template{}, fmt.Errorf("failed to insert template: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *PostgresTemplateStore) Get(id string) (Template, error) {
+	if t, ok := builtinTemplate(id); ok {
+		return t, nil
+	}
+
+	const selectQuery = `SELECT data, version FROM monster_templates WHERE id = $1;`
+
+	var raw []byte
+	var version int
+	err := s.db.QueryRow(selectQuery, id).Scan(&raw, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Template{}, ErrTemplateNotFound
+		}
+		return Template{}, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	var t Template
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return Template{}, fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+	t.Version = version
+	return t, nil
+}
+
+func (s *PostgresTemplateStore) List() []Template {
+	result := BuiltinTemplates()
+
+	const listQuery = `SELECT data, version FROM monster_templates ORDER BY data->>'name', id;`
+	rows, err := s.db.Query(listQuery)
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		var version int
+		if err := rows.Scan(&raw, &version); err != nil {
+			continue
+		}
+		var t Template
+		if err := json.Unmarshal(raw, &t); err != nil {
+			continue
+		}
+		t.Version = version
+		result = append(result, t)
+	}
+	return result
+}
+
+func (s *PostgresTemplateStore) Update(id string, t Template) (Template, error) {
+	if _, ok := builtinTemplate(id); ok {
+		return Template{}, ErrTemplateReadOnly
+	}
+	if err := t.Validate(); err != nil {
+		return Template{}, err
+	}
+
+	t.ID = id
+	t.Builtin = false
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	// Версия 0 - запись без проверки, как у монстров
+	const updateQuery = `UPDATE monster_templates SET data = $2::jsonb, version = version + 1 WHERE id = $1 AND ($3 = 0 OR version = $3) RETURNING version;`
+	err = s.db.QueryRow(updateQuery, id, data, t.Version).Scan(&t.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Отличаем отсутствующий шаблон от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return Template{}, err
+		}
+		return Template{}, ErrVersionConflict
+	}
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *PostgresTemplateStore) Delete(id string, version int) error {
+	if _, ok := builtinTemplate(id); ok {
+		return ErrTemplateReadOnly
+	}
+
+	const deleteQuery = `DELETE FROM monster_templates WHERE id = $1 AND ($2 = 0 OR version = $2);`
+
+	res, err := s.db.Exec(deleteQuery, id, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		// Отличаем отсутствующий шаблон от устаревшей версии
+		if _, err := s.Get(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+
+	return nil
+}
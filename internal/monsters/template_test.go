@@ -0,0 +1,200 @@
+package monsters
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func templateBase() Monster {
+	return Monster{
+		ID:              "ogre",
+		Name:            "Ogre",
+		Type:            "Giant",
+		Size:            "Large",
+		Alignment:       "Chaotic Evil",
+		ArmorClass:      11,
+		HitPoints:       59,
+		HitDice:         "7d10+21",
+		AbilityScores:   map[string]int{"STR": 19, "DEX": 8, "CON": 16, "INT": 5, "WIS": 7, "CHA": 7},
+		SavingThrows:    map[string]int{"CON": 5},
+		Skills:          map[string]int{"Athletics": 6},
+		ChallengeRating: 2,
+		Actions: []Action{
+			{Name: "Greatclub", Type: ActionMelee, AttackBonus: 6, Reach: 5, Damage: []Damage{{Dice: "2d8+4", Type: "bludgeoning"}}},
+		},
+	}
+}
+
+func TestApplyTemplate(t *testing.T) {
+	t.Parallel()
+
+	zombie, ok := builtinTemplate("zombie")
+	if !ok {
+		t.Fatal("zombie template is missing")
+	}
+	derived, err := ApplyTemplate(templateBase(), zombie)
+	if err != nil {
+		t.Fatalf("ApplyTemplate error: %v", err)
+	}
+	if derived.ID != "" || derived.BaseID != "ogre" || derived.TemplateID != "zombie" || derived.Name != "Ogre (Зомби)" {
+		t.Fatalf("unexpected identity: %+v", derived)
+	}
+	if derived.Type != "Undead" || derived.AbilityScores["INT"] != 3 || derived.AbilityScores["CON"] != 18 {
+		t.Fatalf("unexpected type or abilities: %s %v", derived.Type, derived.AbilityScores)
+	}
+	// CON 16 -> 18: +1 к модификатору на каждую кость хитов и к спасброску
+	if derived.HitDice != "7d10+28" || derived.HitPoints != 66 || derived.SavingThrows["CON"] != 6 {
+		t.Fatalf("unexpected hit points: %d (%s), CON save %d", derived.HitPoints, derived.HitDice, derived.SavingThrows["CON"])
+	}
+	if derived.Skills["Athletics"] != 7 {
+		t.Fatalf("STR 19 -> 20 should raise athletics to +7, got %d", derived.Skills["Athletics"])
+	}
+	if derived.ChallengeRating != 1 || derived.XP != 200 {
+		t.Fatalf("unexpected CR: %s (%d XP)", derived.ChallengeRating, derived.XP)
+	}
+	if !slices.Contains(derived.DamageImmunities, "poison") || len(derived.Traits) != 1 {
+		t.Fatalf("template features are missing: %+v", derived)
+	}
+
+	skeleton, _ := builtinTemplate("skeleton")
+	derived, err = ApplyTemplate(templateBase(), skeleton)
+	if err != nil {
+		t.Fatalf("ApplyTemplate error: %v", err)
+	}
+	if !slices.Equal(derived.DamageVulnerabilities, []string{"bludgeoning"}) {
+		t.Fatalf("skeleton should be vulnerable to bludgeoning: %v", derived.DamageVulnerabilities)
+	}
+
+	dragon, _ := builtinTemplate("half-dragon")
+	derived, err = ApplyTemplate(templateBase(), dragon)
+	if err != nil {
+		t.Fatalf("ApplyTemplate error: %v", err)
+	}
+	breath := derived.Actions[len(derived.Actions)-1]
+	// СЛ 8 + бонус мастерства CR 4 (+2) + мод. CON (+3)
+	if breath.SaveDC != 13 || derived.ChallengeRating != 4 {
+		t.Fatalf("unexpected breath DC %d at CR %s", breath.SaveDC, derived.ChallengeRating)
+	}
+	if dragon.Actions[0].SaveDC != 0 {
+		t.Fatal("template was modified")
+	}
+}
+
+func TestChallengeRatingStep(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		from  ChallengeRating
+		steps int
+		want  ChallengeRating
+	}{
+		{1, -1, 0.5}, {0.5, 2, 2}, {0, -3, 0}, {30, 1, 30}, {5, 0, 5},
+	}
+	for _, tc := range cases {
+		if got := tc.from.Step(tc.steps); got != tc.want {
+			t.Errorf("%s.Step(%d) = %s, want %s", tc.from, tc.steps, got, tc.want)
+		}
+	}
+}
+
+func TestRegenerateDerived(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	templates := NewMemoryTemplateStore()
+
+	base, err := store.Create(templateBase())
+	if err != nil {
+		t.Fatalf("create base: %v", err)
+	}
+	elite, _ := templates.Get("elite")
+	derived, err := ApplyTemplate(base, elite)
+	if err != nil {
+		t.Fatalf("ApplyTemplate error: %v", err)
+	}
+	derived, err = store.Create(derived)
+	if err != nil {
+		t.Fatalf("create derived: %v", err)
+	}
+
+	base.ArmorClass = 14
+	base, err = store.Update(base.ID, base)
+	if err != nil {
+		t.Fatalf("update base: %v", err)
+	}
+	regenerated, err := RegenerateDerived(store, templates, base)
+	if err != nil || len(regenerated) != 1 {
+		t.Fatalf("RegenerateDerived = %d monsters, %v", len(regenerated), err)
+	}
+
+	fresh, err := store.Get(derived.ID)
+	if err != nil {
+		t.Fatalf("get derived: %v", err)
+	}
+	if fresh.ArmorClass != 16 || fresh.Version != 2 || fresh.BaseID != base.ID {
+		t.Fatalf("derived monster was not regenerated: %+v", fresh)
+	}
+}
+
+func TestRegenerateDerivedContinuesAfterErrors(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	templates := NewMemoryTemplateStore()
+
+	base, err := store.Create(templateBase())
+	if err != nil {
+		t.Fatalf("create base: %v", err)
+	}
+	elite, _ := templates.Get("elite")
+	derived, err := ApplyTemplate(base, elite)
+	if err != nil {
+		t.Fatalf("ApplyTemplate error: %v", err)
+	}
+	orphan := derived
+	orphan.TemplateID = "missing"
+	if _, err := store.Create(orphan); err != nil {
+		t.Fatalf("create orphan: %v", err)
+	}
+	if derived, err = store.Create(derived); err != nil {
+		t.Fatalf("create derived: %v", err)
+	}
+
+	regenerated, err := RegenerateDerived(store, templates, base)
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+	}
+	if len(regenerated) != 1 || regenerated[0].ID != derived.ID {
+		t.Fatalf("the other derived monster should be regenerated: %+v", regenerated)
+	}
+}
+
+func TestMemoryTemplateStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryTemplateStore()
+	if _, err := store.Update("zombie", Template{Name: "Зомби"}); !errors.Is(err, ErrTemplateReadOnly) {
+		t.Fatalf("expected ErrTemplateReadOnly, got %v", err)
+	}
+	if _, err := store.Create(Template{ID: "elite", Name: "Копия"}); err == nil {
+		t.Fatal("expected error for built-in id")
+	}
+
+	created, err := store.Create(Template{Name: "Призрачный", Type: "Undead", DamageResistances: []string{"cold"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(store.List()) != len(BuiltinTemplates())+1 {
+		t.Fatalf("unexpected list: %+v", store.List())
+	}
+	if _, err := store.Update(created.ID, Template{Name: "Призрачный", Version: 5}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if err := store.Delete(created.ID, created.Version); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get(created.ID); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+	}
+}
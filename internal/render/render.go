@@ -0,0 +1,94 @@
+// Package render выводит монстров в виде классического блока характеристик.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/monsters"
+)
+
+// Форматы блока характеристик.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatText     = "text"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// formatTypes MIME-типы форматов.
+var formatTypes = map[string]string{
+	FormatMarkdown: "text/markdown",
+	FormatHTML:     "text/html",
+	FormatText:     "text/plain",
+}
+
+// ParseFormat разбирает название формата из параметра запроса.
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "html":
+		return FormatHTML, nil
+	case "text", "txt", "plain":
+		return FormatText, nil
+	}
+	return "", fmt.Errorf("%w %q: expected markdown, html or text", ErrUnknownFormat, name)
+}
+
+// ContentType значение заголовка Content-Type для формата.
+func ContentType(format string) string {
+	return formatTypes[format] + "; charset=utf-8"
+}
+
+// Negotiate выбирает формат по заголовку Accept. Возвращает пустую строку,
+// если клиент предпочитает JSON, принимает что угодно или заголовка нет.
+// При равном весе побеждает тип, указанный в заголовке раньше.
+func Negotiate(accept string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			best, bestQ = "", q
+		case "text/markdown", "text/x-markdown":
+			best, bestQ = FormatMarkdown, q
+		case "text/html":
+			best, bestQ = FormatHTML, q
+		case "text/plain":
+			best, bestQ = FormatText, q
+		}
+	}
+	return best
+}
+
+// StatBlock выводит блок характеристик монстра в выбранном формате.
+func StatBlock(w io.Writer, m monsters.Monster, format string) error {
+	view := newStatBlock(m)
+	switch format {
+	case FormatMarkdown:
+		return markdownTemplate.Execute(w, view)
+	case FormatHTML:
+		return htmlTemplate.Execute(w, view)
+	case FormatText:
+		return textTemplate.Execute(w, view)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
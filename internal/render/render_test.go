@@ -0,0 +1,119 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"dice-service/internal/monsters"
+)
+
+func testMonster() monsters.Monster {
+	return monsters.Monster{
+		Name:            "Гоблин <босс>",
+		Type:            "Humanoid",
+		Size:            "Small",
+		Alignment:       "Neutral Evil",
+		ArmorClass:      15,
+		HitPoints:       7,
+		HitDice:         "2d6",
+		Speed:           "30 ft.",
+		AbilityScores:   map[string]int{"STR": 8, "DEX": 14, "CON": 10, "INT": 10, "WIS": 8, "CHA": 8},
+		Skills:          map[string]int{"Stealth": 6},
+		ChallengeRating: 0.25,
+		Actions: []monsters.Action{
+			{Name: "Scimitar", Type: monsters.ActionMelee, AttackBonus: 4, Reach: 5, Damage: []monsters.Damage{{Dice: "1d6+2", Type: "slashing"}}},
+			{Name: "Shortbow", Type: monsters.ActionRanged, AttackBonus: 4, Range: 80, LongRange: 320, Damage: []monsters.Damage{{Dice: "1d6+2", Type: "piercing"}}},
+		},
+	}
+}
+
+func TestStatBlockFormats(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]string{
+		FormatMarkdown: {
+			`## Гоблин \<босс\>`,
+			"| 8 (-1) | 14 (+2) | 10 (+0) | 10 (+0) | 8 (-1) | 8 (-1) |",
+			"**Опасность** 1/4 (50 опыта)",
+			"***Scimitar.*** Рукопашная атака: +4 к попаданию, досягаемость 5 фт., одна цель. Попадание: 5 (1d6+2) рубящего урона.",
+		},
+		FormatText: {
+			"Small Humanoid, Neutral Evil",
+			"Хиты 7 (2d6)",
+			"8 (-1)   14 (+2)  10 (+0)",
+			"Shortbow. Дальнобойная атака: +4 к попаданию, дистанция 80/320 фт., одна цель.",
+		},
+		FormatHTML: {
+			"<!DOCTYPE html>",
+			"<h1>Гоблин &lt;босс&gt;</h1>",
+			"<h2>Действия</h2>",
+			"Stealth &#43;6",
+		},
+	}
+	for format, want := range cases {
+		var b strings.Builder
+		if err := StatBlock(&b, testMonster(), format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		for _, fragment := range want {
+			if !strings.Contains(b.String(), fragment) {
+				t.Errorf("%s output missing %q:\n%s", format, fragment, b.String())
+			}
+		}
+	}
+
+	if err := StatBlock(&strings.Builder{}, testMonster(), "pdf"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestActionText(t *testing.T) {
+	t.Parallel()
+
+	breath := monsters.Action{
+		Name: "Fire Breath", Type: monsters.ActionSave, SaveDC: 21, SaveAbility: "DEX", Recharge: 5,
+		Damage: []monsters.Damage{{Dice: "18d6", Type: "fire"}}, Description: "60-foot cone.",
+	}
+	if got, want := actionText(breath), "Спасбросок Ловкости СЛ 21. Провал: 63 (18d6) урона огнём. 60-foot cone."; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// Импортированное описание уже содержит механику
+	imported := monsters.Action{Name: "Bite", Type: monsters.ActionMelee, AttackBonus: 4, Description: "Bite. Melee Weapon Attack: +4 to hit."}
+	if got := actionText(imported); got != "Melee Weapon Attack: +4 to hit." {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":                                  "",
+		"*/*":                               "",
+		"application/json":                  "",
+		"text/markdown":                     FormatMarkdown,
+		"text/plain;q=0.5, text/html":       FormatHTML,
+		"application/json, text/html;q=0.9": "",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": FormatHTML,
+	}
+	for accept, want := range cases {
+		if got := Negotiate(accept); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{"md": FormatMarkdown, "HTML": FormatHTML, "txt": FormatText} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseFormat("docx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
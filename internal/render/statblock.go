@@ -0,0 +1,283 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dice-service/internal/dice"
+	"dice-service/internal/monsters"
+)
+
+// abilityLabels подписи характеристик в порядке блока характеристик.
+var abilityLabels = []struct{ key, label, short string }{
+	{"STR", "СИЛ", "Сил"}, {"DEX", "ЛОВ", "Лов"}, {"CON", "ТЕЛ", "Тел"},
+	{"INT", "ИНТ", "Инт"}, {"WIS", "МДР", "Мдр"}, {"CHA", "ХАР", "Хар"},
+}
+
+// saveAbilityNames характеристики спасбросков в родительном падеже.
+var saveAbilityNames = map[string]string{
+	"STR": "Силы", "DEX": "Ловкости", "CON": "Телосложения",
+	"INT": "Интеллекта", "WIS": "Мудрости", "CHA": "Харизмы",
+}
+
+// damageTypeNames названия типов урона: прилагательное перед «урона»
+// или дополнение после него.
+var damageTypeNames = map[string]struct{ before, after string }{
+	"piercing":    {before: "колющего"},
+	"slashing":    {before: "рубящего"},
+	"bludgeoning": {before: "дробящего"},
+	"necrotic":    {before: "некротического"},
+	"psychic":     {before: "психического"},
+	"fire":        {after: "огнём"},
+	"cold":        {after: "холодом"},
+	"lightning":   {after: "электричеством"},
+	"thunder":     {after: "звуком"},
+	"acid":        {after: "кислотой"},
+	"poison":      {after: "ядом"},
+	"radiant":     {after: "излучением"},
+	"force":       {after: "силовым полем"},
+}
+
+type property struct {
+	Label string
+	Value string
+}
+
+type ability struct {
+	Label    string
+	Score    string
+	Modifier string
+}
+
+type entry struct {
+	Name string
+	Text string
+}
+
+type section struct {
+	Title   string // пусто - особенности без заголовка
+	Entries []entry
+}
+
+// statBlock готовые к выводу строки блока характеристик.
+type statBlock struct {
+	Name        string
+	Subtitle    string
+	Properties  []property // КД, хиты, скорость
+	Abilities   []ability
+	Details     []property // спасброски, навыки, иммунитеты, чувства, языки, опасность
+	Sections    []section
+	Description string
+}
+
+func newStatBlock(m monsters.Monster) statBlock {
+	m.Normalize()
+	view := statBlock{Name: m.Name, Description: strings.TrimSpace(m.Description)}
+
+	kind := strings.TrimSpace(m.Size + " " + m.Type)
+	view.Subtitle = kind
+	if m.Alignment != "" {
+		view.Subtitle = strings.TrimPrefix(kind+", "+m.Alignment, ", ")
+	}
+
+	hp := strconv.Itoa(m.HitPoints)
+	if m.HitDice != "" {
+		hp += " (" + m.HitDice + ")"
+	}
+	view.Properties = []property{
+		{"Класс доспеха", strconv.Itoa(m.ArmorClass)},
+		{"Хиты", hp},
+	}
+	if m.Speed != "" {
+		view.Properties = append(view.Properties, property{"Скорость", m.Speed})
+	}
+
+	if len(m.AbilityScores) > 0 {
+		for _, a := range abilityLabels {
+			score, ok := m.AbilityScores[a.key]
+			if !ok {
+				view.Abilities = append(view.Abilities, ability{Label: a.label, Score: "—", Modifier: "—"})
+				continue
+			}
+			view.Abilities = append(view.Abilities, ability{
+				Label:    a.label,
+				Score:    strconv.Itoa(score),
+				Modifier: signed(monsters.AbilityModifier(score)),
+			})
+		}
+	}
+
+	var saves []string
+	for _, a := range abilityLabels {
+		if bonus, ok := m.SavingThrows[a.key]; ok {
+			saves = append(saves, a.short+" "+signed(bonus))
+		}
+	}
+	skillNames := make([]string, 0, len(m.Skills))
+	for name := range m.Skills {
+		skillNames = append(skillNames, name)
+	}
+	sort.Strings(skillNames)
+	skills := make([]string, 0, len(skillNames))
+	for _, name := range skillNames {
+		skills = append(skills, name+" "+signed(m.Skills[name]))
+	}
+
+	for _, d := range []property{
+		{"Спасброски", strings.Join(saves, ", ")},
+		{"Навыки", strings.Join(skills, ", ")},
+		{"Уязвимость к урону", strings.Join(m.DamageVulnerabilities, ", ")},
+		{"Сопротивление урону", strings.Join(m.DamageResistances, ", ")},
+		{"Иммунитет к урону", strings.Join(m.DamageImmunities, ", ")},
+		{"Иммунитет к состояниям", strings.Join(m.ConditionImmunities, ", ")},
+		{"Чувства", m.Senses},
+		{"Языки", strings.Join(m.Languages, ", ")},
+	} {
+		if d.Value != "" {
+			view.Details = append(view.Details, d)
+		}
+	}
+	view.Details = append(view.Details,
+		property{"Опасность", fmt.Sprintf("%s (%s опыта)", m.ChallengeRating, groupDigits(m.XP))},
+		property{"Бонус мастерства", signed(m.ProficiencyBonus)},
+	)
+
+	for _, s := range []section{
+		{Entries: entries(m.Traits)},
+		{Title: "Действия", Entries: entries(m.Actions)},
+		{Title: "Легендарные действия", Entries: entries(m.LegendaryActions)},
+	} {
+		if len(s.Entries) > 0 {
+			view.Sections = append(view.Sections, s)
+		}
+	}
+	return view
+}
+
+// AbilityColumns строка таблицы характеристик для простого текста:
+// подписи или значения с модификаторами, выровненные по столбцам.
+func (s statBlock) AbilityColumns(values bool) string {
+	cells := make([]string, 0, len(s.Abilities))
+	for _, a := range s.Abilities {
+		cell := a.Label
+		if values {
+			cell = a.Score + " (" + a.Modifier + ")"
+		}
+		cells = append(cells, fmt.Sprintf("%-9s", cell))
+	}
+	return strings.TrimRight(strings.Join(cells, ""), " ")
+}
+
+func entries(actions []monsters.Action) []entry {
+	result := make([]entry, 0, len(actions))
+	for _, a := range actions {
+		name := a.Name
+		switch {
+		case a.Recharge == 6:
+			name += " (Перезарядка 6)"
+		case a.Recharge > 0:
+			name += fmt.Sprintf(" (Перезарядка %d–6)", a.Recharge)
+		}
+		if a.UsesPerDay > 0 {
+			name += fmt.Sprintf(" (%d/день)", a.UsesPerDay)
+		}
+		result = append(result, entry{Name: name, Text: actionText(a)})
+	}
+	return result
+}
+
+// actionText текст действия. Описание в старом формате «Название. Текст»
+// (импорт блока характеристик) уже содержит всё; иначе механика действия
+// собирается из полей и дополняется описанием.
+func actionText(a monsters.Action) string {
+	description := strings.TrimSpace(a.Description)
+	if head, rest, found := strings.Cut(description, ". "); found && strings.HasPrefix(head, a.Name) {
+		return rest
+	}
+
+	var parts []string
+	switch a.Type {
+	case monsters.ActionMelee, monsters.ActionRanged:
+		attack := fmt.Sprintf("Рукопашная атака: %s к попаданию, досягаемость %d фт., одна цель.", signed(a.AttackBonus), a.Reach)
+		if a.Type == monsters.ActionRanged {
+			distance := strconv.Itoa(a.Range)
+			if a.LongRange > 0 {
+				distance += "/" + strconv.Itoa(a.LongRange)
+			}
+			attack = fmt.Sprintf("Дальнобойная атака: %s к попаданию, дистанция %s фт., одна цель.", signed(a.AttackBonus), distance)
+		}
+		parts = append(parts, attack)
+		if len(a.Damage) > 0 {
+			parts = append(parts, "Попадание: "+damageText(a.Damage)+".")
+		}
+	case monsters.ActionSave:
+		parts = append(parts, fmt.Sprintf("Спасбросок %s СЛ %d.", saveAbilityNames[a.SaveAbility], a.SaveDC))
+		if len(a.Damage) > 0 {
+			parts = append(parts, "Провал: "+damageText(a.Damage)+".")
+		}
+	default:
+		if len(a.Damage) > 0 {
+			parts = append(parts, "Урон: "+damageText(a.Damage)+".")
+		}
+	}
+	if description != "" {
+		parts = append(parts, description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// damageText «19 (2d10+8) колющего урона плюс 7 (2d6) урона огнём».
+func damageText(damage []monsters.Damage) string {
+	parts := make([]string, 0, len(damage))
+	for _, d := range damage {
+		text := d.Dice
+		if avg, ok := averageDamage(d.Dice); ok && strings.ContainsAny(d.Dice, "dD") {
+			text = fmt.Sprintf("%d (%s)", avg, d.Dice)
+		}
+		name, known := damageTypeNames[d.Type]
+		switch {
+		case d.Type == "":
+			text += " урона"
+		case !known:
+			text += " урона (" + d.Type + ")"
+		case name.before != "":
+			text += " " + name.before + " урона"
+		default:
+			text += " урона " + name.after
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " плюс ")
+}
+
+// averageDamage средний урон выражения (с округлением вниз, как в SRD).
+func averageDamage(text string) (int, bool) {
+	expr, err := dice.ParseExpression(text)
+	if err != nil {
+		flat, convErr := strconv.Atoi(strings.TrimSpace(text))
+		return flat, convErr == nil
+	}
+	return max(0, expr.Average()), true
+}
+
+func signed(value int) string {
+	return fmt.Sprintf("%+d", value)
+}
+
+// groupDigits разбивает число на разряды пробелом: 18000 -> "18 000".
+func groupDigits(value int) string {
+	digits := strconv.Itoa(value)
+	if len(digits) <= 4 {
+		return digits
+	}
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
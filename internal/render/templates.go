@@ -0,0 +1,110 @@
+package render
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// markdownEscaper экранирует символы разметки Markdown в данных монстра.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `|`, `\|`, `#`, `\#`,
+)
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"md": markdownEscaper.Replace,
+}).Parse(`## {{md .Name}}
+{{if .Subtitle}}
+*{{md .Subtitle}}*
+{{end}}
+---
+{{range .Properties}}
+**{{.Label}}** {{md .Value}}
+{{end}}
+---
+{{if .Abilities}}
+|{{range .Abilities}} {{.Label}} |{{end}}
+|{{range .Abilities}}:---:|{{end}}
+|{{range .Abilities}} {{.Score}} ({{.Modifier}}) |{{end}}
+
+---
+{{end}}
+{{- range .Details}}
+**{{.Label}}** {{md .Value}}
+{{end}}
+{{- range .Sections}}
+{{- if .Title}}
+### {{.Title}}
+{{end}}
+{{- range .Entries}}
+***{{md .Name}}.*** {{md .Text}}
+{{end}}
+{{- end}}
+{{- if .Description}}
+---
+
+{{md .Description}}
+{{end}}`))
+
+var textTemplate = template.Must(template.New("text").Parse(`{{.Name}}
+{{if .Subtitle}}{{.Subtitle}}
+{{end}}
+{{range .Properties}}{{.Label}} {{.Value}}
+{{end}}
+{{- if .Abilities}}
+{{.AbilityColumns false}}
+{{.AbilityColumns true}}
+{{end}}
+{{range .Details}}{{.Label}} {{.Value}}
+{{end}}
+{{- range .Sections}}
+{{if .Title}}{{.Title}}
+{{end}}
+{{- range .Entries}}{{.Name}}. {{.Text}}
+{{end}}
+{{- end}}
+{{- if .Description}}
+{{.Description}}
+{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: Georgia, "Times New Roman", serif; margin: 2em; }
+.stat-block { max-width: 420px; padding: 0.6em 1em; background: #fdf1dc; border-top: 4px solid #9c2b1b; border-bottom: 4px solid #9c2b1b; }
+.stat-block h1 { margin: 0; color: #7a200d; font-variant: small-caps; font-size: 1.6em; }
+.stat-block h2 { margin: 0.8em 0 0.3em; color: #7a200d; font-variant: small-caps; font-weight: normal; border-bottom: 1px solid #7a200d; font-size: 1.3em; }
+.subtitle { margin: 0 0 0.4em; font-style: italic; }
+.rule { border: none; border-top: 2px solid #9c2b1b; margin: 0.4em 0; }
+.stat-block p { margin: 0.2em 0; }
+.label { color: #7a200d; font-weight: bold; }
+.abilities { width: 100%; text-align: center; color: #7a200d; }
+.abilities td { color: #000; }
+.description { margin-top: 1em; font-style: italic; }
+@media print { body { margin: 0; } .stat-block { box-shadow: none; } }
+</style>
+</head>
+<body>
+<div class="stat-block">
+<h1>{{.Name}}</h1>
+{{if .Subtitle}}<p class="subtitle">{{.Subtitle}}</p>{{end}}
+<hr class="rule">
+{{range .Properties}}<p><span class="label">{{.Label}}</span> {{.Value}}</p>
+{{end}}<hr class="rule">
+{{if .Abilities}}<table class="abilities">
+<tr>{{range .Abilities}}<th>{{.Label}}</th>{{end}}</tr>
+<tr>{{range .Abilities}}<td>{{.Score}} ({{.Modifier}})</td>{{end}}</tr>
+</table>
+<hr class="rule">
+{{end}}{{range .Details}}<p><span class="label">{{.Label}}</span> {{.Value}}</p>
+{{end}}{{range .Sections}}{{if .Title}}<h2>{{.Title}}</h2>
+{{end}}{{range .Entries}}<p><strong><em>{{.Name}}.</em></strong> {{.Text}}</p>
+{{end}}{{end}}</div>
+{{if .Description}}<p class="description">{{.Description}}</p>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,278 @@
+package search
+
+import (
+	"html"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"dice-service/internal/characters"
+	"dice-service/internal/company"
+	"dice-service/internal/monsters"
+)
+
+// Веса совпадений: в названии важнее, чем в остальном тексте.
+const (
+	nameWeight = 2.0
+	bodyWeight = 1.0
+)
+
+// snippetWords число слов вокруг совпадения во фрагменте подсветки.
+const snippetWords = 6
+
+// Document индексируемый текст ресурса.
+type Document struct {
+	Type   string
+	ID     string
+	Name   string
+	Fields []string // описание, особенности, действия и т.п.
+}
+
+// Source возвращает актуальные документы одного типа ресурсов.
+type Source func() []Document
+
+type docKey struct {
+	resourceType string
+	id           string
+}
+
+// MemoryIndex простой инвертированный индекс для режима без базы данных.
+// Перед каждым поиском индекс сверяется с источниками и переиндексирует
+// только изменившиеся документы.
+type MemoryIndex struct {
+	mu       sync.Mutex
+	sources  []Source
+	docs     map[docKey]Document
+	postings map[string]map[docKey]float64 // слово -> документ -> вес
+}
+
+func NewMemoryIndex(sources ...Source) *MemoryIndex {
+	return &MemoryIndex{
+		sources:  sources,
+		docs:     make(map[docKey]Document),
+		postings: make(map[string]map[docKey]float64),
+	}
+}
+
+// CharacterSource, MonsterSource и CompanySource источники документов
+// из хранилищ.
+func CharacterSource(store characters.Store) Source {
+	return func() []Document {
+		list := store.List()
+		docs := make([]Document, 0, len(list))
+		for _, sheet := range list {
+			fields := []string{sheet.Class, sheet.Race, sheet.Background, sheet.Alignment}
+			for _, item := range sheet.Items {
+				fields = append(fields, item.Name)
+			}
+			docs = append(docs, Document{Type: TypeCharacter, ID: sheet.ID, Name: sheet.Name, Fields: fields})
+		}
+		return docs
+	}
+}
+
+func MonsterSource(store monsters.Store) Source {
+	return func() []Document {
+		list := store.List()
+		docs := make([]Document, 0, len(list))
+		for _, m := range list {
+			fields := []string{m.Type, m.Description}
+			for _, list := range [][]monsters.Action{m.Traits, m.Actions, m.LegendaryActions} {
+				for _, a := range list {
+					fields = append(fields, a.Name, a.Description)
+				}
+			}
+			docs = append(docs, Document{Type: TypeMonster, ID: m.ID, Name: m.Name, Fields: fields})
+		}
+		return docs
+	}
+}
+
+func CompanySource(store company.Store) Source {
+	return func() []Document {
+		list := store.List()
+		docs := make([]Document, 0, len(list))
+		for _, c := range list {
+			docs = append(docs, Document{Type: TypeCompany, ID: c.ID, Name: c.Name, Fields: []string{c.Description}})
+		}
+		return docs
+	}
+}
+
+func (idx *MemoryIndex) Search(q Query) ([]Result, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.refresh()
+
+	terms := tokenize(q.Text)
+	if len(terms) == 0 {
+		return []Result{}, nil
+	}
+
+	// Документ должен содержать все слова запроса (как префиксы слов текста)
+	var scores map[docKey]float64
+	for _, term := range terms {
+		matched := make(map[docKey]float64)
+		for word, postings := range idx.postings {
+			if !strings.HasPrefix(word, term) {
+				continue
+			}
+			idf := math.Log(1 + float64(len(idx.docs))/float64(len(postings)))
+			for key, weight := range postings {
+				matched[key] += weight * idf
+			}
+		}
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for key := range scores {
+			if extra, ok := matched[key]; ok {
+				scores[key] += extra
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for key, score := range scores {
+		if !q.includes(key.resourceType) {
+			continue
+		}
+		doc := idx.docs[key]
+		results = append(results, Result{
+			Type:       doc.Type,
+			ID:         doc.ID,
+			Name:       doc.Name,
+			Rank:       score,
+			Highlights: highlight(doc, terms),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Name < results[j].Name
+	})
+	if len(results) > q.limit() {
+		results = results[:q.limit()]
+	}
+	return results, nil
+}
+
+// refresh приводит индекс в соответствие с источниками.
+func (idx *MemoryIndex) refresh() {
+	seen := make(map[docKey]bool, len(idx.docs))
+	for _, source := range idx.sources {
+		for _, doc := range source() {
+			key := docKey{doc.Type, doc.ID}
+			seen[key] = true
+			if indexed, ok := idx.docs[key]; ok && indexed.Name == doc.Name && slices.Equal(indexed.Fields, doc.Fields) {
+				continue
+			}
+			idx.remove(key)
+			idx.add(key, doc)
+		}
+	}
+	for key := range idx.docs {
+		if !seen[key] {
+			idx.remove(key)
+		}
+	}
+}
+
+func (idx *MemoryIndex) add(key docKey, doc Document) {
+	idx.docs[key] = doc
+	addWords := func(text string, weight float64) {
+		for _, word := range tokenize(text) {
+			if idx.postings[word] == nil {
+				idx.postings[word] = make(map[docKey]float64)
+			}
+			idx.postings[word][key] += weight
+		}
+	}
+	addWords(doc.Name, nameWeight)
+	for _, field := range doc.Fields {
+		addWords(field, bodyWeight)
+	}
+}
+
+func (idx *MemoryIndex) remove(key docKey) {
+	doc, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	delete(idx.docs, key)
+	for _, text := range append([]string{doc.Name}, doc.Fields...) {
+		for _, word := range tokenize(text) {
+			delete(idx.postings[word], key)
+			if len(idx.postings[word]) == 0 {
+				delete(idx.postings, word)
+			}
+		}
+	}
+}
+
+// highlight возвращает фрагменты полей с совпадениями, обрамлёнными
+// HighlightStart и HighlightStop. Текст полей экранируется как HTML.
+func highlight(doc Document, terms []string) []string {
+	fragments := []string{}
+	for _, text := range append([]string{doc.Name}, doc.Fields...) {
+		if fragment, ok := highlightText(text, terms); ok {
+			fragments = append(fragments, fragment)
+		}
+		if len(fragments) == 3 {
+			break
+		}
+	}
+	return fragments
+}
+
+func highlightText(text string, terms []string) (string, bool) {
+	words := strings.Fields(text)
+	first := -1
+	marked := make([]string, len(words))
+	for i, word := range words {
+		marked[i] = html.EscapeString(word)
+		core := strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if core == "" {
+			continue
+		}
+		lower := strings.ToLower(core)
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				start := strings.Index(word, core)
+				marked[i] = html.EscapeString(word[:start]) + HighlightStart + html.EscapeString(core) +
+					HighlightStop + html.EscapeString(word[start+len(core):])
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	from := max(first-snippetWords, 0)
+	to := min(first+snippetWords+1, len(marked))
+	fragment := strings.Join(marked[from:to], " ")
+	if from > 0 {
+		fragment = "… " + fragment
+	}
+	if to < len(marked) {
+		fragment += " …"
+	}
+	return fragment, true
+}
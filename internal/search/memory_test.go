@@ -0,0 +1,68 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemoryIndexSearch(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{Type: TypeMonster, ID: "m1", Name: "Red Dragon", Fields: []string{"Dragon", "Fire Breath (Recharge 5-6). The dragon exhales fire."}},
+		{Type: TypeMonster, ID: "m2", Name: "Fire Elemental", Fields: []string{"Elemental", "Fire Form"}},
+		{Type: TypeMonster, ID: "m3", Name: "Огненный змей", Fields: []string{"Дыхание огнём"}},
+		{Type: TypeCompany, ID: "c1", Name: "Fire Breath Society", Fields: []string{"Кампания о драконах"}},
+	}
+	idx := NewMemoryIndex(func() []Document { return docs })
+
+	results, err := idx.Search(Query{Text: "fire breath"})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	// Совпадение в названии ранжируется выше
+	if results[0].ID != "c1" || results[0].Type != TypeCompany {
+		t.Fatalf("expected company first, got %+v", results[0])
+	}
+	if len(results[1].Highlights) == 0 || !strings.Contains(results[1].Highlights[0], "<mark>Fire</mark>") {
+		t.Fatalf("unexpected highlights: %v", results[1].Highlights)
+	}
+
+	results, err = idx.Search(Query{Text: "дыхание", Types: []string{TypeMonster}})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "m3" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	// Изменённые и удалённые документы переиндексируются при следующем поиске
+	docs = docs[1:2]
+	results, err = idx.Search(Query{Text: "dragon"})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected removed documents to disappear, got %+v", results)
+	}
+
+	if _, err := idx.Search(Query{Text: "  "}); err == nil {
+		t.Fatal("expected error for empty query")
+	}
+}
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	t.Parallel()
+
+	fragment, ok := highlightText(`<script>alert(1)</script> "Fire" & <b>bolt</b>`, []string{"fire"})
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	want := `&lt;script&gt;alert(1)&lt;/script&gt; &#34;<mark>Fire</mark>&#34; &amp; &lt;b&gt;bolt&lt;/b&gt;`
+	if fragment != want {
+		t.Fatalf("unexpected fragment:\n got %s\nwant %s", fragment, want)
+	}
+}
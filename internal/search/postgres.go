@@ -0,0 +1,151 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// headlineDelimiter разделитель фрагментов ts_headline.
+const headlineDelimiter = " … "
+
+// Границы совпадений в ts_headline: символы из области частного
+// использования не встречаются в тексте, поэтому фрагмент можно
+// экранировать как HTML и лишь затем заменить их на HighlightStart и
+// HighlightStop.
+const (
+	headlineStartSel = "\uE000"
+	headlineStopSel  = "\uE001"
+)
+
+var headlineMarks = strings.NewReplacer(headlineStartSel, HighlightStart, headlineStopSel, HighlightStop)
+
+// searchTable описывает полнотекстовый индекс одной таблицы.
+type searchTable struct {
+	resourceType string
+	table        string
+	body         string // SQL-выражение с текстом ресурса без названия
+}
+
+// Текст собирается из JSON-полей; translate убирает скобки и кавычки
+// массивов, чтобы они не попадали во фрагменты подсветки. Из действий
+// монстров берутся все строковые значения (название, описание, тип урона),
+// в том числе записи старого формата-строки.
+var searchTables = []searchTable{
+	{
+		resourceType: TypeCharacter,
+		table:        "characters",
+		body: `coalesce(data->>'class', '') || ' ' || coalesce(data->>'race', '') || ' ' ||
+			coalesce(data->>'background', '') || ' ' || coalesce(data->>'alignment', '')`,
+	},
+	{
+		resourceType: TypeMonster,
+		table:        "monsters",
+		body: `coalesce(data->>'type', '') || ' ' || coalesce(data->>'description', '') || ' ' ||
+			translate(jsonb_path_query_array(data, '$.traits[*].** ? (@.type() == "string")')::text, '[]",', '    ') || ' ' ||
+			translate(jsonb_path_query_array(data, '$.actions[*].** ? (@.type() == "string")')::text, '[]",', '    ') || ' ' ||
+			translate(jsonb_path_query_array(data, '$.legendaryActions[*].** ? (@.type() == "string")')::text, '[]",', '    ')`,
+	},
+	{
+		resourceType: TypeCompany,
+		table:        "companies",
+		body:         `coalesce(data->>'description', '')`,
+	},
+}
+
+// searchSchemaVersion меняется вместе с выражениями searchTables: колонка
+// search_vector с другой версией в комментарии пересоздаётся.
+const searchSchemaVersion = "2"
+
+// vector выражение tsvector по русской и английской конфигурациям;
+// совпадения в названии весят больше (A), чем в остальном тексте (B).
+func (t searchTable) vector() string {
+	return fmt.Sprintf(`setweight(to_tsvector('russian', name), 'A') ||
+		setweight(to_tsvector('english', name), 'A') ||
+		setweight(to_tsvector('russian', %[1]s), 'B') ||
+		setweight(to_tsvector('english', %[1]s), 'B')`, t.body)
+}
+
+// PostgresSearcher поиск по tsvector-колонкам таблиц хранилищ.
+type PostgresSearcher struct {
+	db *sql.DB
+}
+
+// NewPostgresSearcher добавляет в таблицы characters, monsters и companies
+// вычисляемую колонку search_vector с GIN-индексом. Таблицы должны быть
+// созданы хранилищами заранее.
+func NewPostgresSearcher(db *sql.DB) *PostgresSearcher {
+	for _, t := range searchTables {
+		migrateColumn := fmt.Sprintf(`
+DO $$
+BEGIN
+	IF coalesce(col_description('%[1]s'::regclass,
+		(SELECT attnum FROM pg_attribute WHERE attrelid = '%[1]s'::regclass AND attname = 'search_vector')), '') <> '%[3]s' THEN
+		ALTER TABLE %[1]s DROP COLUMN IF EXISTS search_vector;
+		ALTER TABLE %[1]s ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (%[2]s) STORED;
+		COMMENT ON COLUMN %[1]s.search_vector IS '%[3]s';
+	END IF;
+END $$;`, t.table, t.vector(), searchSchemaVersion)
+		if _, err := db.Exec(migrateColumn); err != nil {
+			panic(fmt.Errorf("failed to add search column to %s: %w", t.table, err))
+		}
+
+		createIndex := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_search_idx ON %[1]s USING GIN (search_vector);`, t.table)
+		if _, err := db.Exec(createIndex); err != nil {
+			panic(fmt.Errorf("failed to create search index on %s: %w", t.table, err))
+		}
+	}
+	return &PostgresSearcher{db: db}
+}
+
+func (s *PostgresSearcher) Search(q Query) ([]Result, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	headlineOptions := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=3, FragmentDelimiter=\"%s\"",
+		headlineStartSel, headlineStopSel, headlineDelimiter)
+
+	var selects []string
+	for _, t := range searchTables {
+		if !q.includes(t.resourceType) {
+			continue
+		}
+		selects = append(selects, fmt.Sprintf(`SELECT '%s' AS type, id, name,
+		ts_rank(search_vector, sq.q) AS rank,
+		ts_headline('russian', name || ' ' || %s, sq.q, $2) AS headline
+	FROM %s, sq
+	WHERE search_vector @@ sq.q`, t.resourceType, t.body, t.table))
+	}
+
+	searchQuery := `WITH sq AS (
+	SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS q
+)
+` + strings.Join(selects, "\nUNION ALL\n") + `
+ORDER BY rank DESC, name
+LIMIT $3;`
+
+	rows, err := s.db.Query(searchQuery, q.Text, headlineOptions, q.limit())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	results := []Result{}
+	for rows.Next() {
+		var r Result
+		var headline string
+		if err := rows.Scan(&r.Type, &r.ID, &r.Name, &r.Rank, &headline); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		r.Highlights = []string{}
+		for _, fragment := range strings.Split(headline, headlineDelimiter) {
+			if strings.Contains(fragment, headlineStartSel) {
+				r.Highlights = append(r.Highlights, headlineMarks.Replace(html.EscapeString(strings.TrimSpace(fragment))))
+			}
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
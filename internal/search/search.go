@@ -0,0 +1,89 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Типы ресурсов в результатах поиска.
+const (
+	TypeCharacter = "character"
+	TypeMonster   = "monster"
+	TypeCompany   = "company"
+)
+
+// Границы подсветки совпадений во фрагментах.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// DefaultLimit и MaxLimit размер выдачи по умолчанию и наибольший.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Result найденный ресурс.
+type Result struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Rank       float64  `json:"rank"`
+	Highlights []string `json:"highlights"` // фрагменты текста с <mark>совпадениями</mark>
+}
+
+// Query поисковый запрос.
+type Query struct {
+	Text  string
+	Types []string // пусто - все типы ресурсов
+	Limit int      // 0 - DefaultLimit
+}
+
+// Searcher полнотекстовый поиск по персонажам, монстрам и компаниям.
+type Searcher interface {
+	Search(q Query) ([]Result, error)
+}
+
+func (q Query) Validate() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return errors.New("search query is required")
+	}
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	for _, t := range q.Types {
+		if t != TypeCharacter && t != TypeMonster && t != TypeCompany {
+			return fmt.Errorf("unknown resource type %q", t)
+		}
+	}
+	return nil
+}
+
+func (q Query) limit() int {
+	if q.Limit == 0 {
+		return DefaultLimit
+	}
+	return q.Limit
+}
+
+func (q Query) includes(resourceType string) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if t == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenize разбивает текст на слова в нижнем регистре.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}